@@ -97,5 +97,510 @@ DROP CONSTRAINT customer_psychologist_price_psychologist_id_fkey,
 ADD CONSTRAINT customer_psychologist_price_psychologist_id_fkey
 FOREIGN KEY (psychologist_id) REFERENCES psychologists(id) ON DELETE CASCADE;
 
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE appointments
+ADD CONSTRAINT appointments_time_range_check CHECK (end_time > start_time),
+ADD CONSTRAINT appointments_psychologist_no_overlap
+EXCLUDE USING gist (psychologist_id WITH =, tsrange(start_time, end_time) WITH &&),
+ADD CONSTRAINT appointments_customer_no_overlap
+EXCLUDE USING gist (customer_id WITH =, tsrange(start_time, end_time) WITH &&);
+
+CREATE TABLE appointment_status_history (
+    id SERIAL PRIMARY KEY,
+    appointment_id INT NOT NULL REFERENCES appointments(id) ON DELETE CASCADE,
+    from_status VARCHAR(50),
+    to_status VARCHAR(50) NOT NULL,
+    actor VARCHAR(50),
+    reason TEXT,
+    changed_by INT,
+    created_at TIMESTAMP
+);
+
+UPDATE appointments SET status = 'requested'
+WHERE status IS NULL OR status NOT IN ('requested', 'confirmed', 'cancelled_by_customer', 'cancelled_by_psychologist', 'completed', 'no_show');
+
+ALTER TABLE appointments
+ALTER COLUMN status SET DEFAULT 'requested',
+ALTER COLUMN status SET NOT NULL,
+ADD CONSTRAINT appointments_status_check
+CHECK (status IN ('requested', 'confirmed', 'cancelled_by_customer', 'cancelled_by_psychologist', 'completed', 'no_show')),
+DROP CONSTRAINT appointments_psychologist_no_overlap,
+ADD CONSTRAINT appointments_psychologist_no_overlap
+EXCLUDE USING gist (psychologist_id WITH =, tsrange(start_time, end_time) WITH &&)
+WHERE (status NOT IN ('cancelled_by_customer', 'cancelled_by_psychologist')),
+DROP CONSTRAINT appointments_customer_no_overlap,
+ADD CONSTRAINT appointments_customer_no_overlap
+EXCLUDE USING gist (customer_id WITH =, tsrange(start_time, end_time) WITH &&)
+WHERE (status NOT IN ('cancelled_by_customer', 'cancelled_by_psychologist'));
+
+CREATE TABLE availability_exceptions (
+    id SERIAL PRIMARY KEY,
+    psychologist_id INT NOT NULL REFERENCES psychologists(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('block', 'extra')),
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    all_day BOOLEAN NOT NULL DEFAULT FALSE,
+    start_time TIME,
+    end_time TIME,
+    reason TEXT,
+    created_by INT,
+    updated_by INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    CHECK (end_date >= start_date),
+    CHECK (all_day OR (start_time IS NOT NULL AND end_time IS NOT NULL))
+);
+
+CREATE INDEX availability_exceptions_psychologist_dates_idx
+ON availability_exceptions (psychologist_id, start_date, end_date);
+
+ALTER TABLE psychologists
+ADD COLUMN time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE appointments
+DROP CONSTRAINT appointments_psychologist_no_overlap,
+DROP CONSTRAINT appointments_customer_no_overlap;
+
+ALTER TABLE appointments
+ALTER COLUMN start_time TYPE timestamp with time zone USING start_time AT TIME ZONE 'UTC',
+ALTER COLUMN end_time TYPE timestamp with time zone USING end_time AT TIME ZONE 'UTC';
+
+ALTER TABLE appointments
+ADD CONSTRAINT appointments_psychologist_no_overlap
+EXCLUDE USING gist (psychologist_id WITH =, tstzrange(start_time, end_time) WITH &&)
+WHERE (status NOT IN ('cancelled_by_customer', 'cancelled_by_psychologist')),
+ADD CONSTRAINT appointments_customer_no_overlap
+EXCLUDE USING gist (customer_id WITH =, tstzrange(start_time, end_time) WITH &&)
+WHERE (status NOT IN ('cancelled_by_customer', 'cancelled_by_psychologist'));
+
+CREATE TABLE appointment_series (
+    id SERIAL PRIMARY KEY,
+    psychologist_id INT NOT NULL REFERENCES psychologists(id) ON DELETE CASCADE,
+    customer_id INT NOT NULL,
+    start_time timestamp with time zone NOT NULL,
+    end_time timestamp with time zone NOT NULL,
+    rrule TEXT NOT NULL,
+    created_by INT,
+    updated_by INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    CHECK (end_time > start_time)
+);
+
+ALTER TABLE appointments
+ADD COLUMN series_id INT REFERENCES appointment_series(id) ON DELETE SET NULL;
+
+CREATE INDEX appointments_series_id_idx ON appointments (series_id);
+
+ALTER TABLE appointments
+ADD COLUMN sequence INT NOT NULL DEFAULT 0;
+
+CREATE TABLE calendar_feeds (
+    id SERIAL PRIMARY KEY,
+    owner_type VARCHAR(20) NOT NULL CHECK (owner_type IN ('psychologist', 'customer')),
+    owner_id INT NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    revoked_at TIMESTAMP,
+    created_by INT,
+    created_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX calendar_feeds_active_owner_idx
+ON calendar_feeds (owner_type, owner_id) WHERE revoked_at IS NULL;
+
+CREATE TABLE calendar_imports (
+    id SERIAL PRIMARY KEY,
+    psychologist_id INT NOT NULL REFERENCES psychologists(id) ON DELETE CASCADE,
+    name VARCHAR(255),
+    source TEXT,
+    event_count INT NOT NULL DEFAULT 0,
+    last_imported_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_by INT,
+    updated_by INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+CREATE TABLE external_busy_blocks (
+    id SERIAL PRIMARY KEY,
+    import_id INT NOT NULL REFERENCES calendar_imports(id) ON DELETE CASCADE,
+    psychologist_id INT NOT NULL REFERENCES psychologists(id) ON DELETE CASCADE,
+    uid TEXT,
+    start_time timestamp with time zone NOT NULL,
+    end_time timestamp with time zone NOT NULL,
+    created_at TIMESTAMP,
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX external_busy_blocks_psychologist_time_idx
+ON external_busy_blocks (psychologist_id, start_time, end_time);
+
+CREATE TABLE psychologist_scheduling_settings (
+    psychologist_id INT PRIMARY KEY REFERENCES psychologists(id) ON DELETE CASCADE,
+    session_minutes INT NOT NULL DEFAULT 60 CHECK (session_minutes BETWEEN 5 AND 480),
+    buffer_before_minutes INT NOT NULL DEFAULT 0 CHECK (buffer_before_minutes BETWEEN 0 AND 240),
+    buffer_after_minutes INT NOT NULL DEFAULT 0 CHECK (buffer_after_minutes BETWEEN 0 AND 240),
+    slot_step_minutes INT NOT NULL DEFAULT 60 CHECK (slot_step_minutes BETWEEN 5 AND 480),
+    min_notice_minutes INT NOT NULL DEFAULT 0 CHECK (min_notice_minutes >= 0),
+    max_advance_days INT NOT NULL DEFAULT 365 CHECK (max_advance_days BETWEEN 1 AND 730),
+    updated_by INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE TABLE waitlist_entries (
+    id SERIAL PRIMARY KEY,
+    psychologist_id INT NOT NULL REFERENCES psychologists(id) ON DELETE CASCADE,
+    customer_id INT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    preferred_windows JSONB NOT NULL DEFAULT '[]',
+    note TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'waiting'
+        CHECK (status IN ('waiting', 'offered', 'booked', 'withdrawn')),
+    created_by INT,
+    updated_by INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX waitlist_entries_active_idx
+ON waitlist_entries (psychologist_id, customer_id)
+WHERE status IN ('waiting', 'offered');
+
+CREATE TABLE waitlist_offers (
+    id SERIAL PRIMARY KEY,
+    entry_id INT NOT NULL REFERENCES waitlist_entries(id) ON DELETE CASCADE,
+    psychologist_id INT NOT NULL REFERENCES psychologists(id) ON DELETE CASCADE,
+    customer_id INT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    source_appointment_id INT,
+    appointment_id INT REFERENCES appointments(id) ON DELETE SET NULL,
+    start_time timestamp with time zone NOT NULL,
+    end_time timestamp with time zone NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'accepted', 'declined', 'expired')),
+    expires_at timestamp with time zone NOT NULL,
+    responded_at timestamp with time zone,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX waitlist_offers_psychologist_time_idx
+ON waitlist_offers (psychologist_id, start_time, end_time);
+
+CREATE INDEX waitlist_offers_pending_idx
+ON waitlist_offers (expires_at)
+WHERE status = 'pending';
+
+DELETE FROM psychologist_specializations AS duplicate
+USING psychologist_specializations AS original
+WHERE duplicate.psychologist_id = original.psychologist_id
+  AND duplicate.specialization_id = original.specialization_id
+  AND duplicate.id > original.id;
+
+DELETE FROM psychologist_specializations
+WHERE psychologist_id IS NULL OR specialization_id IS NULL;
+
+ALTER TABLE psychologist_specializations
+    ALTER COLUMN psychologist_id SET NOT NULL,
+    ALTER COLUMN specialization_id SET NOT NULL,
+    ADD CONSTRAINT psychologist_specializations_unique UNIQUE (psychologist_id, specialization_id);
+
+CREATE TABLE psychologist_languages (
+    id SERIAL PRIMARY KEY,
+    psychologist_id INT NOT NULL REFERENCES psychologists(id) ON DELETE CASCADE,
+    language_code VARCHAR(3) NOT NULL CHECK (language_code ~ '^[a-z]{2,3}$'),
+    created_by INT,
+    created_at TIMESTAMP,
+    UNIQUE (psychologist_id, language_code)
+);
+
+CREATE INDEX psychologist_languages_code_idx ON psychologist_languages (language_code, psychologist_id);
+
+CREATE INDEX psychologist_specializations_specialization_idx
+ON psychologist_specializations (specialization_id, psychologist_id);
+
+CREATE INDEX consultation_pricing_psychologist_currency_idx
+ON consultation_pricing (psychologist_id, currency, price);
+
+-- Use a real Ukrainian configuration when one is installed (e.g. from hunspell dictionaries),
+-- otherwise fall back to one that only lower-cases words.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'ukrainian') THEN
+        CREATE TEXT SEARCH CONFIGURATION ukrainian (COPY = simple);
+    END IF;
+END
+$$;
+
+ALTER TABLE psychologists ADD COLUMN search_vector tsvector;
+
+CREATE FUNCTION psychologists_search_vector_update() RETURNS trigger AS $$
+DECLARE
+    full_name TEXT := coalesce(NEW.first_name, '') || ' ' || coalesce(NEW.last_name, '');
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('english', full_name), 'A') ||
+        setweight(to_tsvector('ukrainian', full_name), 'A') ||
+        setweight(to_tsvector('english', coalesce(NEW.bio, '')), 'B') ||
+        setweight(to_tsvector('ukrainian', coalesce(NEW.bio, '')), 'B');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER psychologists_search_vector_trigger
+BEFORE INSERT OR UPDATE OF first_name, last_name, bio ON psychologists
+FOR EACH ROW EXECUTE FUNCTION psychologists_search_vector_update();
+
+UPDATE psychologists SET bio = bio;
+
+CREATE INDEX psychologists_search_vector_idx ON psychologists USING GIN (search_vector);
+
+CREATE TABLE psychologist_modalities (
+    id SERIAL PRIMARY KEY,
+    psychologist_id INT NOT NULL REFERENCES psychologists(id) ON DELETE CASCADE,
+    modality VARCHAR(20) NOT NULL CHECK (modality IN ('online', 'in_person')),
+    created_by INT,
+    created_at TIMESTAMP,
+    UNIQUE (psychologist_id, modality)
+);
+
+CREATE INDEX psychologist_modalities_modality_idx ON psychologist_modalities (modality, psychologist_id);
+
+CREATE TABLE office_locations (
+    id SERIAL PRIMARY KEY,
+    psychologist_id INT NOT NULL REFERENCES psychologists(id) ON DELETE CASCADE,
+    label VARCHAR(100),
+    address_line1 VARCHAR(200) NOT NULL,
+    address_line2 VARCHAR(200),
+    city VARCHAR(100) NOT NULL,
+    postal_code VARCHAR(20),
+    country VARCHAR(2) NOT NULL,
+    created_by INT,
+    updated_by INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+CREATE INDEX office_locations_psychologist_idx ON office_locations (psychologist_id);
+CREATE INDEX office_locations_city_idx ON office_locations (lower(city), psychologist_id);
+
+-- Sessions booked before modalities existed were held online.
+INSERT INTO psychologist_modalities (psychologist_id, modality, created_at)
+SELECT id, 'online', now() FROM psychologists;
+
+ALTER TABLE appointments
+ADD COLUMN modality VARCHAR(20) NOT NULL DEFAULT 'online' CHECK (modality IN ('online', 'in_person')),
+ADD COLUMN office_location_id INT REFERENCES office_locations(id) ON DELETE RESTRICT,
+ADD CONSTRAINT appointments_office_location_check CHECK ((modality = 'in_person') = (office_location_id IS NOT NULL));
+
+ALTER TABLE appointments ALTER COLUMN modality DROP DEFAULT;
+
+ALTER TABLE appointment_series
+ADD COLUMN modality VARCHAR(20) NOT NULL DEFAULT 'online' CHECK (modality IN ('online', 'in_person')),
+ADD COLUMN office_location_id INT REFERENCES office_locations(id) ON DELETE RESTRICT;
+
+ALTER TABLE appointment_series ALTER COLUMN modality DROP DEFAULT;
+
+CREATE TABLE reviews (
+    id SERIAL PRIMARY KEY,
+    psychologist_id INT NOT NULL REFERENCES psychologists(id) ON DELETE CASCADE,
+    customer_id INT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    text TEXT,
+    reply TEXT,
+    replied_at timestamp with time zone,
+    hidden BOOLEAN NOT NULL DEFAULT false,
+    hidden_reason TEXT,
+    editable_until timestamp with time zone NOT NULL,
+    created_by INT,
+    updated_by INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    UNIQUE (psychologist_id, customer_id)
+);
+
+CREATE INDEX reviews_psychologist_idx ON reviews (psychologist_id, created_at);
+
+ALTER TABLE psychologists
+ADD COLUMN average_rating NUMERIC(3, 2),
+ADD COLUMN review_count INT NOT NULL DEFAULT 0;
+
+CREATE FUNCTION psychologists_rating_update() RETURNS trigger AS $$
+DECLARE
+    target INT;
+BEGIN
+    FOR target IN
+        SELECT DISTINCT ids.id FROM (VALUES (CASE WHEN TG_OP <> 'INSERT' THEN OLD.psychologist_id END),
+                                            (CASE WHEN TG_OP <> 'DELETE' THEN NEW.psychologist_id END)) AS ids(id)
+        WHERE ids.id IS NOT NULL
+    LOOP
+        UPDATE psychologists SET
+            average_rating = (SELECT round(avg(rating), 2) FROM reviews WHERE psychologist_id = target AND NOT hidden),
+            review_count = (SELECT count(*) FROM reviews WHERE psychologist_id = target AND NOT hidden)
+        WHERE id = target;
+    END LOOP;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER reviews_rating_trigger
+AFTER INSERT OR DELETE OR UPDATE OF rating, hidden, psychologist_id ON reviews
+FOR EACH ROW EXECUTE FUNCTION psychologists_rating_update();
+
+CREATE INDEX psychologists_rating_idx ON psychologists (average_rating DESC NULLS LAST);
+
+ALTER TABLE psychologists
+ADD COLUMN onboarding_status VARCHAR(20) NOT NULL DEFAULT 'draft'
+    CHECK (onboarding_status IN ('draft', 'submitted', 'verified', 'suspended')),
+ADD COLUMN license_number VARCHAR(100),
+ADD COLUMN license_issuer VARCHAR(200),
+ADD COLUMN submitted_at timestamp with time zone,
+ADD COLUMN verified_at timestamp with time zone,
+ADD COLUMN status_reason TEXT;
+
+-- Psychologists listed before onboarding existed stay in the directory.
+UPDATE psychologists SET onboarding_status = 'verified', verified_at = now();
+
+CREATE INDEX psychologists_onboarding_idx ON psychologists (onboarding_status, submitted_at);
+
+CREATE TABLE psychologist_onboarding_history (
+    id SERIAL PRIMARY KEY,
+    psychologist_id INT NOT NULL REFERENCES psychologists(id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    reason TEXT,
+    changed_by INT,
+    created_at TIMESTAMP
+);
+
+CREATE INDEX psychologist_onboarding_history_psychologist_idx ON psychologist_onboarding_history (psychologist_id, created_at);
+
+CREATE TABLE credential_documents (
+    id SERIAL PRIMARY KEY,
+    psychologist_id INT NOT NULL REFERENCES psychologists(id) ON DELETE CASCADE,
+    storage_name TEXT UNIQUE NOT NULL,
+    original_name TEXT NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    created_by INT,
+    created_at TIMESTAMP
+);
+
+CREATE INDEX credential_documents_psychologist_idx ON credential_documents (psychologist_id);
+
+ALTER TABLE psychologists
+ADD COLUMN gender VARCHAR(20) CHECK (gender IN ('female', 'male', 'non_binary'));
+
+CREATE TABLE intake_questions (
+    id SERIAL PRIMARY KEY,
+    key VARCHAR(20) UNIQUE NOT NULL
+        CHECK (key IN ('concerns', 'gender', 'language', 'budget', 'modality', 'time_of_day')),
+    prompt TEXT NOT NULL,
+    help_text TEXT,
+    options JSONB,
+    required BOOLEAN NOT NULL DEFAULT false,
+    position INT NOT NULL DEFAULT 0,
+    weight NUMERIC(4, 2) NOT NULL DEFAULT 0 CHECK (weight >= 0),
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_by INT,
+    updated_by INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+INSERT INTO intake_questions (key, prompt, options, required, position, active, created_at, updated_at)
+VALUES ('concerns', 'What would you like help with?', NULL, true, 1, true, now(), now()),
+       ('language', 'Which language would you like to talk in?', NULL, false, 2, true, now(), now()),
+       ('modality', 'How would you like to meet?',
+        '[{"value": "online", "label": "Online"}, {"value": "in_person", "label": "In person"}]', false, 3, true, now(), now()),
+       ('budget', 'How much can you pay per session?', NULL, false, 4, true, now(), now()),
+       ('time_of_day', 'When do you prefer to have sessions?',
+        '[{"value": "morning", "label": "Morning"}, {"value": "afternoon", "label": "Afternoon"}, {"value": "evening", "label": "Evening"}]',
+        false, 5, true, now(), now()),
+       ('gender', 'Do you prefer a psychologist of a particular gender?',
+        '[{"value": "female", "label": "Female"}, {"value": "male", "label": "Male"}, {"value": "non_binary", "label": "Non-binary"}]',
+        false, 6, true, now(), now());
+
+CREATE TABLE intake_responses (
+    id SERIAL PRIMARY KEY,
+    customer_id INT REFERENCES customers(id) ON DELETE CASCADE,
+    answers JSONB NOT NULL,
+    created_by INT,
+    created_at TIMESTAMP
+);
+
+CREATE INDEX intake_responses_customer_idx ON intake_responses (customer_id, created_at);
+
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) UNIQUE NOT NULL,
+    password_hash TEXT,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('admin', 'psychologist', 'customer')),
+    psychologist_id INT UNIQUE REFERENCES psychologists(id) ON DELETE CASCADE,
+    customer_id INT UNIQUE REFERENCES customers(id) ON DELETE CASCADE,
+    last_login_at timestamp with time zone,
+    created_by INT,
+    updated_by INT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    CHECK ((role = 'admin' AND psychologist_id IS NULL AND customer_id IS NULL)
+        OR (role = 'psychologist' AND psychologist_id IS NOT NULL AND customer_id IS NULL)
+        OR (role = 'customer' AND customer_id IS NOT NULL AND psychologist_id IS NULL))
+);
+
+CREATE TABLE refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id VARCHAR(32) NOT NULL,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    used_at timestamp with time zone,
+    revoked_at timestamp with time zone,
+    created_at timestamp with time zone
+);
+
+CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family_id);
+
+CREATE TABLE login_challenges (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    customer_id INT REFERENCES customers(id) ON DELETE CASCADE,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    request_ip VARCHAR(45) NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    used_at timestamp with time zone,
+    created_at timestamp with time zone
+);
+
+CREATE INDEX login_challenges_email_idx ON login_challenges (email, created_at);
+CREATE INDEX login_challenges_request_ip_idx ON login_challenges (request_ip, created_at);
+
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) UNIQUE NOT NULL,
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL,
+    allowed_ips TEXT[],
+    expires_at timestamp with time zone,
+    last_used_at timestamp with time zone,
+    last_used_ip VARCHAR(45),
+    revoked_at timestamp with time zone,
+    created_by INT,
+    updated_by INT,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone
+);
+
+CREATE TABLE rate_limit_buckets (
+    key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
 
 
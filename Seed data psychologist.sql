@@ -1,6 +1,6 @@
 
-INSERT INTO psychologists (first_name, last_name, email, bio)
-VALUES ('John', 'Doe', 'john.doe@example.com', 'Experienced family therapist.');
+INSERT INTO psychologists (first_name, last_name, email, bio, onboarding_status)
+VALUES ('John', 'Doe', 'john.doe@example.com', 'Experienced family therapist.', 'verified');
 
 INSERT INTO specializations (name)
 VALUES ('Family Therapy'), ('Cognitive-Behavioral Therapy');
@@ -14,8 +14,11 @@ VALUES (1, 1, '09:00', '12:00'), (1, 3, '14:00', '18:00');
 INSERT INTO consultation_pricing (psychologist_id, price, currency)
 VALUES (1, 1000.00, 'UAH');
 
-INSERT INTO appointments (psychologist_id, customer_id, start_time, end_time)
-VALUES (1, 1, '10:00', '11:00');
+INSERT INTO psychologist_modalities (psychologist_id, modality)
+VALUES (1, 'online'), (1, 'in_person');
+
+INSERT INTO appointments (psychologist_id, customer_id, start_time, end_time, modality)
+VALUES (1, 1, '10:00', '11:00', 'online');
 
 -- Insert sample customers
 INSERT INTO customers (first_name, last_name, email, phone, created_by, updated_by, created_at, updated_at) VALUES
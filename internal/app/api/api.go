@@ -6,6 +6,8 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
 	"github.com/vitalicher97/psychologist_app/internal/app/middleware"
 )
 
@@ -18,51 +20,237 @@ func SetupRoutes(r *gin.Engine) {
 		AllowOrigins:     []string{"http://localhost:4200"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	r.Use(middleware.ErrorHandler())
+	// Count every request by address before authenticating it, so guessing tokens and API keys is throttled too
+	r.Use(middleware.RateLimitIP(RateLimitStore, "default", DefaultRateLimits.IP))
+	r.Use(middleware.Authenticate())
+	r.Use(middleware.RateLimit(RateLimitStore, "default", middleware.RateLimits{
+		APIKey: DefaultRateLimits.APIKey,
+		User:   DefaultRateLimits.User,
+	}))
+
+	loginLimit := middleware.RateLimit(RateLimitStore, "login", LoginRateLimits)
+	bookingLimit := middleware.RateLimit(RateLimitStore, "booking", BookingRateLimits)
 
 	apiRouter := r.Group("/api/psychotherapy")
 
-	psychologist := apiRouter.Group("psychologists")
+	authentication := apiRouter.Group("auth")
+	authentication.POST("register", loginLimit, Register)
+	authentication.POST("login", loginLimit, Login)
+	authentication.POST("passwordless", loginLimit, RequestLoginLink)
+	authentication.POST("passwordless/verify", loginLimit, PasswordlessLogin)
+	authentication.POST("refresh", RefreshSession)
+	authentication.POST("logout", Logout)
+	authentication.GET("me", GetCurrentUser)
+
+	// Routes are guarded by the policy of their group, and single routes that differ from their group by an
+	// additional allow rule. Administrators may call every route.
+	psychologist := apiRouter.Group("psychologists", policy{
+		read:     anyone,
+		write:    owns(psychologistParty, psychologistRecord),
+		resource: "psychologists",
+	}.handler())
 	psychologist.GET("", GetAllPsychologists)
+	psychologist.GET("search", SearchPsychologists)
 	psychologist.GET(":id", GetPsychologist)
 	psychologist.POST("", CreatePsychologist)
 	psychologist.PUT(":id", UpdatePsychologist)
 	psychologist.DELETE(":id", DeletePsychologist)
+	psychologist.GET(":id/slots", GetPsychologistSlots)
+	psychologist.GET(":id/calendar.ics", GetPsychologistCalendar)
+	psychologist.POST(":id/calendar-token", IssuePsychologistCalendarToken)
+	psychologist.DELETE(":id/calendar-token", RevokePsychologistCalendarToken)
+	psychologist.GET(":id/scheduling-settings", GetSchedulingSettings)
+	psychologist.PUT(":id/scheduling-settings", UpdateSchedulingSettings)
+	psychologist.GET(":id/specializations", GetPsychologistSpecializations)
+	psychologist.PUT(":id/specializations/:specialization_id", AttachPsychologistSpecialization)
+	psychologist.DELETE(":id/specializations/:specialization_id", DetachPsychologistSpecialization)
+	psychologist.GET(":id/languages", GetPsychologistLanguages)
+	psychologist.PUT(":id/languages", UpdatePsychologistLanguages)
+	psychologist.PUT(":id/languages/:code", AddPsychologistLanguage)
+	psychologist.DELETE(":id/languages/:code", RemovePsychologistLanguage)
+	psychologist.GET(":id/modalities", GetPsychologistModalities)
+	psychologist.PUT(":id/modalities", UpdatePsychologistModalities)
+	psychologist.PUT(":id/modalities/:modality", AddPsychologistModality)
+	psychologist.DELETE(":id/modalities/:modality", RemovePsychologistModality)
+	psychologist.POST(":id/picture", UploadPsychologistPicture)
+	psychologist.PUT(":id/license", UpdatePsychologistLicense)
+	psychologist.POST(":id/submit", SubmitPsychologistProfile)
+	psychologist.GET(":id/onboarding-history", allow(owns(psychologistParty, psychologistRecord)), GetOnboardingHistory)
+	psychologist.GET(":id/credentials", allow(owns(psychologistParty, psychologistRecord)), GetCredentialDocuments)
+	psychologist.POST(":id/credentials", UploadCredentialDocument)
+	psychologist.GET(":id/credentials/:document_id", allow(owns(psychologistParty, psychologistRecord)), DownloadCredentialDocument)
+	psychologist.DELETE(":id/credentials/:document_id", DeleteCredentialDocument)
+
+	specialization := apiRouter.Group("specializations", policy{read: anyone, write: adminsOnly, resource: "specializations"}.handler())
+	specialization.GET("", GetAllSpecializations)
+	specialization.GET(":id", GetSpecialization)
+	specialization.POST("", CreateSpecialization)
+	specialization.PUT(":id", UpdateSpecialization)
+	specialization.DELETE(":id", DeleteSpecialization)
+
+	admin := apiRouter.Group("admin", policy{read: adminsOnly, write: adminsOnly}.handler())
+	admin.GET("onboarding", GetOnboardingQueue)
+	admin.POST("psychologists/:id/approve", ApprovePsychologistProfile)
+	admin.POST("psychologists/:id/reject", RejectPsychologistProfile)
+	admin.POST("psychologists/:id/suspend", SuspendPsychologistProfile)
+	admin.POST("psychologists/:id/reinstate", ReinstatePsychologistProfile)
+	admin.GET("users", GetAllUsers)
+	admin.POST("users", CreateUser)
+	admin.GET("api-keys", GetAllAPIKeys)
+	admin.POST("api-keys", CreateAPIKey)
+	admin.GET("api-keys/:id", GetAPIKey)
+	admin.PUT("api-keys/:id", UpdateAPIKey)
+	admin.DELETE("api-keys/:id", RevokeAPIKey)
+	admin.GET("intake-questions", GetAllIntakeQuestions)
+	admin.POST("intake-questions", CreateIntakeQuestion)
+	admin.PUT("intake-questions/:id", UpdateIntakeQuestion)
+	admin.DELETE("intake-questions/:id", DeleteIntakeQuestion)
+
+	intake := apiRouter.Group("intake")
+	intake.GET("questionnaire", GetIntakeQuestionnaire)
+	intake.POST("responses", CreateIntakeResponse)
+	intake.GET("responses/:id/matches", GetIntakeResponseMatches)
+
+	review := apiRouter.Group("reviews", policy{read: withoutQuery("hidden"), write: signedIn, resource: "reviews"}.handler())
+	review.GET("", GetAllReviews)
+	review.GET(":id", GetReview)
+	review.POST("", allow(owns(customerParty, nil)), CreateReview)
+	review.PUT(":id", allow(owns(customerParty, models.ReviewOwner)), UpdateReview)
+	review.POST(":id/reply", allow(owns(psychologistParty, models.ReviewOwner)), ReplyToReview)
+	review.PUT(":id/visibility", allow(adminsOnly), SetReviewVisibility)
 
-	availability := apiRouter.Group("availabilities")
+	officeLocation := apiRouter.Group("office-locations", policy{
+		read:     anyone,
+		write:    owns(psychologistParty, models.OfficeLocationOwner),
+		resource: "office_locations",
+	}.handler())
+	officeLocation.GET("", GetAllOfficeLocations)
+	officeLocation.GET(":id", GetOfficeLocation)
+	officeLocation.POST("", CreateOfficeLocation)
+	officeLocation.PUT(":id", UpdateOfficeLocation)
+	officeLocation.DELETE(":id", DeleteOfficeLocation)
+
+	availability := apiRouter.Group("availabilities", policy{
+		read:     anyone,
+		write:    owns(psychologistParty, models.AvailabilityOwner),
+		resource: "availabilities",
+	}.handler())
 	availability.GET("", GetAllAvailability)
 	availability.GET(":id", GetAvailability)
 	availability.POST("", CreateAvailability)
 	availability.PUT(":id", UpdateAvailability)
 	availability.DELETE(":id", DeleteAvailability)
 
-	consultationPricing := apiRouter.Group("consultation-pricings")
+	availabilityException := apiRouter.Group("availabilities/exceptions", policy{
+		read:     owns(psychologistParty, models.AvailabilityExceptionOwner),
+		write:    owns(psychologistParty, models.AvailabilityExceptionOwner),
+		resource: "availabilities",
+	}.handler())
+	availabilityException.GET("", GetAllAvailabilityExceptions)
+	availabilityException.GET(":id", GetAvailabilityException)
+	availabilityException.POST("", CreateAvailabilityException)
+	availabilityException.PUT(":id", UpdateAvailabilityException)
+	availabilityException.DELETE(":id", DeleteAvailabilityException)
+
+	waitlist := apiRouter.Group("waitlist", policy{
+		read:     owns(eitherParty, models.WaitlistEntryOwner),
+		write:    owns(customerParty, models.WaitlistEntryOwner),
+		resource: "waitlist",
+	}.handler())
+	waitlist.GET("", GetAllWaitlistEntries)
+	waitlist.GET(":id", GetWaitlistEntry)
+	waitlist.POST("", bookingLimit, CreateWaitlistEntry)
+	waitlist.DELETE(":id", WithdrawWaitlistEntry)
+
+	waitlistOffer := apiRouter.Group("waitlist/offers", policy{
+		read:     owns(eitherParty, models.WaitlistOfferOwner),
+		write:    owns(customerParty, models.WaitlistOfferOwner),
+		resource: "waitlist",
+	}.handler())
+	waitlistOffer.GET("", GetAllWaitlistOffers)
+	waitlistOffer.GET("stats", allow(owns(psychologistParty, nil)), GetWaitlistStats)
+	waitlistOffer.POST(":id/accept", bookingLimit, AcceptWaitlistOffer)
+	waitlistOffer.POST(":id/decline", DeclineWaitlistOffer)
+
+	calendarImports := apiRouter.Group("calendar-imports", policy{
+		read:     owns(psychologistParty, models.CalendarImportOwner),
+		write:    owns(psychologistParty, models.CalendarImportOwner),
+		resource: "calendar_imports",
+	}.handler())
+	calendarImports.GET("", GetAllCalendarImports)
+	calendarImports.GET(":id", GetCalendarImport)
+	calendarImports.POST("", CreateCalendarImport)
+	calendarImports.PUT(":id", ReimportCalendar)
+	calendarImports.DELETE(":id", DeleteCalendarImport)
+
+	consultationPricing := apiRouter.Group("consultation-pricings", policy{
+		read:     anyone,
+		write:    owns(psychologistParty, models.ConsultationPricingOwner),
+		resource: "consultation_pricing",
+	}.handler())
 	consultationPricing.GET("", GetAllConsultationPricing)
 	consultationPricing.GET(":id", GetConsultationPricing)
 	consultationPricing.POST("", CreateConsultationPricing)
 	consultationPricing.PUT(":id", UpdateConsultationPricing)
 	consultationPricing.DELETE(":id", DeleteConsultationPricing)
 
-	appointments := apiRouter.Group("appointments")
+	appointmentOwner := owns(eitherParty, models.AppointmentOwner)
+	appointmentPsychologist := owns(psychologistParty, models.AppointmentOwner)
+	appointments := apiRouter.Group("appointments", policy{
+		read:     signedIn,
+		write:    appointmentOwner,
+		scope:    map[auth.Role]string{auth.RolePsychologist: "psychologist", auth.RoleCustomer: "customer"},
+		resource: "appointments",
+	}.handler())
 	appointments.GET("", GetAllAppointments)
-	appointments.GET(":id", GetAppointment)
-	appointments.POST("", CreateAppointment)
+	appointments.GET(":id", allow(appointmentOwner), GetAppointment)
+	appointments.POST("", bookingLimit, CreateAppointment)
 	appointments.PUT(":id", UpdateAppointment)
-	appointments.DELETE(":id", DeleteAppointment)
+	appointments.DELETE(":id", allow(appointmentPsychologist), DeleteAppointment)
+	appointments.GET(":id/history", allow(appointmentOwner), GetAppointmentHistory)
+	appointments.POST(":id/confirm", allow(appointmentPsychologist), ConfirmAppointment)
+	appointments.POST(":id/cancel", CancelAppointment)
+	appointments.POST(":id/complete", allow(appointmentPsychologist), CompleteAppointment)
+	appointments.POST(":id/no-show", allow(appointmentPsychologist), MarkAppointmentNoShow)
+
+	appointmentSeries := apiRouter.Group("appointment-series", policy{
+		read:     owns(eitherParty, models.AppointmentSeriesOwner),
+		write:    owns(eitherParty, models.AppointmentSeriesOwner),
+		resource: "appointments",
+	}.handler())
+	appointmentSeries.GET(":id", GetAppointmentSeries)
+	appointmentSeries.POST("", bookingLimit, CreateAppointmentSeries)
+	appointmentSeries.PUT(":id", UpdateAppointmentSeries)
+	appointmentSeries.POST(":id/cancel", CancelAppointmentSeries)
+
+	// Calendar apps read the feed with the token of its URL rather than signing in.
+	apiRouter.GET("customers/:id/calendar.ics", GetCustomerCalendar)
 
-	customer := apiRouter.Group("customers")
+	customer := apiRouter.Group("customers", policy{
+		read:     signedIn,
+		write:    owns(customerParty, customerRecord),
+		scope:    map[auth.Role]string{auth.RolePsychologist: "psychologist", auth.RoleCustomer: "id"},
+		resource: "customers",
+	}.handler())
 	customer.GET("", GetAllCustomers)
-	customer.GET(":id", GetCustomer)
+	customer.GET(":id", allow(customerOrTheirPsychologist), GetCustomer)
 	customer.POST("", CreateCustomer)
 	customer.PUT(":id", UpdateCustomer)
 	customer.DELETE(":id", DeleteCustomer)
+	customer.POST(":id/calendar-token", IssueCustomerCalendarToken)
+	customer.DELETE(":id/calendar-token", RevokeCustomerCalendarToken)
 
-	CustomerPsychologistPrices := apiRouter.Group("customer-psychologist-prices")
+	CustomerPsychologistPrices := apiRouter.Group("customer-psychologist-prices", policy{
+		read:     owns(eitherParty, nil),
+		write:    owns(psychologistParty, nil),
+		resource: "customer_prices",
+	}.handler())
 	CustomerPsychologistPrices.GET("", GetCustomerPsychologistPrices)
 	CustomerPsychologistPrices.POST("", CreateCustomerPsychologistPrices)
 }
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// CreateAPIKey handles an administrator issuing an API key. The key is only part of this response.
+func CreateAPIKey(c *gin.Context) {
+	var key models.APIKey
+	if err := c.ShouldBindJSON(&key); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := key.Create(c); err != nil {
+		handleAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// GetAllAPIKeys handles retrieving a page of API keys, including revoked and expired ones.
+func GetAllAPIKeys(c *gin.Context) {
+	params, ok := parseListParams(c, time.UTC)
+	if !ok {
+		return
+	}
+
+	keys, total, err := models.ListAPIKeys(c, params)
+	if err != nil {
+		handleListError(c, err)
+		return
+	}
+
+	if len(keys) == 0 {
+		keys = []models.APIKey{}
+	}
+
+	respondWithPage(c, params, total, keys)
+}
+
+// GetAPIKey handles retrieving an API key by ID.
+func GetAPIKey(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	key := &models.APIKey{ID: id}
+
+	key, err = key.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// UpdateAPIKey handles changing the name, scopes, allowed addresses and expiry of an API key by ID.
+func UpdateAPIKey(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var key models.APIKey
+	if err := c.ShouldBindJSON(&key); err != nil {
+		c.Error(err)
+		return
+	}
+	key.ID = id
+
+	if err := key.Update(c); err != nil {
+		handleAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// RevokeAPIKey handles revoking an API key by ID. Requests made with it are rejected from then on.
+// The key is kept so its last use can still be looked up.
+func RevokeAPIKey(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	key := &models.APIKey{ID: id}
+	if err := key.Revoke(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// handleAPIKeyError maps API key errors to HTTP responses.
+func handleAPIKeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidAPIKeyScope), errors.Is(err, models.ErrInvalidAllowedIP),
+		errors.Is(err, models.ErrAPIKeyExpiry):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.Error(err)
+	}
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -11,6 +13,12 @@ import (
 
 // CreateAppointment handles the creation of a new appointment.
 func CreateAppointment(c *gin.Context) {
+	loc, err := viewerLocation(c, time.UTC)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var appointment models.Appointment
 	if err := c.ShouldBindJSON(&appointment); err != nil {
 		c.Error(err)
@@ -18,10 +26,11 @@ func CreateAppointment(c *gin.Context) {
 	}
 
 	if err := appointment.Create(c); err != nil {
-		c.Error(err)
+		handleAppointmentError(c, err)
 		return
 	}
 
+	appointment.In(loc)
 	c.JSON(http.StatusCreated, appointment)
 }
 
@@ -35,6 +44,12 @@ func GetAppointment(c *gin.Context) {
 		return
 	}
 
+	loc, err := viewerLocation(c, time.UTC)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	appointment := &models.Appointment{ID: id}
 
 	appointment, err = appointment.GetByID(c)
@@ -43,6 +58,7 @@ func GetAppointment(c *gin.Context) {
 		return
 	}
 
+	appointment.In(loc)
 	c.JSON(http.StatusOK, appointment)
 }
 
@@ -56,6 +72,12 @@ func UpdateAppointment(c *gin.Context) {
 		return
 	}
 
+	loc, err := viewerLocation(c, time.UTC)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	existingAppointment := &models.Appointment{ID: id}
 	existingAppointment, err = existingAppointment.GetByID(c)
 	if err != nil {
@@ -70,13 +92,17 @@ func UpdateAppointment(c *gin.Context) {
 	}
 
 	appointment.CreatedAt = existingAppointment.CreatedAt
+	appointment.CreatedBy = existingAppointment.CreatedBy
+	appointment.Status = existingAppointment.Status
+	appointment.SeriesID = existingAppointment.SeriesID
 	appointment.ID = id
 
 	if err := appointment.Update(c); err != nil {
-		c.Error(err)
+		handleAppointmentError(c, err)
 		return
 	}
 
+	appointment.In(loc)
 	c.JSON(http.StatusOK, appointment)
 }
 
@@ -102,33 +128,61 @@ func DeleteAppointment(c *gin.Context) {
 
 // ListAppointments handles retrieving a list of all appointments.
 func GetAllAppointments(c *gin.Context) {
-	psychologistIDStr := c.Query("psychologist")
-
-	var appointmentList []models.Appointment
-	var err error
-	if psychologistIDStr != "" {
-		psychologistID, err := strconv.Atoi(psychologistIDStr)
-		if err != nil {
-			c.Error(err)
-			return
-		}
-
-		appointmentList, err = models.GetAppointmentsByPsychologistID(c, psychologistID)
-		if err != nil {
-			c.Error(err)
-			return
-		}
-	} else {
-		appointmentList, err = models.GetAllAppointments(c)
-		if err != nil {
-			c.Error(err)
-			return
-		}
+	loc, err := viewerLocation(c, time.UTC)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	params, ok := parseListParams(c, loc)
+	if !ok {
+		return
+	}
+
+	appointmentList, total, err := models.ListAppointments(c, params)
+	if err != nil {
+		handleListError(c, err)
+		return
 	}
 
+	for i := range appointmentList {
+		appointmentList[i].In(loc)
+	}
 	if len(appointmentList) == 0 {
 		appointmentList = []models.Appointment{}
 	}
 
-	c.JSON(http.StatusOK, appointmentList)
+	respondWithPage(c, params, total, appointmentList)
+}
+
+// handleAppointmentError responds to booking validation errors and passes any other error to the error middleware.
+func handleAppointmentError(c *gin.Context, err error) {
+	var conflict *models.ConflictError
+	var transition *models.TransitionError
+
+	switch {
+	case errors.Is(err, models.ErrInvalidTimeRange), errors.Is(err, models.ErrInvalidModality),
+		errors.Is(err, models.ErrOfficeLocationRequired), errors.Is(err, models.ErrOfficeLocationNotAllowed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrOutsideAvailability), errors.Is(err, models.ErrOutsideBookingWindow),
+		errors.Is(err, models.ErrModalityNotOffered), errors.Is(err, models.ErrUnknownOfficeLocation),
+		errors.Is(err, models.ErrPsychologistNotVerified), errors.Is(err, models.ErrSessionLength),
+		errors.Is(err, models.ErrOffSlotGrid):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrPsychologistBusy), errors.Is(err, models.ErrSlotHeld):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &conflict):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":                       conflict.Error(),
+			"conflicting_appointment_ids": conflict.AppointmentIDs,
+		})
+	case errors.As(err, &transition):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":               transition.Error(),
+			"status":              transition.From,
+			"allowed_transitions": transition.Allowed,
+		})
+	default:
+		c.Error(err)
+	}
 }
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+	"github.com/vitalicher97/psychologist_app/internal/app/scheduling"
+)
+
+// seriesRescheduleRequest is the body of the series update endpoint.
+type seriesRescheduleRequest struct {
+	Scope         models.SeriesScope `json:"scope" binding:"required"`
+	AppointmentID int                `json:"appointment_id"`
+	StartTime     time.Time          `json:"start_time" binding:"required"`
+	EndTime       time.Time          `json:"end_time" binding:"required"`
+}
+
+// seriesCancelRequest is the body of the series cancel endpoint. Only administrators and API keys choose the actor.
+type seriesCancelRequest struct {
+	Scope         models.SeriesScope `json:"scope" binding:"required"`
+	AppointmentID int                `json:"appointment_id"`
+	Actor         string             `json:"actor"`
+	Reason        string             `json:"reason"`
+}
+
+// CreateAppointmentSeries handles the creation of a recurring appointment series.
+// Occurrences that cannot be booked are reported individually.
+func CreateAppointmentSeries(c *gin.Context) {
+	loc, err := viewerLocation(c, time.UTC)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var series models.AppointmentSeries
+	if err := c.ShouldBindJSON(&series); err != nil {
+		c.Error(err)
+		return
+	}
+
+	occurrences, err := series.Create(c)
+	if err != nil {
+		handleAppointmentSeriesError(c, err)
+		return
+	}
+
+	localizeOccurrences(occurrences, loc)
+
+	status := http.StatusCreated
+	if series.ID == 0 {
+		status = http.StatusConflict
+	}
+
+	c.JSON(status, gin.H{"series": series, "occurrences": occurrences})
+}
+
+// GetAppointmentSeries handles retrieving a series and its occurrences by ID.
+func GetAppointmentSeries(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	loc, err := viewerLocation(c, time.UTC)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series := &models.AppointmentSeries{ID: id}
+
+	series, err = series.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	appointments, err := series.Appointments(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	for i := range appointments {
+		appointments[i].In(loc)
+	}
+	if len(appointments) == 0 {
+		appointments = []models.Appointment{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series, "appointments": appointments})
+}
+
+// UpdateAppointmentSeries handles moving one, the following or all occurrences of a series.
+func UpdateAppointmentSeries(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	loc, err := viewerLocation(c, time.UTC)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req seriesRescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	series := &models.AppointmentSeries{ID: id}
+
+	series, err = series.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	occurrences, err := series.Reschedule(c, req.Scope, req.AppointmentID, req.StartTime, req.EndTime)
+	if err != nil {
+		handleAppointmentSeriesError(c, err)
+		return
+	}
+
+	localizeOccurrences(occurrences, loc)
+	c.JSON(http.StatusOK, gin.H{"series": series, "occurrences": occurrences})
+}
+
+// CancelAppointmentSeries handles cancelling one, the following or all occurrences of a series.
+func CancelAppointmentSeries(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	loc, err := viewerLocation(c, time.UTC)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req seriesCancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	req.Actor = statusActor(auth.PrincipalFrom(c), req.Actor)
+
+	var status models.AppointmentStatus
+	switch req.Actor {
+	case actorCustomer:
+		status = models.StatusCancelledByCustomer
+	case actorPsychologist:
+		status = models.StatusCancelledByPsychologist
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "actor must be either customer or psychologist"})
+		return
+	}
+
+	series := &models.AppointmentSeries{ID: id}
+
+	series, err = series.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	occurrences, err := series.Cancel(c, req.Scope, req.AppointmentID, status, req.Actor, req.Reason)
+	if err != nil {
+		handleAppointmentSeriesError(c, err)
+		return
+	}
+
+	localizeOccurrences(occurrences, loc)
+	c.JSON(http.StatusOK, gin.H{"series": series, "occurrences": occurrences})
+}
+
+// localizeOccurrences converts the occurrence times to the given time zone for rendering.
+func localizeOccurrences(occurrences []models.OccurrenceResult, loc *time.Location) {
+	for i := range occurrences {
+		occurrences[i].StartTime = occurrences[i].StartTime.In(loc)
+		occurrences[i].EndTime = occurrences[i].EndTime.In(loc)
+	}
+}
+
+// handleAppointmentSeriesError responds to series validation errors and passes any other error on.
+func handleAppointmentSeriesError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, scheduling.ErrInvalidRecurrence),
+		errors.Is(err, models.ErrInvalidSeriesScope),
+		errors.Is(err, models.ErrNotInSeries):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		handleAppointmentError(c, err)
+	}
+}
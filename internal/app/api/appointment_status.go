@@ -0,0 +1,146 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// Actors that can change the status of an appointment.
+const (
+	actorCustomer     = "customer"
+	actorPsychologist = "psychologist"
+)
+
+// statusChangeRequest is the optional body of the appointment status endpoints. Only administrators and
+// API keys choose the actor; everyone else acts as themselves.
+type statusChangeRequest struct {
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+}
+
+// ConfirmAppointment handles confirming a requested appointment.
+func ConfirmAppointment(c *gin.Context) {
+	transitionAppointment(c, func(statusChangeRequest) (models.AppointmentStatus, bool) {
+		return models.StatusConfirmed, true
+	})
+}
+
+// CancelAppointment handles cancelling an appointment on behalf of the customer or the psychologist,
+// whichever the caller is.
+func CancelAppointment(c *gin.Context) {
+	transitionAppointment(c, func(req statusChangeRequest) (models.AppointmentStatus, bool) {
+		switch req.Actor {
+		case actorCustomer:
+			return models.StatusCancelledByCustomer, true
+		case actorPsychologist:
+			return models.StatusCancelledByPsychologist, true
+		default:
+			return "", false
+		}
+	})
+}
+
+// CompleteAppointment handles marking an appointment as completed.
+func CompleteAppointment(c *gin.Context) {
+	transitionAppointment(c, func(statusChangeRequest) (models.AppointmentStatus, bool) {
+		return models.StatusCompleted, true
+	})
+}
+
+// MarkAppointmentNoShow handles marking an appointment as missed by the customer.
+func MarkAppointmentNoShow(c *gin.Context) {
+	transitionAppointment(c, func(statusChangeRequest) (models.AppointmentStatus, bool) {
+		return models.StatusNoShow, true
+	})
+}
+
+// GetAppointmentHistory handles retrieving the status history of an appointment.
+func GetAppointmentHistory(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	appointment := &models.Appointment{ID: id}
+	if _, err := appointment.GetByID(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	history, err := models.GetAppointmentStatusHistory(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(history) == 0 {
+		history = []models.AppointmentStatusChange{}
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// transitionAppointment moves the appointment from the path to the state chosen by target.
+// target reports false when the request does not identify a valid target state.
+func transitionAppointment(c *gin.Context, target func(statusChangeRequest) (models.AppointmentStatus, bool)) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	loc, err := viewerLocation(c, time.UTC)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req statusChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Actor = statusActor(auth.PrincipalFrom(c), req.Actor)
+
+	status, ok := target(req)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "actor must be either customer or psychologist"})
+		return
+	}
+
+	appointment := &models.Appointment{ID: id}
+	if err := appointment.Transition(c, status, req.Actor, req.Reason); err != nil {
+		handleAppointmentError(c, err)
+		return
+	}
+
+	appointment.In(loc)
+	c.JSON(http.StatusOK, appointment)
+}
+
+// statusActor returns who a status change is made on behalf of. Customers and psychologists act as
+// themselves whatever they ask for; administrators and API keys act for either side, so the requested
+// actor is kept.
+func statusActor(principal *auth.Principal, requested string) string {
+	switch principal.Role {
+	case auth.RoleCustomer:
+		return actorCustomer
+	case auth.RolePsychologist:
+		return actorPsychologist
+	default:
+		return requested
+	}
+}
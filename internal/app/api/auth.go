@@ -0,0 +1,345 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+	"github.com/vitalicher97/psychologist_app/internal/app/mail"
+	"github.com/vitalicher97/psychologist_app/internal/app/middleware"
+)
+
+// LoginLinkURL is the page login links point to. The link token is added as the token query parameter.
+var LoginLinkURL = "http://localhost:4200/login/verify"
+
+// registerRequest is the JSON body used to sign up as a psychologist or customer.
+type registerRequest struct {
+	Email     string    `json:"email" binding:"required,email"`
+	Password  string    `json:"password" binding:"required,min=8,max=72"`
+	Role      auth.Role `json:"role" binding:"required,oneof=psychologist customer"`
+	FirstName string    `json:"first_name" binding:"required"`
+	LastName  string    `json:"last_name" binding:"required"`
+	Phone     string    `json:"phone"`
+	TimeZone  string    `json:"time_zone"`
+}
+
+// loginRequest is the JSON body used to sign in with a password.
+type loginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// refreshRequest is the JSON body carrying a refresh token.
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// loginLinkRequest is the JSON body a customer asks for a login link and code with.
+type loginLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// passwordlessLoginRequest is the JSON body used to sign in with the token of a login link,
+// or with an email and the code sent to it.
+type passwordlessLoginRequest struct {
+	Token string `json:"token"`
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+// createUserRequest is the JSON body an administrator creates an account with.
+type createUserRequest struct {
+	Email          string    `json:"email" binding:"required,email"`
+	Password       string    `json:"password" binding:"required,min=8,max=72"`
+	Role           auth.Role `json:"role" binding:"required"`
+	PsychologistID *int      `json:"psychologist_id"`
+	CustomerID     *int      `json:"customer_id"`
+}
+
+// sessionView is the pair of tokens returned when someone signs in or refreshes their session.
+type sessionView struct {
+	AccessToken           string       `json:"access_token"`
+	TokenType             string       `json:"token_type"`
+	ExpiresIn             int          `json:"expires_in"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  *models.User `json:"user"`
+}
+
+// Register handles signing up as a psychologist or customer. It creates the profile and the account and signs them in.
+func Register(c *gin.Context) {
+	var req registerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	user := &models.User{Email: req.Email}
+	email := models.NormalizeEmail(req.Email)
+
+	var err error
+	switch req.Role {
+	case auth.RolePsychologist:
+		psychologist := &models.Psychologist{FirstName: req.FirstName, LastName: req.LastName, Email: email, TimeZone: req.TimeZone}
+		err = models.RegisterPsychologist(c, user, req.Password, psychologist)
+	case auth.RoleCustomer:
+		customer := &models.Customer{FirstName: req.FirstName, LastName: req.LastName, Email: email, Phone: req.Phone}
+		err = models.RegisterCustomer(c, user, req.Password, customer)
+	}
+	if err != nil {
+		handleAuthError(c, err)
+		return
+	}
+
+	startSession(c, http.StatusCreated, user)
+}
+
+// Login handles signing in with an email and password. Attempts are counted per account as well as per caller.
+func Login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if !middleware.Throttle(c, RateLimitStore, "login:account:"+models.NormalizeEmail(req.Email), LoginAccountRateLimit) {
+		return
+	}
+
+	user, err := models.Authenticate(c, req.Email, req.Password)
+	if err != nil {
+		handleAuthError(c, err)
+		return
+	}
+
+	startSession(c, http.StatusOK, user)
+}
+
+// RequestLoginLink handles a customer asking for a login link and code by email.
+// It responds the same whether or not a customer has the email.
+func RequestLoginLink(c *gin.Context) {
+	var req loginLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	challenge, err := models.RequestLogin(c, req.Email, c.ClientIP())
+	if err != nil {
+		handleAuthError(c, err)
+		return
+	}
+
+	if challenge != nil {
+		if err := mail.Default().Send(c, loginLinkMessage(challenge)); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If a customer has this email, a sign-in link and code have been sent to it"})
+}
+
+// PasswordlessLogin handles signing in with the token of a login link, or with an email and the code sent to it.
+func PasswordlessLogin(c *gin.Context) {
+	var req passwordlessLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var user *models.User
+	var err error
+	switch {
+	case req.Token != "":
+		user, err = models.LoginWithLink(c, req.Token)
+	case req.Email != "" && req.Code != "":
+		user, err = models.LoginWithCode(c, req.Email, req.Code)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "either token or email and code are required"})
+		return
+	}
+	if err != nil {
+		handleAuthError(c, err)
+		return
+	}
+
+	startSession(c, http.StatusOK, user)
+}
+
+// loginLinkMessage is the email carrying a login link and code.
+func loginLinkMessage(challenge *models.LoginChallenge) mail.Message {
+	link := LoginLinkURL + "?token=" + url.QueryEscape(challenge.Token)
+	minutes := int(math.Round(models.LoginChallengeTTL.Minutes()))
+
+	return mail.Message{
+		To:      challenge.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf("Open this link to sign in:\n\n%s\n\nOr enter this code: %s\n\n"+
+			"The link and code work once and expire in %d minutes. If you did not ask to sign in, ignore this email.\n",
+			link, challenge.Code, minutes),
+	}
+}
+
+// RefreshSession handles exchanging a refresh token for a new access token and refresh token.
+func RefreshSession(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	session, err := models.RefreshSession(c, req.RefreshToken)
+	if err != nil {
+		handleAuthError(c, err)
+		return
+	}
+
+	respondWithSession(c, http.StatusOK, session)
+}
+
+// Logout handles signing out by revoking the session of the refresh token.
+// Access tokens already issued stay valid until they expire.
+func Logout(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := models.EndSession(c, req.RefreshToken); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetCurrentUser handles retrieving the account of the signed in caller. API keys have no account.
+func GetCurrentUser(c *gin.Context) {
+	principal := auth.PrincipalFrom(c)
+	if principal.IsAnonymous() {
+		respondUnauthorized(c)
+		return
+	}
+	if principal.IsAPIKey() {
+		respondForbidden(c)
+		return
+	}
+
+	user := &models.User{ID: principal.UserID}
+
+	user, err := user.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// CreateUser handles an administrator creating an account for an existing psychologist or customer,
+// or another administrator.
+func CreateUser(c *gin.Context) {
+	var req createUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	user := &models.User{
+		Email:          req.Email,
+		Role:           req.Role,
+		PsychologistID: req.PsychologistID,
+		CustomerID:     req.CustomerID,
+	}
+	if err := user.Create(c, req.Password); err != nil {
+		handleAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// GetAllUsers handles retrieving a page of accounts.
+func GetAllUsers(c *gin.Context) {
+	params, ok := parseListParams(c, time.UTC)
+	if !ok {
+		return
+	}
+
+	users, total, err := models.ListUsers(c, params)
+	if err != nil {
+		handleListError(c, err)
+		return
+	}
+
+	if len(users) == 0 {
+		users = []models.User{}
+	}
+
+	respondWithPage(c, params, total, users)
+}
+
+// startSession starts a new session for the user and responds with its tokens.
+func startSession(c *gin.Context, status int, user *models.User) {
+	session, err := models.StartSession(c, user)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondWithSession(c, status, session)
+}
+
+// respondWithSession responds with a fresh access token and the refresh token of the session.
+func respondWithSession(c *gin.Context, status int, session *models.Session) {
+	accessToken, expiresAt, err := auth.IssueAccessToken(session.User.Principal())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(status, sessionView{
+		AccessToken:           accessToken,
+		TokenType:             "Bearer",
+		ExpiresIn:             int(time.Until(expiresAt).Seconds()),
+		RefreshToken:          session.RefreshToken,
+		RefreshTokenExpiresAt: session.RefreshTokenExpiresAt,
+		User:                  session.User,
+	})
+}
+
+// respondUnauthorized responds with 401 to a caller that has to sign in first.
+func respondUnauthorized(c *gin.Context) {
+	c.Header("WWW-Authenticate", "Bearer")
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+}
+
+// handleAuthError maps account and session errors to HTTP responses.
+func handleAuthError(c *gin.Context, err error) {
+	var limitErr *models.LoginRateLimitError
+	switch {
+	case errors.As(err, &limitErr):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": limitErr.Error()})
+	case errors.Is(err, models.ErrInvalidCredentials), errors.Is(err, models.ErrInvalidRefreshToken),
+		errors.Is(err, models.ErrInvalidLoginLink), errors.Is(err, models.ErrInvalidLoginCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrEmailTaken), errors.Is(err, models.ErrProfileTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInvalidRole), errors.Is(err, models.ErrProfileLink),
+		errors.Is(err, models.ErrUnknownProfile), errors.Is(err, models.ErrInvalidTimeZone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.Error(err)
+	}
+}
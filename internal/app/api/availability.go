@@ -3,6 +3,7 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -70,6 +71,7 @@ func UpdateAvailability(c *gin.Context) {
 	}
 
 	availability.CreatedAt = existingAvailability.CreatedAt
+	availability.CreatedBy = existingAvailability.CreatedBy
 	availability.ID = id
 
 	if err := availability.Update(c); err != nil {
@@ -102,34 +104,20 @@ func DeleteAvailability(c *gin.Context) {
 
 // ListAvailability handles retrieving a list of all availability entries.
 func GetAllAvailability(c *gin.Context) {
-	psychologistIDStr := c.Query("psychologist")
-
-	var availabilityList []models.Availability
-	var err error
-	if psychologistIDStr != "" {
-		psychologistID, err := strconv.Atoi(psychologistIDStr)
-		if err != nil {
-			c.Error(err)
-			return
-		}
-
-		availabilityList, err = models.GetAvailabilityByPsychologist(c, psychologistID)
-		if err != nil {
-			c.Error(err)
-			return
-		}
-
-	} else {
-		availabilityList, err = models.GetAllAvailabilities(c)
-		if err != nil {
-			c.Error(err)
-			return
-		}
+	params, ok := parseListParams(c, time.UTC)
+	if !ok {
+		return
+	}
+
+	availabilityList, total, err := models.ListAvailabilities(c, params)
+	if err != nil {
+		handleListError(c, err)
+		return
 	}
 
 	if len(availabilityList) == 0 {
 		availabilityList = []models.Availability{}
 	}
 
-	c.JSON(http.StatusOK, availabilityList)
+	respondWithPage(c, params, total, availabilityList)
 }
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// CreateAvailabilityException handles the creation of a new availability exception.
+func CreateAvailabilityException(c *gin.Context) {
+	var exception models.AvailabilityException
+	if err := c.ShouldBindJSON(&exception); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := exception.Create(c); err != nil {
+		handleAvailabilityExceptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, exception)
+}
+
+// GetAvailabilityException handles retrieving an availability exception by ID.
+func GetAvailabilityException(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	exception := &models.AvailabilityException{ID: id}
+
+	exception, err = exception.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, exception)
+}
+
+// UpdateAvailabilityException handles updating an availability exception by ID.
+func UpdateAvailabilityException(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	existingException := &models.AvailabilityException{ID: id}
+	existingException, err = existingException.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var exception models.AvailabilityException
+	if err := c.ShouldBindJSON(&exception); err != nil {
+		c.Error(err)
+		return
+	}
+
+	exception.CreatedAt = existingException.CreatedAt
+	exception.CreatedBy = existingException.CreatedBy
+	exception.ID = id
+
+	if err := exception.Update(c); err != nil {
+		handleAvailabilityExceptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, exception)
+}
+
+// DeleteAvailabilityException handles deleting an availability exception by ID.
+func DeleteAvailabilityException(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	exception := &models.AvailabilityException{ID: id}
+
+	if err := exception.DeleteByID(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetAllAvailabilityExceptions handles retrieving a list of availability exceptions,
+// optionally limited to a psychologist and to the dates between from and to.
+func GetAllAvailabilityExceptions(c *gin.Context) {
+	psychologistIDStr := c.Query("psychologist")
+
+	var exceptionList []models.AvailabilityException
+	var err error
+	if psychologistIDStr != "" {
+		psychologistID, err := strconv.Atoi(psychologistIDStr)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		from, to, err := parseDateRange(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		exceptionList, err = models.GetAvailabilityExceptionsByPsychologist(c, psychologistID, from, to)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	} else {
+		exceptionList, err = models.GetAllAvailabilityExceptions(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	if len(exceptionList) == 0 {
+		exceptionList = []models.AvailabilityException{}
+	}
+
+	c.JSON(http.StatusOK, exceptionList)
+}
+
+// parseDateRange reads the optional "from" and "to" query parameters, leaving the range open when they are absent.
+func parseDateRange(c *gin.Context) (time.Time, time.Time, error) {
+	from := time.Time{}
+	if value := c.Query("from"); value != "" {
+		parsed, err := parseTimeParam(value, time.UTC)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid from")
+		}
+		from = parsed
+	}
+
+	to := time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+	if value := c.Query("to"); value != "" {
+		parsed, err := parseTimeParam(value, time.UTC)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid to")
+		}
+		to = parsed
+	}
+
+	return from, to, nil
+}
+
+// handleAvailabilityExceptionError responds to validation errors and passes any other error to the error middleware.
+func handleAvailabilityExceptionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidExceptionKind),
+		errors.Is(err, models.ErrInvalidDateRange),
+		errors.Is(err, models.ErrExceptionTimeRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.Error(err)
+	}
+}
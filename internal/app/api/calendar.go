@@ -0,0 +1,209 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+	"github.com/vitalicher97/psychologist_app/internal/app/ical"
+)
+
+// calendarProductID identifies this application in generated calendars.
+const calendarProductID = "-//Psychologist App//Appointments//EN"
+
+// IssuePsychologistCalendarToken handles issuing a new calendar feed token for a psychologist, revoking the previous one.
+func IssuePsychologistCalendarToken(c *gin.Context) {
+	issueCalendarToken(c, models.FeedOwnerPsychologist)
+}
+
+// RevokePsychologistCalendarToken handles revoking the calendar feed token of a psychologist.
+func RevokePsychologistCalendarToken(c *gin.Context) {
+	revokeCalendarToken(c, models.FeedOwnerPsychologist)
+}
+
+// GetPsychologistCalendar handles rendering the appointments of a psychologist as an iCalendar feed.
+func GetPsychologistCalendar(c *gin.Context) {
+	renderCalendar(c, models.FeedOwnerPsychologist)
+}
+
+// IssueCustomerCalendarToken handles issuing a new calendar feed token for a customer, revoking the previous one.
+func IssueCustomerCalendarToken(c *gin.Context) {
+	issueCalendarToken(c, models.FeedOwnerCustomer)
+}
+
+// RevokeCustomerCalendarToken handles revoking the calendar feed token of a customer.
+func RevokeCustomerCalendarToken(c *gin.Context) {
+	revokeCalendarToken(c, models.FeedOwnerCustomer)
+}
+
+// GetCustomerCalendar handles rendering the appointments of a customer as an iCalendar feed.
+func GetCustomerCalendar(c *gin.Context) {
+	renderCalendar(c, models.FeedOwnerCustomer)
+}
+
+// issueCalendarToken issues a feed token for the owner in the path and returns it with the feed URL.
+func issueCalendarToken(c *gin.Context, ownerType string) {
+	id, ok := calendarOwnerID(c, ownerType)
+	if !ok {
+		return
+	}
+
+	token, err := models.IssueCalendarFeed(c, ownerType, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	basePath := strings.TrimSuffix(c.Request.URL.Path, "/calendar-token")
+	c.JSON(http.StatusCreated, gin.H{
+		"token": token,
+		"url":   fmt.Sprintf("%s/calendar.ics?token=%s", basePath, token),
+	})
+}
+
+// revokeCalendarToken revokes the feed token of the owner in the path.
+func revokeCalendarToken(c *gin.Context, ownerType string) {
+	id, ok := calendarOwnerID(c, ownerType)
+	if !ok {
+		return
+	}
+
+	if err := models.RevokeCalendarFeed(c, ownerType, id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// renderCalendar writes the feed of the owner in the path, provided the token is valid.
+func renderCalendar(c *gin.Context, ownerType string) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	feed, err := models.GetCalendarFeed(c, ownerType, id, c.Query("token"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	appointments, err := feed.Appointments(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	calendar, err := buildCalendar(c, ownerType, appointments)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", `inline; filename="calendar.ics"`)
+	c.Status(http.StatusOK)
+	if err := calendar.Encode(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
+
+// buildCalendar turns appointments into calendar events named after the other party of each appointment.
+func buildCalendar(c *gin.Context, ownerType string, appointments []models.Appointment) (*ical.Calendar, error) {
+	names := map[int]string{}
+	if ownerType == models.FeedOwnerPsychologist {
+		ids := make([]int, 0, len(appointments))
+		for _, appointment := range appointments {
+			ids = append(ids, appointment.CustomerID)
+		}
+		customers, err := models.GetCustomersByIDs(c, ids)
+		if err != nil {
+			return nil, err
+		}
+		for id, customer := range customers {
+			names[id] = customer.FirstName + " " + customer.LastName
+		}
+	} else {
+		ids := make([]int, 0, len(appointments))
+		for _, appointment := range appointments {
+			ids = append(ids, appointment.PsychologistID)
+		}
+		psychologists, err := models.GetPsychologistsByIDs(c, ids)
+		if err != nil {
+			return nil, err
+		}
+		for id, psychologist := range psychologists {
+			names[id] = psychologist.FirstName + " " + psychologist.LastName
+		}
+	}
+
+	calendar := &ical.Calendar{ProductID: calendarProductID, Name: "Consultations"}
+	for _, appointment := range appointments {
+		counterpart := appointment.PsychologistID
+		if ownerType == models.FeedOwnerPsychologist {
+			counterpart = appointment.CustomerID
+		}
+
+		summary := "Consultation"
+		if name, ok := names[counterpart]; ok {
+			summary = "Consultation with " + name
+		}
+
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:          fmt.Sprintf("appointment-%d@psychologist-app", appointment.ID),
+			Sequence:     appointment.Sequence,
+			Start:        appointment.StartTime,
+			End:          appointment.EndTime,
+			Summary:      summary,
+			Status:       calendarStatus(appointment.Status),
+			Created:      appointment.CreatedAt,
+			LastModified: appointment.UpdatedAt,
+		})
+	}
+
+	return calendar, nil
+}
+
+// calendarStatus maps an appointment status to the matching event status.
+func calendarStatus(status models.AppointmentStatus) string {
+	switch {
+	case status.IsCancelled():
+		return ical.StatusCancelled
+	case status == models.StatusRequested:
+		return ical.StatusTentative
+	default:
+		return ical.StatusConfirmed
+	}
+}
+
+// calendarOwnerID reads the owner ID from the path and checks that the owner exists.
+// It reports false when a response has already been sent.
+func calendarOwnerID(c *gin.Context, ownerType string) (int, bool) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return 0, false
+	}
+
+	if ownerType == models.FeedOwnerPsychologist {
+		_, err = (&models.Psychologist{ID: id}).GetByID(c)
+	} else {
+		_, err = (&models.Customer{ID: id}).GetByID(c)
+	}
+	if err != nil {
+		c.Error(err)
+		return 0, false
+	}
+
+	return id, true
+}
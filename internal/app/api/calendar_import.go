@@ -0,0 +1,184 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+	"github.com/vitalicher97/psychologist_app/internal/app/ical"
+)
+
+// calendarImportRequest is the JSON body used to register a calendar that is re-read from a path or URL.
+type calendarImportRequest struct {
+	PsychologistID int    `json:"psychologist_id" form:"psychologist_id" binding:"required"`
+	Name           string `json:"name" form:"name"`
+	Source         string `json:"source" form:"source"`
+}
+
+// CreateCalendarImport handles importing an external calendar as busy time for a psychologist.
+// The calendar is either uploaded as the multipart "file" field or read from the given source.
+// Fields are read from a form or, whatever the content type, from JSON, the two formats policies check.
+func CreateCalendarImport(c *gin.Context) {
+	var bind binding.Binding = binding.JSON
+	if ct := c.ContentType(); ct == gin.MIMEPOSTForm || ct == gin.MIMEMultipartPOSTForm {
+		bind = binding.Form
+	}
+
+	var req calendarImportRequest
+	if err := c.ShouldBindWith(&req, bind); err != nil {
+		c.Error(err)
+		return
+	}
+
+	calendarImport := &models.CalendarImport{
+		PsychologistID: req.PsychologistID,
+		Name:           req.Name,
+		Source:         req.Source,
+	}
+
+	file, _ := c.FormFile("file")
+	if file == nil && req.Source == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "either upload a file or provide a source"})
+		return
+	}
+	if req.Source != "" {
+		if err := models.ValidateCalendarSource(req.Source, auth.PrincipalFrom(c).IsAdmin()); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := calendarImport.Create(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if !importCalendar(c, calendarImport) {
+		// Do not keep an import whose first read failed.
+		_ = calendarImport.DeleteByID(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, calendarImport)
+}
+
+// GetCalendarImport handles retrieving a calendar import by ID.
+func GetCalendarImport(c *gin.Context) {
+	calendarImport, ok := loadCalendarImport(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, calendarImport)
+}
+
+// GetAllCalendarImports handles retrieving the calendar imports of a psychologist.
+func GetAllCalendarImports(c *gin.Context) {
+	psychologistID, err := strconv.Atoi(c.Query("psychologist"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "psychologist is required"})
+		return
+	}
+
+	imports, err := models.GetCalendarImportsByPsychologist(c, psychologistID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(imports) == 0 {
+		imports = []models.CalendarImport{}
+	}
+
+	c.JSON(http.StatusOK, imports)
+}
+
+// ReimportCalendar handles replacing the busy blocks of an import, either from an uploaded
+// "file" field or by re-reading the import's source.
+func ReimportCalendar(c *gin.Context) {
+	calendarImport, ok := loadCalendarImport(c)
+	if !ok {
+		return
+	}
+
+	if !importCalendar(c, calendarImport) {
+		return
+	}
+
+	c.JSON(http.StatusOK, calendarImport)
+}
+
+// DeleteCalendarImport handles deleting a calendar import together with its busy blocks.
+func DeleteCalendarImport(c *gin.Context) {
+	calendarImport, ok := loadCalendarImport(c)
+	if !ok {
+		return
+	}
+
+	if err := calendarImport.DeleteByID(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// importCalendar imports the uploaded file if there is one, otherwise re-reads the import's source.
+// It reports false when an error response has been sent.
+func importCalendar(c *gin.Context, calendarImport *models.CalendarImport) bool {
+	var err error
+	if header, _ := c.FormFile("file"); header != nil {
+		file, openErr := header.Open()
+		if openErr != nil {
+			c.Error(openErr)
+			return false
+		}
+		defer file.Close()
+
+		err = calendarImport.Import(c, file)
+	} else {
+		err = calendarImport.Refresh(c)
+	}
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ical.ErrInvalidCalendar),
+		errors.Is(err, models.ErrCalendarTooLarge),
+		errors.Is(err, models.ErrNoSource),
+		errors.Is(err, models.ErrUnsupportedSource):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrSourceUnavailable):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.Error(err)
+	}
+
+	return false
+}
+
+// loadCalendarImport reads the import from the path. It reports false when an error has been recorded.
+func loadCalendarImport(c *gin.Context) (*models.CalendarImport, bool) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+
+	calendarImport := &models.CalendarImport{ID: id}
+
+	calendarImport, err = calendarImport.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+
+	return calendarImport, true
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -70,6 +71,7 @@ func UpdateConsultationPricing(c *gin.Context) {
 	}
 
 	consultationPricing.CreatedAt = existingConsultationPricing.CreatedAt
+	consultationPricing.CreatedBy = existingConsultationPricing.CreatedBy
 	consultationPricing.ID = id
 
 	if err := consultationPricing.Update(c); err != nil {
@@ -102,35 +104,20 @@ func DeleteConsultationPricing(c *gin.Context) {
 
 // GetAllConsultationPricing handles retrieving a list of all consultation pricing entries.
 func GetAllConsultationPricing(c *gin.Context) {
-	psychologistIDStr := c.Query("psychologist")
-
-	var pricingList []models.ConsultationPricing
-	var psychologistID int
-	var err error
-
-	if psychologistIDStr != "" {
-		psychologistID, err = strconv.Atoi(psychologistIDStr)
-		if err != nil {
-			c.Error(err)
-			return
-		}
-
-		pricingList, err = models.GetConsultationPricingByPsychologistID(c, psychologistID)
-		if err != nil {
-			c.Error(err)
-			return
-		}
-	} else {
-		pricingList, err = models.GetAllConsultationPricing(c)
-		if err != nil {
-			c.Error(err)
-			return
-		}
+	params, ok := parseListParams(c, time.UTC)
+	if !ok {
+		return
+	}
+
+	pricingList, total, err := models.ListConsultationPricing(c, params)
+	if err != nil {
+		handleListError(c, err)
+		return
 	}
 
 	if len(pricingList) == 0 {
 		pricingList = []models.ConsultationPricing{}
 	}
 
-	c.JSON(http.StatusOK, pricingList)
+	respondWithPage(c, params, total, pricingList)
 }
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+	"github.com/vitalicher97/psychologist_app/internal/app/storage"
+)
+
+// maxDocumentSize limits the size of an uploaded credential document.
+const maxDocumentSize = 10 << 20
+
+// documentExtensions maps the accepted document types to the extension they are stored with.
+var documentExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// Errors returned when a credential document is rejected.
+var (
+	errDocumentTooLarge    = errors.New("the document must not be larger than 10 MB")
+	errUnsupportedDocument = errors.New("the document must be a PDF, JPEG or PNG file")
+)
+
+// UploadCredentialDocument handles adding a credential document to a draft profile.
+// The document is uploaded as the multipart "document" field.
+func UploadCredentialDocument(c *gin.Context) {
+	id, ok := ownPsychologistID(c)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("document")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "upload the document as the multipart document field"})
+		return
+	}
+	if file.Size > maxDocumentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errDocumentTooLarge.Error()})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxDocumentSize+1))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if len(data) > maxDocumentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errDocumentTooLarge.Error()})
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := documentExtensions[contentType]
+	if !ok {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": errUnsupportedDocument.Error()})
+		return
+	}
+
+	name, err := documentName(id, ext)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if err := storage.Documents().Put(c, name, bytes.NewReader(data)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	document := &models.CredentialDocument{
+		PsychologistID: id,
+		StorageName:    name,
+		OriginalName:   filepath.Base(file.Filename),
+		ContentType:    contentType,
+		Size:           int64(len(data)),
+	}
+	if err := document.Create(c); err != nil {
+		deleteDocumentFile(c, name)
+		handleOnboardingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, document)
+}
+
+// GetCredentialDocuments handles listing the credential documents of a psychologist.
+func GetCredentialDocuments(c *gin.Context) {
+	id, ok := ownPsychologistID(c)
+	if !ok {
+		return
+	}
+
+	documents, err := models.GetCredentialDocumentsByPsychologist(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(documents) == 0 {
+		documents = []models.CredentialDocument{}
+	}
+
+	c.JSON(http.StatusOK, documents)
+}
+
+// DownloadCredentialDocument handles serving the file of a credential document.
+func DownloadCredentialDocument(c *gin.Context) {
+	document, ok := credentialDocumentParams(c)
+	if !ok {
+		return
+	}
+
+	if _, err := document.GetByID(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	r, err := storage.Documents().Open(c, document.StorageName)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer r.Close()
+
+	c.DataFromReader(http.StatusOK, document.Size, document.ContentType, r, map[string]string{
+		"Content-Disposition":    fmt.Sprintf("attachment; filename=%q", document.OriginalName),
+		"Cache-Control":          "private, no-store",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteCredentialDocument handles removing a credential document from a draft profile.
+func DeleteCredentialDocument(c *gin.Context) {
+	document, ok := credentialDocumentParams(c)
+	if !ok {
+		return
+	}
+
+	if err := document.DeleteByID(c); err != nil {
+		handleOnboardingError(c, err)
+		return
+	}
+	deleteDocumentFile(c, document.StorageName)
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ownPsychologistID reads the psychologist ID from the path and ensures the psychologist exists and the
+// caller may see their private data.
+func ownPsychologistID(c *gin.Context) (int, bool) {
+	id, ok := existingPsychologistID(c)
+	if !ok {
+		return 0, false
+	}
+
+	if psychologistAudience(c, id) < audienceSelf {
+		respondForbidden(c)
+		return 0, false
+	}
+
+	return id, true
+}
+
+// credentialDocumentParams reads the psychologist and document IDs from the path.
+func credentialDocumentParams(c *gin.Context) (*models.CredentialDocument, bool) {
+	psychologistID, ok := ownPsychologistID(c)
+	if !ok {
+		return nil, false
+	}
+
+	documentID, err := strconv.Atoi(c.Param("document_id"))
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+
+	return &models.CredentialDocument{ID: documentID, PsychologistID: psychologistID}, true
+}
+
+// documentName returns an unguessable storage name for a document of the psychologist.
+func documentName(psychologistID int, ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d_%s%s", psychologistID, hex.EncodeToString(b), ext), nil
+}
+
+// deleteDocumentFile removes a stored document file, logging failures.
+func deleteDocumentFile(c *gin.Context, name string) {
+	if err := storage.Documents().Delete(c, name); err != nil {
+		log.Printf("Failed to delete document %s: %v", name, err)
+	}
+}
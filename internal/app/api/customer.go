@@ -3,9 +3,11 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
 	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
 )
 
@@ -22,7 +24,7 @@ func CreateCustomer(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, customer)
+	c.JSON(http.StatusCreated, newCustomerView(&customer, creatorAudience(c)))
 }
 
 // GetCustomer handles retrieving a customer by ID.
@@ -43,7 +45,7 @@ func GetCustomer(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, customer)
+	c.JSON(http.StatusOK, newCustomerView(customer, customerAudience(c, id)))
 }
 
 // UpdateCustomer handles updating a customer's data by ID.
@@ -70,6 +72,7 @@ func UpdateCustomer(c *gin.Context) {
 	}
 
 	customer.CreatedAt = existingCustomer.CreatedAt
+	customer.CreatedBy = existingCustomer.CreatedBy
 	customer.ID = id
 
 	if err := customer.Update(c); err != nil {
@@ -77,7 +80,7 @@ func UpdateCustomer(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, customer)
+	c.JSON(http.StatusOK, newCustomerView(&customer, customerAudience(c, id)))
 }
 
 // DeleteCustomer handles deleting a customer by ID.
@@ -100,24 +103,58 @@ func DeleteCustomer(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
-// GetAllCustomers handles retrieving a list of all customers.
+// GetAllCustomers handles retrieving a list of customers. Administrators see every customer, psychologists
+// the customers who booked with them and customers only themselves.
+// Looking a customer up by the "email" query parameter is reserved for administrators and the customer themself.
 func GetAllCustomers(c *gin.Context) {
 	email := c.Query("email")
 	if email != "" {
-		customer, err := models.GetCustomerByEmail(c, email)
-		if err != nil {
-			c.Error(err)
-			return
-		}
-		c.JSON(http.StatusOK, customer)
+		getCustomerByEmail(c, email)
 		return
 	}
 
-	customers, err := models.GetAllCustomers(c)
+	params, ok := parseListParams(c, time.UTC)
+	if !ok {
+		return
+	}
+
+	if !auth.PrincipalFrom(c).IsAdmin() && usesPrivateFields(params, "last_name", "email", "phone") {
+		respondForbidden(c)
+		return
+	}
+
+	customers, total, err := models.ListCustomers(c, params)
+	if err != nil {
+		handleListError(c, err)
+		return
+	}
+
+	views := make([]customerView, 0, len(customers))
+	for i := range customers {
+		views = append(views, newCustomerView(&customers[i], customerAudience(c, customers[i].ID)))
+	}
+
+	respondWithPage(c, params, total, views)
+}
+
+// getCustomerByEmail responds with the customer registered under the email. Anyone but an administrator
+// gets 403, without revealing whether the email is registered, unless the customer is themself.
+func getCustomerByEmail(c *gin.Context, email string) {
+	principal := auth.PrincipalFrom(c)
+	if !principal.IsAdmin() && principal.Role != auth.RoleCustomer {
+		respondForbidden(c)
+		return
+	}
+
+	customer, err := models.GetCustomerByEmail(c, email)
+	if !principal.IsAdmin() && (err != nil || !principal.IsCustomer(customer.ID)) {
+		respondForbidden(c)
+		return
+	}
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, customers)
+	c.JSON(http.StatusOK, newCustomerView(customer, customerAudience(c, customer.ID)))
 }
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"time"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// customerView is a customer as shown to other callers: only their first name. Contact details and record
+// timestamps are only added for the customer themself, and audit fields only for administrators.
+type customerView struct {
+	ID        int    `json:"id"`
+	FirstName string `json:"first_name"`
+
+	*customerPrivateView
+	*auditView
+}
+
+// customerPrivateView holds the fields of a customer only they and administrators see.
+type customerPrivateView struct {
+	LastName  string    `json:"last_name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// newCustomerView shapes the customer for the audience.
+func newCustomerView(customer *models.Customer, aud audience) customerView {
+	view := customerView{ID: customer.ID, FirstName: customer.FirstName}
+
+	if aud >= audienceSelf {
+		view.customerPrivateView = &customerPrivateView{
+			LastName:  customer.LastName,
+			Email:     customer.Email,
+			Phone:     customer.Phone,
+			CreatedAt: customer.CreatedAt,
+			UpdatedAt: customer.UpdatedAt,
+		}
+	}
+	if aud >= audienceAdmin {
+		view.auditView = &auditView{CreatedBy: customer.CreatedBy, UpdatedBy: customer.UpdatedBy}
+	}
+
+	return view
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Related data that can be embedded in responses through the "include" query parameter.
+const (
+	includeSpecializations = "specializations"
+	includeLanguages       = "languages"
+	includeModalities      = "modalities"
+	includeOfficeLocations = "office_locations"
+)
+
+// parseIncludes reads the comma separated "include" query parameter, accepting only the allowed names.
+// It responds with 400 and reports false when an unknown name is requested.
+func parseIncludes(c *gin.Context, allowed ...string) (map[string]bool, bool) {
+	includes := make(map[string]bool)
+	for _, name := range strings.Split(c.Query("include"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		known := false
+		for _, a := range allowed {
+			if name == a {
+				known = true
+				break
+			}
+		}
+		if !known {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("cannot include %q", name)})
+			return nil, false
+		}
+
+		includes[name] = true
+	}
+
+	return includes, true
+}
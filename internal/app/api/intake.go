@@ -0,0 +1,214 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// psychologistMatchView is a psychologist ranked for an intake response, with the reasons they matched or not.
+type psychologistMatchView struct {
+	Psychologist psychologistView `json:"psychologist"`
+	Score        float64          `json:"score"`
+	Reasons      []string         `json:"reasons"`
+	Mismatches   []string         `json:"mismatches"`
+}
+
+// intakeResponseView is a stored intake response together with the psychologists it matches.
+type intakeResponseView struct {
+	Response *models.IntakeResponse  `json:"response"`
+	Matches  []psychologistMatchView `json:"matches"`
+}
+
+// GetIntakeQuestionnaire handles retrieving the active questions of the intake questionnaire.
+func GetIntakeQuestionnaire(c *gin.Context) {
+	questions, err := models.GetIntakeQuestionnaire(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, questions)
+}
+
+// GetAllIntakeQuestions handles retrieving every intake question, including inactive ones.
+func GetAllIntakeQuestions(c *gin.Context) {
+	questions, err := models.ListIntakeQuestions(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, questions)
+}
+
+// CreateIntakeQuestion handles adding a question to the intake questionnaire.
+func CreateIntakeQuestion(c *gin.Context) {
+	var question models.IntakeQuestion
+	if err := c.ShouldBindJSON(&question); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := question.Create(c); err != nil {
+		handleIntakeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, question)
+}
+
+// UpdateIntakeQuestion handles changing an intake question by ID.
+func UpdateIntakeQuestion(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var question models.IntakeQuestion
+	if err := c.ShouldBindJSON(&question); err != nil {
+		c.Error(err)
+		return
+	}
+	question.ID = id
+
+	if err := question.Update(c); err != nil {
+		handleIntakeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, question)
+}
+
+// DeleteIntakeQuestion handles removing an intake question by ID.
+func DeleteIntakeQuestion(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	question := &models.IntakeQuestion{ID: id}
+	if err := question.DeleteByID(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// CreateIntakeResponse handles submitting a filled in questionnaire and responds with the best matching
+// psychologists. Signed in customers have the response recorded against them.
+func CreateIntakeResponse(c *gin.Context) {
+	var response models.IntakeResponse
+	if err := c.ShouldBindJSON(&response); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.CustomerID = nil
+	if principal := auth.PrincipalFrom(c); principal.Role == auth.RoleCustomer {
+		response.CustomerID = &principal.CustomerID
+	}
+
+	if err := response.Create(c); err != nil {
+		handleIntakeError(c, err)
+		return
+	}
+
+	matches, ok := matchPsychologists(c, &response)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, intakeResponseView{Response: &response, Matches: matches})
+}
+
+// GetIntakeResponseMatches handles ranking the psychologists for a stored intake response again.
+// Only the customer who gave the response and administrators can see it.
+func GetIntakeResponseMatches(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := &models.IntakeResponse{ID: id}
+
+	response, err = response.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	principal := auth.PrincipalFrom(c)
+	if !principal.IsAdmin() && (response.CustomerID == nil || !principal.IsCustomer(*response.CustomerID)) {
+		respondForbidden(c)
+		return
+	}
+
+	matches, ok := matchPsychologists(c, response)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, intakeResponseView{Response: response, Matches: matches})
+}
+
+// matchPsychologists ranks the psychologists for the response, limited by the limit query parameter.
+func matchPsychologists(c *gin.Context, response *models.IntakeResponse) ([]psychologistMatchView, bool) {
+	limit := models.DefaultMatchLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return nil, false
+		}
+		limit = n
+	}
+
+	matches, err := models.MatchPsychologists(c, response.Answers, limit)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+
+	views := make([]psychologistMatchView, len(matches))
+	for i := range matches {
+		views[i] = psychologistMatchView{
+			Psychologist: newPsychologistView(&matches[i].Psychologist, psychologistAudience(c, matches[i].Psychologist.ID)),
+			Score:        matches[i].Score,
+			Reasons:      matches[i].Reasons,
+			Mismatches:   matches[i].Mismatches,
+		}
+	}
+
+	return views, true
+}
+
+// handleIntakeError maps intake errors to HTTP responses.
+func handleIntakeError(c *gin.Context, err error) {
+	var answerErr *models.IntakeAnswerError
+	switch {
+	case errors.As(err, &answerErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": answerErr.Error(), "key": answerErr.Key})
+	case errors.Is(err, models.ErrUnknownIntakeKey), errors.Is(err, models.ErrIntakeOptionsNotAllowed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrDuplicateIntakeQuestion):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.Error(err)
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// languagesRequest is the JSON body used to replace the languages of a psychologist.
+type languagesRequest struct {
+	Languages []string `json:"languages" binding:"required"`
+}
+
+// GetPsychologistLanguages handles retrieving the languages a psychologist holds sessions in.
+func GetPsychologistLanguages(c *gin.Context) {
+	id, ok := existingPsychologistID(c)
+	if !ok {
+		return
+	}
+
+	respondWithLanguages(c, id)
+}
+
+// UpdatePsychologistLanguages handles replacing the languages a psychologist holds sessions in.
+func UpdatePsychologistLanguages(c *gin.Context) {
+	id, ok := existingPsychologistID(c)
+	if !ok {
+		return
+	}
+
+	var req languagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := models.SetPsychologistLanguages(c, id, req.Languages); err != nil {
+		handleLanguageError(c, err)
+		return
+	}
+
+	respondWithLanguages(c, id)
+}
+
+// AddPsychologistLanguage handles adding a language to the ones a psychologist holds sessions in.
+// It responds with the psychologist's languages.
+func AddPsychologistLanguage(c *gin.Context) {
+	id, ok := existingPsychologistID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.AddPsychologistLanguage(c, id, c.Param("code")); err != nil {
+		handleLanguageError(c, err)
+		return
+	}
+
+	respondWithLanguages(c, id)
+}
+
+// RemovePsychologistLanguage handles removing a language from the ones a psychologist holds sessions in.
+func RemovePsychologistLanguage(c *gin.Context) {
+	id, ok := existingPsychologistID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.RemovePsychologistLanguage(c, id, c.Param("code")); err != nil {
+		handleLanguageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// existingPsychologistID reads the psychologist ID from the path and ensures the psychologist exists.
+func existingPsychologistID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return 0, false
+	}
+
+	psychologist := &models.Psychologist{ID: id}
+	if _, err := psychologist.GetByID(c); err != nil {
+		c.Error(err)
+		return 0, false
+	}
+
+	return id, true
+}
+
+// respondWithLanguages writes the language codes of a psychologist.
+func respondWithLanguages(c *gin.Context, psychologistID int) {
+	languages, err := models.GetLanguagesByPsychologist(c, psychologistID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(languages) == 0 {
+		languages = []string{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"psychologist_id": psychologistID, "languages": languages})
+}
+
+// handleLanguageError responds to invalid language codes and passes any other error to the error middleware.
+func handleLanguageError(c *gin.Context, err error) {
+	if errors.Is(err, models.ErrInvalidLanguageCode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Error(err)
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// listControlParams are the query parameters of list endpoints that are not field filters.
+var listControlParams = map[string]bool{
+	"page":    true,
+	"limit":   true,
+	"sort":    true,
+	"tz":      true,
+	"include": true,
+	"q":       true,
+	"lang":    true,
+}
+
+// page is the response envelope of list endpoints.
+type page struct {
+	Data       interface{} `json:"data"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+	Total      int         `json:"total"`
+	TotalPages int         `json:"total_pages"`
+	Links      pageLinks   `json:"links"`
+}
+
+// pageLinks points to the current, next and previous pages of a list.
+type pageLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// parseListParams reads the page, limit, sort and field filter query parameters.
+// Sort takes a comma separated list of fields, each prefixed with "-" for descending order.
+// Every other query parameter except the control ones is passed on as a filter, and the filters the
+// route's policy scopes the list with override whatever the caller asked for.
+// It responds with 400 and reports false when a parameter is malformed.
+func parseListParams(c *gin.Context, loc *time.Location) (*models.ListParams, bool) {
+	params := &models.ListParams{Filters: make(map[string]string), Location: loc}
+
+	var err error
+	if params.Page, err = parsePositiveParam(c, "page", 1); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if params.Page > models.MaxPage {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("page must be at most %d", models.MaxPage)})
+		return nil, false
+	}
+	if params.Limit, err = parsePositiveParam(c, "limit", models.DefaultPageSize); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	for _, field := range strings.Split(c.Query("sort"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		sort := models.SortField{Field: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")}
+		params.Sort = append(params.Sort, sort)
+	}
+
+	for name, values := range c.Request.URL.Query() {
+		if listControlParams[name] || len(values) == 0 || values[0] == "" {
+			continue
+		}
+		params.Filters[name] = values[0]
+	}
+
+	for name, value := range c.GetStringMapString(listScopeKey) {
+		params.Filters[name] = value
+	}
+
+	return params, true
+}
+
+// parsePositiveParam reads a positive integer from the query, returning def when it is absent.
+func parsePositiveParam(c *gin.Context, name string, def int) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive number", name)
+	}
+
+	return n, nil
+}
+
+// respondWithPage writes a page of a list in the response envelope, with links to the neighbouring pages.
+func respondWithPage(c *gin.Context, params *models.ListParams, total int, data interface{}) {
+	totalPages := (total + params.Limit - 1) / params.Limit
+
+	links := pageLinks{Self: pageURL(c, params.Page, params.Limit)}
+	if params.Page < totalPages {
+		links.Next = pageURL(c, params.Page+1, params.Limit)
+	}
+	if params.Page > 1 {
+		links.Prev = pageURL(c, min(params.Page-1, max(totalPages, 1)), params.Limit)
+	}
+
+	c.JSON(http.StatusOK, page{
+		Data:       data,
+		Page:       params.Page,
+		Limit:      params.Limit,
+		Total:      total,
+		TotalPages: totalPages,
+		Links:      links,
+	})
+}
+
+// pageURL returns the request URL pointing to the given page.
+func pageURL(c *gin.Context, number, limit int) string {
+	u := *c.Request.URL
+	query := u.Query()
+	query.Set("page", strconv.Itoa(number))
+	query.Set("limit", strconv.Itoa(limit))
+	u.RawQuery = query.Encode()
+
+	return u.RequestURI()
+}
+
+// handleListError responds to invalid list parameters and passes any other error to the error middleware.
+func handleListError(c *gin.Context, err error) {
+	var paramErr *models.ListParamError
+	if errors.As(err, &paramErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": paramErr.Error(), "param": paramErr.Param})
+		return
+	}
+
+	c.Error(err)
+}
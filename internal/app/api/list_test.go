@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+func TestParseListParamsPage(t *testing.T) {
+	tests := []struct {
+		query    string
+		want     int
+		wantCode int
+	}{
+		{query: "", want: 1, wantCode: http.StatusOK},
+		{query: "page=3", want: 3, wantCode: http.StatusOK},
+		{query: "page=" + strconv.Itoa(models.MaxPage), want: models.MaxPage, wantCode: http.StatusOK},
+		{query: "page=" + strconv.Itoa(models.MaxPage+1), wantCode: http.StatusBadRequest},
+		{query: "page=4611686018427387904", wantCode: http.StatusBadRequest},
+		{query: "page=99999999999999999999", wantCode: http.StatusBadRequest},
+		{query: "page=0", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got *models.ListParams
+			w := serve(anonymous, http.MethodGet, "/things", httptest.NewRequest(http.MethodGet, "/things?"+tt.query, nil), func(c *gin.Context) {
+				params, ok := parseListParams(c, time.UTC)
+				if !ok {
+					c.Abort()
+					return
+				}
+				got = params
+			})
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusOK && got.Page != tt.want {
+				t.Errorf("Page = %d, want %d", got.Page, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// modalitiesRequest is the JSON body used to replace the modalities of a psychologist.
+type modalitiesRequest struct {
+	Modalities []models.Modality `json:"modalities" binding:"required"`
+}
+
+// GetPsychologistModalities handles retrieving the modalities a psychologist offers.
+func GetPsychologistModalities(c *gin.Context) {
+	id, ok := existingPsychologistID(c)
+	if !ok {
+		return
+	}
+
+	respondWithModalities(c, id)
+}
+
+// UpdatePsychologistModalities handles replacing the modalities a psychologist offers.
+func UpdatePsychologistModalities(c *gin.Context) {
+	id, ok := existingPsychologistID(c)
+	if !ok {
+		return
+	}
+
+	var req modalitiesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := models.SetPsychologistModalities(c, id, req.Modalities); err != nil {
+		handleModalityError(c, err)
+		return
+	}
+
+	respondWithModalities(c, id)
+}
+
+// AddPsychologistModality handles adding a modality to the ones a psychologist offers.
+// It responds with the psychologist's modalities.
+func AddPsychologistModality(c *gin.Context) {
+	id, ok := existingPsychologistID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.AddPsychologistModality(c, id, models.Modality(c.Param("modality"))); err != nil {
+		handleModalityError(c, err)
+		return
+	}
+
+	respondWithModalities(c, id)
+}
+
+// RemovePsychologistModality handles removing a modality from the ones a psychologist offers.
+func RemovePsychologistModality(c *gin.Context) {
+	id, ok := existingPsychologistID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.RemovePsychologistModality(c, id, models.Modality(c.Param("modality"))); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// respondWithModalities writes the modalities of a psychologist.
+func respondWithModalities(c *gin.Context, psychologistID int) {
+	modalities, err := models.GetModalitiesByPsychologist(c, psychologistID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(modalities) == 0 {
+		modalities = []models.Modality{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"psychologist_id": psychologistID, "modalities": modalities})
+}
+
+// handleModalityError responds to unknown modalities and passes any other error to the error middleware.
+func handleModalityError(c *gin.Context, err error) {
+	if errors.Is(err, models.ErrInvalidModality) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Error(err)
+}
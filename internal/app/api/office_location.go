@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// CreateOfficeLocation handles adding an office location to a psychologist.
+func CreateOfficeLocation(c *gin.Context) {
+	var location models.OfficeLocation
+	if err := c.ShouldBindJSON(&location); err != nil {
+		c.Error(err)
+		return
+	}
+
+	psychologist := &models.Psychologist{ID: location.PsychologistID}
+	if _, err := psychologist.GetByID(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := location.Create(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, location)
+}
+
+// GetOfficeLocation handles retrieving an office location by ID.
+func GetOfficeLocation(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	location := &models.OfficeLocation{ID: id}
+
+	location, err = location.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+}
+
+// UpdateOfficeLocation handles updating the address of an office location by ID.
+func UpdateOfficeLocation(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	existingLocation := &models.OfficeLocation{ID: id}
+	existingLocation, err = existingLocation.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var location models.OfficeLocation
+	if err := c.ShouldBindJSON(&location); err != nil {
+		c.Error(err)
+		return
+	}
+
+	location.CreatedAt = existingLocation.CreatedAt
+	location.CreatedBy = existingLocation.CreatedBy
+	location.ID = id
+
+	if err := location.Update(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+}
+
+// DeleteOfficeLocation handles deleting an office location by ID.
+func DeleteOfficeLocation(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	location := &models.OfficeLocation{ID: id}
+
+	if err := location.DeleteByID(c); err != nil {
+		if errors.Is(err, models.ErrOfficeLocationInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetAllOfficeLocations handles retrieving a list of office locations.
+func GetAllOfficeLocations(c *gin.Context) {
+	params, ok := parseListParams(c, time.UTC)
+	if !ok {
+		return
+	}
+
+	locations, total, err := models.ListOfficeLocations(c, params)
+	if err != nil {
+		handleListError(c, err)
+		return
+	}
+
+	if len(locations) == 0 {
+		locations = []models.OfficeLocation{}
+	}
+
+	respondWithPage(c, params, total, locations)
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// licenseRequest is the JSON body used to record a psychologist's license.
+type licenseRequest struct {
+	LicenseNumber string `json:"license_number" binding:"required,max=100"`
+	LicenseIssuer string `json:"license_issuer" binding:"required,max=200"`
+}
+
+// onboardingReasonRequest is the JSON body of the onboarding decisions that need a reason.
+type onboardingReasonRequest struct {
+	Reason string `json:"reason" binding:"required,max=1000"`
+}
+
+// UpdatePsychologistLicense handles recording the license of a psychologist whose profile is a draft.
+func UpdatePsychologistLicense(c *gin.Context) {
+	id, ok := ownPsychologistID(c)
+	if !ok {
+		return
+	}
+
+	var req licenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	psychologist := &models.Psychologist{ID: id}
+	if err := psychologist.SetLicense(c, req.LicenseNumber, req.LicenseIssuer); err != nil {
+		handleOnboardingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPsychologistView(psychologist, psychologistAudience(c, id)))
+}
+
+// SubmitPsychologistProfile handles sending a draft profile to the admin review queue.
+func SubmitPsychologistProfile(c *gin.Context) {
+	if _, ok := ownPsychologistID(c); !ok {
+		return
+	}
+
+	transitionOnboarding(c, func(p *models.Psychologist) error {
+		return p.Submit(c)
+	})
+}
+
+// ApprovePsychologistProfile handles verifying a submitted profile.
+func ApprovePsychologistProfile(c *gin.Context) {
+	transitionOnboarding(c, func(p *models.Psychologist) error {
+		return p.Approve(c)
+	})
+}
+
+// RejectPsychologistProfile handles sending a submitted profile back to draft with a reason.
+func RejectPsychologistProfile(c *gin.Context) {
+	var req onboardingReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	transitionOnboarding(c, func(p *models.Psychologist) error {
+		return p.Reject(c, req.Reason)
+	})
+}
+
+// SuspendPsychologistProfile handles removing a verified psychologist from the directory with a reason.
+func SuspendPsychologistProfile(c *gin.Context) {
+	var req onboardingReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	transitionOnboarding(c, func(p *models.Psychologist) error {
+		return p.Suspend(c, req.Reason)
+	})
+}
+
+// ReinstatePsychologistProfile handles listing a suspended psychologist in the directory again.
+func ReinstatePsychologistProfile(c *gin.Context) {
+	transitionOnboarding(c, func(p *models.Psychologist) error {
+		return p.Reinstate(c)
+	})
+}
+
+// GetOnboardingHistory handles retrieving the onboarding changes of a psychologist.
+func GetOnboardingHistory(c *gin.Context) {
+	id, ok := ownPsychologistID(c)
+	if !ok {
+		return
+	}
+
+	history, err := models.GetOnboardingHistory(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(history) == 0 {
+		history = []models.OnboardingChange{}
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// GetOnboardingQueue handles retrieving the profiles waiting for review, oldest submission first.
+func GetOnboardingQueue(c *gin.Context) {
+	params, ok := parseListParams(c, time.UTC)
+	if !ok {
+		return
+	}
+
+	psychologists, total, err := models.ListOnboardingQueue(c, params)
+	if err != nil {
+		handleListError(c, err)
+		return
+	}
+
+	views := make([]psychologistView, 0, len(psychologists))
+	for i := range psychologists {
+		views = append(views, newPsychologistView(&psychologists[i], psychologistAudience(c, psychologists[i].ID)))
+	}
+
+	respondWithPage(c, params, total, views)
+}
+
+// transitionOnboarding applies an onboarding change to the psychologist from the path.
+func transitionOnboarding(c *gin.Context, change func(p *models.Psychologist) error) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	psychologist := &models.Psychologist{ID: id}
+	if err := change(psychologist); err != nil {
+		handleOnboardingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPsychologistView(psychologist, psychologistAudience(c, id)))
+}
+
+// handleOnboardingError responds to rejected onboarding changes and passes any other error to the error middleware.
+func handleOnboardingError(c *gin.Context, err error) {
+	var transition *models.OnboardingTransitionError
+
+	switch {
+	case errors.Is(err, models.ErrReasonRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrLicenseIncomplete):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrOnboardingLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &transition):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":               transition.Error(),
+			"status":              transition.From,
+			"allowed_transitions": transition.Allowed,
+		})
+	default:
+		c.Error(err)
+	}
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+	"github.com/vitalicher97/psychologist_app/internal/app/imaging"
+	"github.com/vitalicher97/psychologist_app/internal/app/storage"
+)
+
+// maxPictureSize limits the size of an uploaded profile picture.
+const maxPictureSize = 5 << 20
+
+// pictureSizes are the edge lengths of the square thumbnails generated for every profile picture, largest first.
+// The first one is used as the profile picture itself.
+var pictureSizes = []int{512, 256, 96}
+
+// generatedPicture matches the content-addressed names of generated thumbnails.
+var generatedPicture = regexp.MustCompile(`^([0-9a-f]{64})_[0-9]+\.jpg$`)
+
+// UploadPsychologistPicture handles replacing the profile picture of a psychologist.
+// The picture is uploaded as the multipart "picture" field.
+func UploadPsychologistPicture(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	psychologist := &models.Psychologist{ID: id}
+	if _, err := psychologist.GetByID(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	file, err := c.FormFile("picture")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "upload the picture as the multipart picture field"})
+		return
+	}
+	if file.Size > maxPictureSize {
+		handlePictureError(c, imaging.ErrTooLarge)
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer f.Close()
+
+	data, _, err := imaging.Read(f, maxPictureSize)
+	if err != nil {
+		handlePictureError(c, err)
+		return
+	}
+
+	img, err := imaging.Decode(data)
+	if err != nil {
+		handlePictureError(c, err)
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+	for i, thumbnail := range imaging.Thumbnails(img, pictureSizes...) {
+		size := pictureSizes[i]
+		encoded, err := imaging.EncodeJPEG(thumbnail)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		if err := storage.Default().Put(c, pictureName(key, size), bytes.NewReader(encoded)); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	picture := pictureName(key, pictureSizes[0])
+	previous, err := psychologist.SetProfilePicture(c, picture)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if previous != "" && previous != picture {
+		removeUnusedPicture(c, previous)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"profile_picture": picture,
+		"thumbnails":      pictureThumbnails(key),
+	})
+}
+
+// GetImage handles serving a stored picture by name.
+func GetImage(c *gin.Context) {
+	name := c.Param("imageName")
+
+	r, err := storage.Default().Open(c, name)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidName) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer r.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	headers := map[string]string{"X-Content-Type-Options": "nosniff"}
+	if generatedPicture.MatchString(name) {
+		// Generated names change whenever the content does.
+		headers["Cache-Control"] = "public, max-age=31536000, immutable"
+	}
+
+	c.DataFromReader(http.StatusOK, -1, contentType, r, headers)
+}
+
+// pictureName returns the content-addressed name of a thumbnail.
+func pictureName(key string, size int) string {
+	return fmt.Sprintf("%s_%d.jpg", key, size)
+}
+
+// pictureThumbnails returns the URLs of all thumbnails of a picture keyed by their size.
+func pictureThumbnails(key string) map[string]string {
+	thumbnails := make(map[string]string, len(pictureSizes))
+	for _, size := range pictureSizes {
+		thumbnails[strconv.Itoa(size)] = "/image/" + pictureName(key, size)
+	}
+
+	return thumbnails
+}
+
+// removeUnusedPicture deletes the thumbnails of a replaced picture unless another psychologist still uses it.
+// Pictures that were not generated by the upload pipeline are kept.
+func removeUnusedPicture(c *gin.Context, picture string) {
+	match := generatedPicture.FindStringSubmatch(picture)
+	if match == nil {
+		return
+	}
+
+	inUse, err := models.IsProfilePictureInUse(c, picture)
+	if err != nil || inUse {
+		return
+	}
+
+	for _, size := range pictureSizes {
+		if err := storage.Default().Delete(c, pictureName(match[1], size)); err != nil {
+			log.Printf("Failed to delete picture %s: %v", pictureName(match[1], size), err)
+		}
+	}
+}
+
+// handlePictureError responds to rejected pictures and passes any other error to the error middleware.
+func handlePictureError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, imaging.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, imaging.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error(), "allowed_types": imaging.AllowedTypes})
+	case errors.Is(err, imaging.ErrInvalidImage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.Error(err)
+	}
+}
@@ -0,0 +1,329 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// Gin context keys policies store their decisions under.
+const (
+	// listScopeKey holds the filters a policy forces on list endpoints.
+	listScopeKey = "api.listScope"
+	// apiKeyGrantedKey is set once a group policy let an API key in through one of its scopes.
+	apiKeyGrantedKey = "api.apiKeyGranted"
+)
+
+// rule decides whether the caller may make the request. Rules that look records up can fail.
+type rule func(c *gin.Context, principal *auth.Principal) (bool, error)
+
+// policy says who may call the routes it is attached to. Read applies to GET requests and write to
+// every other method. Administrators may call every route.
+//
+// Resource names the API key scopes of the group: keys with "<resource>:read" may make its GET requests
+// and keys with "<resource>:write" the others, seeing everything an administrator would. Other keys may
+// only make the requests anonymous callers can.
+//
+// Scope names, per role, the list filter forced to the caller's own psychologist or customer ID,
+// so list endpoints only return the records the caller may see whatever filters they ask for.
+type policy struct {
+	read     rule
+	write    rule
+	resource string
+	scope    map[auth.Role]string
+}
+
+// handler returns the middleware enforcing the policy. Anonymous callers that are turned away are
+// asked to sign in with 401; signed in callers get 403.
+func (p policy) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := auth.PrincipalFrom(c)
+
+		check, access := p.write, "write"
+		if c.Request.Method == http.MethodGet {
+			check, access = p.read, "read"
+		}
+
+		if !principal.IsAdmin() {
+			var allowed bool
+			var err error
+			if principal.IsAPIKey() {
+				allowed, err = p.allowsKey(c, principal, check, access)
+			} else {
+				allowed, err = check(c, principal)
+			}
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			if !allowed {
+				if principal.IsAnonymous() {
+					respondUnauthorized(c)
+				} else {
+					respondForbidden(c)
+				}
+				c.Abort()
+				return
+			}
+		}
+
+		if filter, ok := p.scope[principal.Role]; ok {
+			scopeList(c, filter, principal)
+		}
+
+		c.Next()
+	}
+}
+
+// allowsKey decides whether an API key may make the request.
+func (p policy) allowsKey(c *gin.Context, principal *auth.Principal, check rule, access string) (bool, error) {
+	switch {
+	case p.resource != "" && principal.HasScope(p.resource+":"+access):
+		c.Set(apiKeyGrantedKey, true)
+		return true, nil
+	case p.resource == "" && c.GetBool(apiKeyGrantedKey):
+		// Single routes follow the decision of their group.
+		return true, nil
+	default:
+		return check(c, &auth.Principal{})
+	}
+}
+
+// allow returns the middleware of a policy that applies the rule to requests of every method.
+// It is used on single routes whose rule differs from the rest of their group.
+func allow(r rule) gin.HandlerFunc {
+	return policy{read: r, write: r}.handler()
+}
+
+// scopeList forces the list filter to the ID of the psychologist or customer the caller acts as.
+func scopeList(c *gin.Context, filter string, principal *auth.Principal) {
+	id := principal.PsychologistID
+	if principal.Role == auth.RoleCustomer {
+		id = principal.CustomerID
+	}
+
+	scope := c.GetStringMapString(listScopeKey)
+	if scope == nil {
+		scope = make(map[string]string)
+	}
+	scope[filter] = strconv.Itoa(id)
+	c.Set(listScopeKey, scope)
+}
+
+// anyone lets every caller through, signed in or not.
+func anyone(*gin.Context, *auth.Principal) (bool, error) {
+	return true, nil
+}
+
+// signedIn lets every signed in caller through.
+func signedIn(_ *gin.Context, principal *auth.Principal) (bool, error) {
+	return !principal.IsAnonymous(), nil
+}
+
+// adminsOnly lets nobody but administrators through.
+func adminsOnly(*gin.Context, *auth.Principal) (bool, error) {
+	return false, nil
+}
+
+// withoutQuery lets through requests that do not use any of the query parameters, such as filters
+// only administrators may use.
+func withoutQuery(names ...string) rule {
+	return func(c *gin.Context, _ *auth.Principal) (bool, error) {
+		for _, name := range names {
+			if _, ok := c.GetQuery(name); ok {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	}
+}
+
+// party is the side of a record an ownership rule lets act on it.
+type party int
+
+// Parties of a record.
+const (
+	psychologistParty party = 1 << iota
+	customerParty
+	eitherParty = psychologistParty | customerParty
+)
+
+// ownerLookup finds the owner of the record with the ID.
+type ownerLookup func(ctx context.Context, id int) (models.Owner, error)
+
+// psychologistRecord is the lookup of routes whose ID is the psychologist itself.
+func psychologistRecord(_ context.Context, id int) (models.Owner, error) {
+	return models.Owner{PsychologistID: id}, nil
+}
+
+// customerRecord is the lookup of routes whose ID is the customer itself.
+func customerRecord(_ context.Context, id int) (models.Owner, error) {
+	return models.Owner{CustomerID: id}, nil
+}
+
+// customerOrTheirPsychologist lets through the customer of the "id" path parameter and the psychologists
+// the customer has booked with.
+func customerOrTheirPsychologist(c *gin.Context, principal *auth.Principal) (bool, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return false, err
+	}
+
+	if principal.IsCustomer(id) {
+		return true, nil
+	}
+	if principal.Role != auth.RolePsychologist {
+		return false, nil
+	}
+
+	return models.HasBookedWith(c, id, principal.PsychologistID)
+}
+
+// owns lets the caller through when they are the given party of every record the request names:
+// the record of the "id" path parameter, found with lookup; the psychologist_id and customer_id fields
+// of the body; and the psychologist and customer query parameters, with or without the _id suffix.
+// Requests that name no record are turned away. Lookup may be nil on routes without an ID.
+func owns(parties party, lookup ownerLookup) rule {
+	return func(c *gin.Context, principal *auth.Principal) (bool, error) {
+		owners, err := requestOwners(c, lookup)
+		if err != nil || len(owners) == 0 {
+			return false, err
+		}
+
+		for _, owner := range owners {
+			isPsychologist := parties&psychologistParty != 0 && principal.IsPsychologist(owner.PsychologistID)
+			isCustomer := parties&customerParty != 0 && principal.IsCustomer(owner.CustomerID)
+			if !isPsychologist && !isCustomer {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	}
+}
+
+// requestOwners collects the owners of the records the request names.
+func requestOwners(c *gin.Context, lookup ownerLookup) ([]models.Owner, error) {
+	var owners []models.Owner
+
+	if idStr := c.Param("id"); idStr != "" && lookup != nil {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return nil, err
+		}
+
+		owner, err := lookup(c, id)
+		if err != nil {
+			return nil, err
+		}
+		owners = append(owners, owner)
+	}
+
+	body, err := bodyOwners(c)
+	if err != nil {
+		return nil, err
+	}
+	owners = append(owners, body...)
+
+	if owner, ok := queryOwner(c); ok {
+		owners = append(owners, owner)
+	}
+
+	return owners, nil
+}
+
+// maxFormMemory is how much of a multipart body is kept in memory while it is parsed, as gin does.
+const maxFormMemory = 32 << 20
+
+// bodyOwners reads the psychologist_id and customer_id fields of the body. Form bodies are parsed the
+// way gin binds them and every value of a repeated field is returned, paired in order. Any other body
+// is read as JSON whatever its content type, since JSON binding does not look at it; a JSON body is
+// put back so the handler can still bind it. Bodies that do not parse name no record, and the handler
+// rejects them when it binds.
+func bodyOwners(c *gin.Context) ([]models.Owner, error) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, nil
+	}
+
+	switch c.ContentType() {
+	case gin.MIMEPOSTForm, gin.MIMEMultipartPOSTForm:
+		// Whatever parsed before an error is what the handler would bind, so it is checked either way.
+		_ = c.Request.ParseMultipartForm(maxFormMemory)
+
+		return formOwners(c.Request.PostForm), nil
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+
+	var fields struct {
+		PsychologistID *int `json:"psychologist_id"`
+		CustomerID     *int `json:"customer_id"`
+	}
+	if json.Unmarshal(data, &fields) != nil || (fields.PsychologistID == nil && fields.CustomerID == nil) {
+		return nil, nil
+	}
+
+	var owner models.Owner
+	if fields.PsychologistID != nil {
+		owner.PsychologistID = *fields.PsychologistID
+	}
+	if fields.CustomerID != nil {
+		owner.CustomerID = *fields.CustomerID
+	}
+
+	return []models.Owner{owner}, nil
+}
+
+// formOwners pairs the psychologist_id and customer_id values of a form in order. Values that are not
+// numbers name nobody.
+func formOwners(form url.Values) []models.Owner {
+	psychologists, customers := form["psychologist_id"], form["customer_id"]
+
+	owners := make([]models.Owner, max(len(psychologists), len(customers)))
+	for i := range owners {
+		if i < len(psychologists) {
+			owners[i].PsychologistID, _ = strconv.Atoi(psychologists[i])
+		}
+		if i < len(customers) {
+			owners[i].CustomerID, _ = strconv.Atoi(customers[i])
+		}
+	}
+
+	return owners
+}
+
+// queryOwner reads the psychologist and customer query parameters.
+func queryOwner(c *gin.Context) (models.Owner, bool) {
+	var owner models.Owner
+	found := false
+
+	for _, name := range []string{"psychologist", "psychologist_id"} {
+		if value, ok := c.GetQuery(name); ok {
+			owner.PsychologistID, _ = strconv.Atoi(value)
+			found = true
+		}
+	}
+	for _, name := range []string{"customer", "customer_id"} {
+		if value, ok := c.GetQuery(name); ok {
+			owner.CustomerID, _ = strconv.Atoi(value)
+			found = true
+		}
+	}
+
+	return owner, found
+}
@@ -0,0 +1,357 @@
+package api
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+)
+
+// Callers the policy tests act as.
+var (
+	anonymous     = &auth.Principal{}
+	admin         = &auth.Principal{UserID: 1, Role: auth.RoleAdmin}
+	psychologist7 = &auth.Principal{UserID: 2, Role: auth.RolePsychologist, PsychologistID: 7}
+	customer9     = &auth.Principal{UserID: 3, Role: auth.RoleCustomer, CustomerID: 9}
+)
+
+// serve routes the request through the handlers as the principal and returns the response.
+func serve(principal *auth.Principal, method, pattern string, req *http.Request, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	handlers = append([]gin.HandlerFunc{func(c *gin.Context) { auth.SetPrincipal(c, principal) }}, handlers...)
+	handlers = append(handlers, func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.Handle(method, pattern, handlers...)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+// jsonRequest builds a request with the body and content type.
+func jsonRequest(method, target, contentType, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return req
+}
+
+// formRequest builds a URL encoded form request.
+func formRequest(target string, values url.Values) *http.Request {
+	return jsonRequest(http.MethodPost, target, gin.MIMEPOSTForm, values.Encode())
+}
+
+// multipartRequest builds a multipart form request with the fields.
+func multipartRequest(target string, fields map[string][]string) *http.Request {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, values := range fields {
+		for _, value := range values {
+			_ = w.WriteField(name, value)
+		}
+	}
+	_ = w.Close()
+
+	return jsonRequest(http.MethodPost, target, w.FormDataContentType(), body.String())
+}
+
+func TestPolicyDeniesWithTheRightStatus(t *testing.T) {
+	p := policy{read: signedIn, write: adminsOnly}.handler()
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		method    string
+		want      int
+	}{
+		{name: "anonymous read", principal: anonymous, method: http.MethodGet, want: http.StatusUnauthorized},
+		{name: "signed in read", principal: customer9, method: http.MethodGet, want: http.StatusOK},
+		{name: "signed in write", principal: customer9, method: http.MethodPost, want: http.StatusForbidden},
+		{name: "anonymous write", principal: anonymous, method: http.MethodPost, want: http.StatusUnauthorized},
+		{name: "administrator write", principal: admin, method: http.MethodPost, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.principal, tt.method, "/things", httptest.NewRequest(tt.method, "/things", nil), p)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without a WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestWithoutQuery(t *testing.T) {
+	p := allow(withoutQuery("hidden"))
+
+	if w := serve(anonymous, http.MethodGet, "/reviews", httptest.NewRequest(http.MethodGet, "/reviews?rating=5", nil), p); w.Code != http.StatusOK {
+		t.Errorf("other query: status = %d, want 200", w.Code)
+	}
+	if w := serve(anonymous, http.MethodGet, "/reviews", httptest.NewRequest(http.MethodGet, "/reviews?hidden=", nil), p); w.Code != http.StatusUnauthorized {
+		t.Errorf("reserved query: status = %d, want 401", w.Code)
+	}
+}
+
+func TestOwnsPathRecord(t *testing.T) {
+	p := allow(owns(psychologistParty, psychologistRecord))
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		target    string
+		want      int
+	}{
+		{name: "own record", principal: psychologist7, target: "/psychologists/7", want: http.StatusOK},
+		{name: "other record", principal: psychologist7, target: "/psychologists/8", want: http.StatusForbidden},
+		{name: "wrong party", principal: customer9, target: "/psychologists/9", want: http.StatusForbidden},
+		{name: "anonymous", principal: anonymous, target: "/psychologists/7", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, tt.target, nil)
+			if w := serve(tt.principal, http.MethodPut, "/psychologists/:id", req, p); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestOwnsRequestFields(t *testing.T) {
+	either := allow(owns(eitherParty, nil))
+
+	// Requests naming someone else's record in the body also name one of the caller's in the query,
+	// so they are only turned away when the body is read.
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		req       *http.Request
+		want      int
+	}{
+		{
+			name:      "nothing named",
+			principal: psychologist7,
+			req:       jsonRequest(http.MethodPost, "/prices", gin.MIMEJSON, `{}`),
+			want:      http.StatusForbidden,
+		},
+		{
+			name:      "own JSON body",
+			principal: psychologist7,
+			req:       jsonRequest(http.MethodPost, "/prices", gin.MIMEJSON, `{"psychologist_id": 7, "customer_id": 1}`),
+			want:      http.StatusOK,
+		},
+		{
+			name:      "other JSON body",
+			principal: psychologist7,
+			req:       jsonRequest(http.MethodPost, "/prices", gin.MIMEJSON, `{"psychologist_id": 8}`),
+			want:      http.StatusForbidden,
+		},
+		{
+			name:      "JSON body sent as text",
+			principal: psychologist7,
+			req:       jsonRequest(http.MethodPost, "/prices?psychologist=7", "text/plain", `{"psychologist_id": 8}`),
+			want:      http.StatusForbidden,
+		},
+		{
+			name:      "JSON body without a content type",
+			principal: psychologist7,
+			req:       jsonRequest(http.MethodPost, "/prices?psychologist=7", "", `{"psychologist_id": 8}`),
+			want:      http.StatusForbidden,
+		},
+		{
+			name:      "customer of the JSON body",
+			principal: customer9,
+			req:       jsonRequest(http.MethodPost, "/prices", gin.MIMEJSON, `{"psychologist_id": 7, "customer_id": 9}`),
+			want:      http.StatusOK,
+		},
+		{
+			name:      "own form",
+			principal: psychologist7,
+			req:       formRequest("/prices", url.Values{"psychologist_id": {"7"}}),
+			want:      http.StatusOK,
+		},
+		{
+			name:      "other form",
+			principal: psychologist7,
+			req:       formRequest("/prices?psychologist=7", url.Values{"psychologist_id": {"8"}}),
+			want:      http.StatusForbidden,
+		},
+		{
+			name:      "repeated form field",
+			principal: psychologist7,
+			req:       formRequest("/prices", url.Values{"psychologist_id": {"7", "8"}}),
+			want:      http.StatusForbidden,
+		},
+		{
+			name:      "other multipart form",
+			principal: psychologist7,
+			req:       multipartRequest("/prices?psychologist=7", map[string][]string{"psychologist_id": {"8"}, "name": {"Work"}}),
+			want:      http.StatusForbidden,
+		},
+		{
+			name:      "own multipart form",
+			principal: psychologist7,
+			req:       multipartRequest("/prices", map[string][]string{"psychologist_id": {"7"}}),
+			want:      http.StatusOK,
+		},
+		{
+			name:      "own query",
+			principal: customer9,
+			req:       httptest.NewRequest(http.MethodGet, "/prices?customer=9", nil),
+			want:      http.StatusOK,
+		},
+		{
+			name:      "other query",
+			principal: customer9,
+			req:       httptest.NewRequest(http.MethodGet, "/prices?customer_id=10", nil),
+			want:      http.StatusForbidden,
+		},
+		{
+			name:      "own body and other query",
+			principal: psychologist7,
+			req:       jsonRequest(http.MethodPost, "/prices?psychologist=8", gin.MIMEJSON, `{"psychologist_id": 7}`),
+			want:      http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(tt.principal, tt.req.Method, "/prices", tt.req, either); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestOwnsLeavesTheBodyForTheHandler(t *testing.T) {
+	var bound struct {
+		PsychologistID int `json:"psychologist_id" form:"psychologist_id"`
+	}
+	bind := func(c *gin.Context) {
+		if err := c.ShouldBind(&bound); err != nil {
+			t.Errorf("binding after the policy: %v", err)
+		}
+	}
+	p := allow(owns(psychologistParty, nil))
+
+	for name, req := range map[string]*http.Request{
+		"JSON":      jsonRequest(http.MethodPost, "/imports", gin.MIMEJSON, `{"psychologist_id": 7}`),
+		"form":      formRequest("/imports", url.Values{"psychologist_id": {"7"}}),
+		"multipart": multipartRequest("/imports", map[string][]string{"psychologist_id": {"7"}}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			bound.PsychologistID = 0
+			if w := serve(psychologist7, http.MethodPost, "/imports", req, p, bind); w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if bound.PsychologistID != 7 {
+				t.Errorf("handler bound psychologist_id %d, want 7", bound.PsychologistID)
+			}
+		})
+	}
+}
+
+func TestPolicyScopesLists(t *testing.T) {
+	p := policy{
+		read:  signedIn,
+		scope: map[auth.Role]string{auth.RolePsychologist: "psychologist", auth.RoleCustomer: "customer"},
+	}.handler()
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		want      map[string]string
+	}{
+		{name: "psychologist", principal: psychologist7, want: map[string]string{"psychologist": "7"}},
+		{name: "customer", principal: customer9, want: map[string]string{"customer": "9"}},
+		{name: "administrator", principal: admin, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var scope map[string]string
+			capture := func(c *gin.Context) { scope = c.GetStringMapString(listScopeKey) }
+
+			req := httptest.NewRequest(http.MethodGet, "/appointments?psychologist=1&customer=2", nil)
+			if w := serve(tt.principal, http.MethodGet, "/appointments", req, p, capture); w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if len(scope) != len(tt.want) {
+				t.Fatalf("scope = %v, want %v", scope, tt.want)
+			}
+			for filter, value := range tt.want {
+				if scope[filter] != value {
+					t.Errorf("scope[%q] = %q, want %q", filter, scope[filter], value)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyAPIKeys(t *testing.T) {
+	group := policy{read: signedIn, write: adminsOnly, resource: "appointments"}.handler()
+	reader := &auth.Principal{Role: auth.RoleAPIKey, APIKeyID: 1, Scopes: []string{"appointments:read"}}
+	other := &auth.Principal{Role: auth.RoleAPIKey, APIKeyID: 2, Scopes: []string{"reviews:read"}}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		method    string
+		handlers  []gin.HandlerFunc
+		want      int
+	}{
+		{name: "scope granted", principal: reader, method: http.MethodGet, handlers: []gin.HandlerFunc{group}, want: http.StatusOK},
+		{name: "single route follows the group", principal: reader, method: http.MethodGet, handlers: []gin.HandlerFunc{group, allow(adminsOnly)}, want: http.StatusOK},
+		{name: "read scope does not write", principal: reader, method: http.MethodPost, handlers: []gin.HandlerFunc{group}, want: http.StatusForbidden},
+		{name: "other scope is anonymous", principal: other, method: http.MethodGet, handlers: []gin.HandlerFunc{group}, want: http.StatusForbidden},
+		{name: "other scope may do what anyone may", principal: other, method: http.MethodGet, handlers: []gin.HandlerFunc{allow(anyone)}, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/appointments", nil)
+			if w := serve(tt.principal, tt.method, "/appointments", req, tt.handlers...); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusActor(t *testing.T) {
+	apiKey := &auth.Principal{Role: auth.RoleAPIKey, APIKeyID: 1}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		requested string
+		want      string
+	}{
+		{name: "customer acts as themself", principal: customer9, requested: actorPsychologist, want: actorCustomer},
+		{name: "psychologist acts as themself", principal: psychologist7, requested: actorCustomer, want: actorPsychologist},
+		{name: "psychologist without a request", principal: psychologist7, want: actorPsychologist},
+		{name: "administrator chooses", principal: admin, requested: actorCustomer, want: actorCustomer},
+		{name: "API key chooses", principal: apiKey, requested: actorPsychologist, want: actorPsychologist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusActor(tt.principal, tt.requested); got != tt.want {
+				t.Errorf("statusActor = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,11 +1,17 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-pg/pg/v10"
 
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
 	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
 )
 
@@ -18,14 +24,16 @@ func CreatePsychologist(c *gin.Context) {
 	}
 
 	if err := psychologist.Create(c); err != nil {
-		c.Error(err)
+		handlePsychologistError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, psychologist)
+	c.JSON(http.StatusCreated, newPsychologistView(&psychologist, creatorAudience(c)))
 }
 
 // GetPsychologist handles retrieving a psychologist by ID.
+// The "include" query parameter embeds any of the psychologist's specializations, languages, modalities
+// and office_locations.
 func GetPsychologist(c *gin.Context) {
 	idStr := c.Param("id")
 
@@ -35,6 +43,11 @@ func GetPsychologist(c *gin.Context) {
 		return
 	}
 
+	includes, ok := parseIncludes(c, includeSpecializations, includeLanguages, includeModalities, includeOfficeLocations)
+	if !ok {
+		return
+	}
+
 	psychologist := &models.Psychologist{ID: id}
 
 	psychologist, err = psychologist.GetByID(c)
@@ -43,7 +56,30 @@ func GetPsychologist(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, psychologist)
+	aud := psychologistAudience(c, id)
+	if aud == audiencePublic && psychologist.OnboardingStatus != models.OnboardingVerified {
+		// Profiles that are not verified do not exist for the public.
+		c.Error(pg.ErrNoRows)
+		return
+	}
+
+	loaders := map[string]func(context.Context) error{
+		includeSpecializations: psychologist.LoadSpecializations,
+		includeLanguages:       psychologist.LoadLanguages,
+		includeModalities:      psychologist.LoadModalities,
+		includeOfficeLocations: psychologist.LoadOfficeLocations,
+	}
+	for name, load := range loaders {
+		if !includes[name] {
+			continue
+		}
+		if err := load(c); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, newPsychologistView(psychologist, aud))
 }
 
 // UpdatePsychologist handles updating a psychologist's data by ID.
@@ -70,14 +106,15 @@ func UpdatePsychologist(c *gin.Context) {
 	}
 
 	psychologist.CreatedAt = existingPsychologist.CreatedAt
+	psychologist.CreatedBy = existingPsychologist.CreatedBy
 	psychologist.ID = id
 
 	if err := psychologist.Update(c); err != nil {
-		c.Error(err)
+		handlePsychologistError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, psychologist)
+	c.JSON(http.StatusOK, newPsychologistView(&psychologist, psychologistAudience(c, id)))
 }
 
 // DeletePsychologist handles deleting a psychologist by ID.
@@ -101,13 +138,54 @@ func DeletePsychologist(c *gin.Context) {
 }
 
 // ListPsychologists handles retrieving a list of all psychologists.
+// The "q" query parameter runs a full-text search over names and bios; "lang" (uk or en) picks how snippets are highlighted.
+// Only verified profiles are listed, unless an administrator filters by onboarding status.
 func GetAllPsychologists(c *gin.Context) {
+	params, ok := parseListParams(c, time.UTC)
+	if !ok {
+		return
+	}
 
-	psychologists, err := models.GetAllPsychologists(c)
+	text := models.TextSearch{Query: strings.TrimSpace(c.Query("q")), Language: c.Query("lang")}
+
+	if !auth.PrincipalFrom(c).IsAdmin() {
+		if usesPrivateFields(params, "email", "status") {
+			respondForbidden(c)
+			return
+		}
+		// The public directory only lists verified profiles.
+		params.Filters["status"] = string(models.OnboardingVerified)
+	}
+
+	psychologists, total, err := models.ListPsychologists(c, params, text)
+	if errors.Is(err, models.ErrInvalidSearchLang) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		c.Error(err)
+		handleListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, psychologists)
+	views := make([]psychologistListItemView, 0, len(psychologists))
+	for i := range psychologists {
+		item := &psychologists[i]
+		views = append(views, psychologistListItemView{
+			psychologistView: newPsychologistView(&item.Psychologist, psychologistAudience(c, item.ID)),
+			SearchRank:       item.SearchRank,
+			Snippet:          item.Snippet,
+		})
+	}
+
+	respondWithPage(c, params, total, views)
+}
+
+// handlePsychologistError responds to validation errors and passes any other error to the error middleware.
+func handlePsychologistError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidTimeZone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.Error(err)
+	}
 }
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// SearchPsychologists handles searching the psychologist directory.
+// Query parameters: name, specialization (comma separated IDs), language, modality, city, currency, min_price, max_price,
+// weekday (0 = Sunday), date (2006-01-02), sort (relevance, price, -price or next_slot), page and limit.
+// Sorting by next slot ranks the most relevant matches only, so the total is capped.
+func SearchPsychologists(c *gin.Context) {
+	params, ok := parseListParams(c, time.UTC)
+	if !ok {
+		return
+	}
+
+	search, err := parsePsychologistSearch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, total, err := models.SearchPsychologists(c, search, params)
+	if err != nil {
+		handlePsychologistSearchError(c, err)
+		return
+	}
+
+	views := make([]psychologistSearchResultView, 0, len(results))
+	for i := range results {
+		result := &results[i]
+		views = append(views, psychologistSearchResultView{
+			psychologistView: newPsychologistView(&result.Psychologist, psychologistAudience(c, result.ID)),
+			Price:            result.Price,
+			Currency:         result.Currency,
+			Relevance:        result.Relevance,
+			NextAvailableAt:  result.NextAvailableAt,
+		})
+	}
+
+	respondWithPage(c, params, total, views)
+}
+
+// parsePsychologistSearch reads the search filters from the query string.
+func parsePsychologistSearch(c *gin.Context) (*models.PsychologistSearch, error) {
+	search := &models.PsychologistSearch{
+		Name:     c.Query("name"),
+		Language: c.Query("language"),
+		Modality: models.Modality(c.Query("modality")),
+		City:     c.Query("city"),
+		Currency: c.Query("currency"),
+		Sort:     c.Query("sort"),
+	}
+
+	if value := c.Query("specialization"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return nil, fmt.Errorf("specialization must be a comma separated list of IDs")
+			}
+			search.SpecializationIDs = append(search.SpecializationIDs, id)
+		}
+	}
+
+	var err error
+	if search.MinPrice, err = parsePriceParam(c, "min_price"); err != nil {
+		return nil, err
+	}
+	if search.MaxPrice, err = parsePriceParam(c, "max_price"); err != nil {
+		return nil, err
+	}
+
+	if value := c.Query("weekday"); value != "" {
+		day, err := strconv.Atoi(value)
+		if err != nil || day < 0 || day > 6 {
+			return nil, fmt.Errorf("weekday must be a number from 0 (Sunday) to 6 (Saturday)")
+		}
+		weekday := time.Weekday(day)
+		search.Weekday = &weekday
+	}
+
+	if value := c.Query("date"); value != "" {
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return nil, fmt.Errorf("date must be formatted as 2006-01-02")
+		}
+		search.Date = &date
+	}
+
+	return search, nil
+}
+
+// parsePriceParam reads an optional non-negative price from the query.
+func parsePriceParam(c *gin.Context, name string) (*float64, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || price < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative number", name)
+	}
+
+	return &price, nil
+}
+
+// handlePsychologistSearchError responds to inconsistent filters and passes any other error to the error middleware.
+func handlePsychologistSearchError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrCurrencyRequired), errors.Is(err, models.ErrInvalidPriceRange),
+		errors.Is(err, models.ErrInvalidSearchSort), errors.Is(err, models.ErrInvalidLanguageCode),
+		errors.Is(err, models.ErrInvalidModality):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.Error(err)
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"time"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// psychologistView is a psychologist as shown in the public directory. Contact details and record
+// timestamps are only added for the psychologist themself, and audit fields only for administrators.
+type psychologistView struct {
+	ID              int                  `json:"id"`
+	FirstName       string               `json:"first_name"`
+	LastName        string               `json:"last_name"`
+	ProfilePicture  string               `json:"profile_picture"`
+	Bio             string               `json:"bio"`
+	TimeZone        string               `json:"time_zone"`
+	Gender          string               `json:"gender,omitempty"`
+	AverageRating   *float64             `json:"average_rating"`
+	ReviewCount     int                  `json:"review_count"`
+	Specializations []specializationView `json:"specializations,omitempty"`
+	Languages       []string             `json:"languages,omitempty"`
+	Modalities      []models.Modality    `json:"modalities,omitempty"`
+	OfficeLocations []officeLocationView `json:"office_locations,omitempty"`
+
+	*psychologistPrivateView
+	*auditView
+}
+
+// psychologistPrivateView holds the fields of a psychologist only they and administrators see.
+type psychologistPrivateView struct {
+	Email            string                  `json:"email"`
+	OnboardingStatus models.OnboardingStatus `json:"onboarding_status"`
+	LicenseNumber    string                  `json:"license_number"`
+	LicenseIssuer    string                  `json:"license_issuer"`
+	SubmittedAt      *time.Time              `json:"submitted_at"`
+	VerifiedAt       *time.Time              `json:"verified_at"`
+	StatusReason     string                  `json:"status_reason,omitempty"`
+	CreatedAt        time.Time               `json:"created_at"`
+	UpdatedAt        time.Time               `json:"updated_at"`
+}
+
+// specializationView is a specialization embedded in a psychologist.
+type specializationView struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// officeLocationView is the address of an office embedded in a psychologist.
+type officeLocationView struct {
+	ID           int    `json:"id"`
+	Label        string `json:"label"`
+	AddressLine1 string `json:"address_line1"`
+	AddressLine2 string `json:"address_line2"`
+	City         string `json:"city"`
+	PostalCode   string `json:"postal_code"`
+	Country      string `json:"country"`
+}
+
+// psychologistListItemView is a psychologist in a list, with its rank and snippet when the list is a text search.
+type psychologistListItemView struct {
+	psychologistView
+	SearchRank float64 `json:"search_rank,omitempty"`
+	Snippet    string  `json:"snippet,omitempty"`
+}
+
+// psychologistSearchResultView is a psychologist matched by a directory search.
+type psychologistSearchResultView struct {
+	psychologistView
+	Price           *float64   `json:"price,omitempty"`
+	Currency        string     `json:"currency,omitempty"`
+	Relevance       float64    `json:"relevance"`
+	NextAvailableAt *time.Time `json:"next_available_at,omitempty"`
+}
+
+// newPsychologistView shapes the psychologist for the audience.
+func newPsychologistView(p *models.Psychologist, aud audience) psychologistView {
+	view := psychologistView{
+		ID:             p.ID,
+		FirstName:      p.FirstName,
+		LastName:       p.LastName,
+		ProfilePicture: p.ProfilePicture,
+		Bio:            p.Bio,
+		TimeZone:       p.TimeZone,
+		Gender:         p.Gender,
+		AverageRating:  p.AverageRating,
+		ReviewCount:    p.ReviewCount,
+		Languages:      p.Languages,
+		Modalities:     p.Modalities,
+	}
+
+	for _, s := range p.Specializations {
+		view.Specializations = append(view.Specializations, specializationView{ID: s.ID, Name: s.Name})
+	}
+	for _, o := range p.OfficeLocations {
+		view.OfficeLocations = append(view.OfficeLocations, officeLocationView{
+			ID:           o.ID,
+			Label:        o.Label,
+			AddressLine1: o.AddressLine1,
+			AddressLine2: o.AddressLine2,
+			City:         o.City,
+			PostalCode:   o.PostalCode,
+			Country:      o.Country,
+		})
+	}
+
+	if aud >= audienceSelf {
+		view.psychologistPrivateView = &psychologistPrivateView{
+			Email:            p.Email,
+			OnboardingStatus: p.OnboardingStatus,
+			LicenseNumber:    p.LicenseNumber,
+			LicenseIssuer:    p.LicenseIssuer,
+			SubmittedAt:      p.SubmittedAt,
+			VerifiedAt:       p.VerifiedAt,
+			StatusReason:     p.StatusReason,
+			CreatedAt:        p.CreatedAt,
+			UpdatedAt:        p.UpdatedAt,
+		}
+	}
+	if aud >= audienceAdmin {
+		view.auditView = &auditView{CreatedBy: p.CreatedBy, UpdatedBy: p.UpdatedBy}
+	}
+
+	return view
+}
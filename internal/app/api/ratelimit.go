@@ -0,0 +1,41 @@
+package api
+
+import (
+	"time"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/middleware"
+	"github.com/vitalicher97/psychologist_app/internal/app/ratelimit"
+)
+
+// Rate limits applied by SetupRoutes. They can be changed at startup, before the routes are set up.
+var (
+	// RateLimitStore keeps the buckets of every caller.
+	RateLimitStore ratelimit.Store = ratelimit.NewMemory()
+
+	// DefaultRateLimits apply to every request. The IP limit counts every request from an address,
+	// signed in or not; the others count each API key and signed in user on top of it.
+	DefaultRateLimits = middleware.RateLimits{
+		IP:     ratelimit.Limit{Requests: 300, Period: time.Minute},
+		APIKey: ratelimit.Limit{Requests: 600, Period: time.Minute},
+		User:   ratelimit.Limit{Requests: 600, Period: time.Minute},
+	}
+
+	// LoginRateLimits apply to signing up and signing in, on top of the default limits, to slow down
+	// password guessing.
+	LoginRateLimits = middleware.RateLimits{
+		IP:     ratelimit.Limit{Requests: 10, Period: time.Minute},
+		APIKey: ratelimit.Limit{Requests: 10, Period: time.Minute},
+		User:   ratelimit.Limit{Requests: 10, Period: time.Minute},
+	}
+
+	// LoginAccountRateLimit applies to signing in to each account, whatever address the attempts come
+	// from, so spreading password guesses over many addresses does not help.
+	LoginAccountRateLimit = ratelimit.Limit{Requests: 10, Period: 15 * time.Minute}
+
+	// BookingRateLimits apply to booking appointments and joining waitlists, on top of the default limits.
+	BookingRateLimits = middleware.RateLimits{
+		IP:     ratelimit.Limit{Requests: 10, Period: time.Hour},
+		APIKey: ratelimit.Limit{Requests: 300, Period: time.Hour},
+		User:   ratelimit.Limit{Requests: 20, Period: time.Hour},
+	}
+)
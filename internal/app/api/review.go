@@ -0,0 +1,175 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// reviewEditRequest is the JSON body used to change a review.
+type reviewEditRequest struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Text   string `json:"text" binding:"max=5000"`
+}
+
+// reviewReplyRequest is the JSON body of a psychologist's reply to a review.
+type reviewReplyRequest struct {
+	Reply string `json:"reply" binding:"required,max=5000"`
+}
+
+// reviewVisibilityRequest is the JSON body used to hide a review or show it again.
+type reviewVisibilityRequest struct {
+	Hidden *bool  `json:"hidden" binding:"required"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// CreateReview handles posting a review of a psychologist.
+func CreateReview(c *gin.Context) {
+	var review models.Review
+	if err := c.ShouldBindJSON(&review); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := review.Create(c); err != nil {
+		handleReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, review)
+}
+
+// GetReview handles retrieving a review by ID.
+func GetReview(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	review := &models.Review{ID: id}
+
+	review, err = review.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// UpdateReview handles changing the rating and text of a review while it can still be edited.
+func UpdateReview(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req reviewEditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	review := &models.Review{ID: id}
+	if err := review.Edit(c, req.Rating, req.Text); err != nil {
+		handleReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// ReplyToReview handles the psychologist's reply to a review.
+func ReplyToReview(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req reviewReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	review := &models.Review{ID: id}
+	if err := review.AddReply(c, req.Reply); err != nil {
+		handleReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// SetReviewVisibility handles hiding a review, or showing it again, by ID.
+func SetReviewVisibility(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req reviewVisibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	review := &models.Review{ID: id}
+	if err := review.SetHidden(c, *req.Hidden, req.Reason); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// GetAllReviews handles retrieving a list of reviews.
+// Hidden reviews are only listed when the "hidden" filter is given.
+func GetAllReviews(c *gin.Context) {
+	params, ok := parseListParams(c, time.UTC)
+	if !ok {
+		return
+	}
+
+	reviews, total, err := models.ListReviews(c, params)
+	if err != nil {
+		handleListError(c, err)
+		return
+	}
+
+	if len(reviews) == 0 {
+		reviews = []models.Review{}
+	}
+
+	respondWithPage(c, params, total, reviews)
+}
+
+// handleReviewError responds to rejected review changes and passes any other error to the error middleware.
+func handleReviewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrNoCompletedAppointment):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrAlreadyReviewed), errors.Is(err, models.ErrAlreadyReplied),
+		errors.Is(err, models.ErrReviewEditWindowClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.Error(err)
+	}
+}
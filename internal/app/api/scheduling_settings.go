@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// GetSchedulingSettings handles retrieving the scheduling settings of a psychologist.
+// Psychologists that never saved their settings get the defaults.
+func GetSchedulingSettings(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	psychologist := &models.Psychologist{ID: id}
+	if _, err := psychologist.GetByID(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	settings, err := models.GetSchedulingSettings(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSchedulingSettings handles replacing the scheduling settings of a psychologist.
+func UpdateSchedulingSettings(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	psychologist := &models.Psychologist{ID: id}
+	if _, err := psychologist.GetByID(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var settings models.SchedulingSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.Error(err)
+		return
+	}
+
+	settings.PsychologistID = id
+
+	if err := settings.Save(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
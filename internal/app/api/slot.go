@@ -0,0 +1,162 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+	"github.com/vitalicher97/psychologist_app/internal/app/scheduling"
+)
+
+const (
+	defaultSlotWindow = 7 * 24 * time.Hour
+	maxSlotWindow     = 62 * 24 * time.Hour
+)
+
+// GetPsychologistSlots handles retrieving the bookable slots of a psychologist. Only verified psychologists have slots.
+// Session length, slot step, buffers and the booking window come from the psychologist's scheduling settings;
+// the "duration" and "step" query parameters override the first two.
+func GetPsychologistSlots(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	psychologist := &models.Psychologist{ID: id}
+	if _, err := psychologist.GetByID(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	psychologistLoc, err := psychologist.Location()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	loc, err := viewerLocation(c, psychologistLoc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	from, to, err := parseTimeWindow(c, now, defaultSlotWindow, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if to.Sub(from) > maxSlotWindow {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the requested window is too large"})
+		return
+	}
+	if from.Before(now) {
+		from = now
+	}
+
+	settings, err := models.GetSchedulingSettings(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	opts := settings.SlotOptions(now, 0)
+
+	opts.Duration, err = parseMinutesParam(c, "duration", opts.Duration)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts.Step, err = parseMinutesParam(c, "step", opts.Step)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Psychologists who are not verified cannot be booked, so they have no slots to offer.
+	var slots []scheduling.Interval
+	if psychologist.OnboardingStatus == models.OnboardingVerified {
+		schedule, err := models.LoadSchedule(c, id, from, to)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		slots = schedule.Slots(from, to, opts)
+	}
+	for i := range slots {
+		slots[i] = scheduling.Interval{Start: slots[i].Start.In(loc), End: slots[i].End.In(loc)}
+	}
+	if len(slots) == 0 {
+		slots = []scheduling.Interval{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"psychologist_id": id,
+		"time_zone":       loc.String(),
+		"from":            from.In(loc),
+		"to":              to.In(loc),
+		"duration":        int(opts.Duration / time.Minute),
+		"step":            int(opts.Step / time.Minute),
+		"slots":           slots,
+	})
+}
+
+// parseTimeWindow reads the "from" and "to" query parameters, falling back to a window of the given length starting now.
+// Dates without a time are taken as midnight in loc.
+func parseTimeWindow(c *gin.Context, now time.Time, length time.Duration, loc *time.Location) (time.Time, time.Time, error) {
+	from := now
+	if value := c.Query("from"); value != "" {
+		parsed, err := parseTimeParam(value, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+		}
+		from = parsed
+	}
+
+	to := from.Add(length)
+	if value := c.Query("to"); value != "" {
+		parsed, err := parseTimeParam(value, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+		}
+		to = parsed
+	}
+
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to must be after from")
+	}
+
+	return from, to, nil
+}
+
+// parseTimeParam parses either a date ("2006-01-02"), taken as midnight in loc, or an RFC 3339 timestamp.
+func parseTimeParam(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// parseMinutesParam reads a positive number of minutes from the query, returning def when it is absent.
+func parseMinutesParam(c *gin.Context, name string, def time.Duration) (time.Duration, error) {
+	value := c.Query(name)
+	if value == "" {
+		return def, nil
+	}
+
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes <= 0 {
+		return 0, fmt.Errorf("%s must be a positive number of minutes", name)
+	}
+
+	return time.Duration(minutes) * time.Minute, nil
+}
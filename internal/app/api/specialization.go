@@ -0,0 +1,219 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// CreateSpecialization handles the creation of a new specialization.
+func CreateSpecialization(c *gin.Context) {
+	var specialization models.Specialization
+	if err := c.ShouldBindJSON(&specialization); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := specialization.Create(c); err != nil {
+		handleSpecializationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, specialization)
+}
+
+// GetSpecialization handles retrieving a specialization by ID.
+func GetSpecialization(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	specialization := &models.Specialization{ID: id}
+
+	specialization, err = specialization.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, specialization)
+}
+
+// UpdateSpecialization handles renaming a specialization by ID.
+func UpdateSpecialization(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	existingSpecialization := &models.Specialization{ID: id}
+	existingSpecialization, err = existingSpecialization.GetByID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var specialization models.Specialization
+	if err := c.ShouldBindJSON(&specialization); err != nil {
+		c.Error(err)
+		return
+	}
+
+	specialization.CreatedAt = existingSpecialization.CreatedAt
+	specialization.CreatedBy = existingSpecialization.CreatedBy
+	specialization.ID = id
+
+	if err := specialization.Update(c); err != nil {
+		handleSpecializationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, specialization)
+}
+
+// DeleteSpecialization handles deleting a specialization by ID.
+func DeleteSpecialization(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	specialization := &models.Specialization{ID: id}
+
+	if err := specialization.DeleteByID(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetAllSpecializations handles retrieving a list of all specializations.
+func GetAllSpecializations(c *gin.Context) {
+	specializations, err := models.GetAllSpecializations(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, specializations)
+}
+
+// GetPsychologistSpecializations handles retrieving the specializations of a psychologist.
+func GetPsychologistSpecializations(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	psychologist := &models.Psychologist{ID: id}
+	if _, err := psychologist.GetByID(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondWithSpecializations(c, id)
+}
+
+// AttachPsychologistSpecialization handles adding a specialization to a psychologist.
+// It responds with the psychologist's specializations.
+func AttachPsychologistSpecialization(c *gin.Context) {
+	psychologistID, specializationID, ok := psychologistSpecializationParams(c)
+	if !ok {
+		return
+	}
+
+	if err := models.AttachSpecialization(c, psychologistID, specializationID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondWithSpecializations(c, psychologistID)
+}
+
+// DetachPsychologistSpecialization handles removing a specialization from a psychologist.
+func DetachPsychologistSpecialization(c *gin.Context) {
+	psychologistID, specializationID, ok := psychologistSpecializationParams(c)
+	if !ok {
+		return
+	}
+
+	if err := models.DetachSpecialization(c, psychologistID, specializationID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// psychologistSpecializationParams reads the psychologist and specialization IDs from the path
+// and ensures both exist.
+func psychologistSpecializationParams(c *gin.Context) (int, int, bool) {
+	psychologistID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return 0, 0, false
+	}
+
+	specializationID, err := strconv.Atoi(c.Param("specialization_id"))
+	if err != nil {
+		c.Error(err)
+		return 0, 0, false
+	}
+
+	psychologist := &models.Psychologist{ID: psychologistID}
+	if _, err := psychologist.GetByID(c); err != nil {
+		c.Error(err)
+		return 0, 0, false
+	}
+
+	specialization := &models.Specialization{ID: specializationID}
+	if _, err := specialization.GetByID(c); err != nil {
+		c.Error(err)
+		return 0, 0, false
+	}
+
+	return psychologistID, specializationID, true
+}
+
+// respondWithSpecializations writes the specializations of a psychologist.
+func respondWithSpecializations(c *gin.Context, psychologistID int) {
+	specializations, err := models.GetSpecializationsByPsychologist(c, psychologistID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(specializations) == 0 {
+		specializations = []models.Specialization{}
+	}
+
+	c.JSON(http.StatusOK, specializations)
+}
+
+// handleSpecializationError responds to duplicate names and passes any other error to the error middleware.
+func handleSpecializationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrDuplicateSpecialization):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.Error(err)
+	}
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// viewerLocation returns the time zone requested with the "tz" query parameter, or def when it is absent.
+// "Local" is not accepted, because it names the server's zone rather than the viewer's.
+func viewerLocation(c *gin.Context, def *time.Location) (*time.Location, error) {
+	tz := c.Query("tz")
+	if tz == "" {
+		return def, nil
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", tz)
+	}
+
+	return loc, nil
+}
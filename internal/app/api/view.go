@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// audience is who a response is shaped for. Each audience sees everything the previous one sees.
+type audience int
+
+// Audiences of psychologist and customer responses.
+const (
+	// audiencePublic is anyone browsing the directory.
+	audiencePublic audience = iota
+	// audienceSelf is the psychologist or customer the record describes.
+	audienceSelf
+	// audienceAdmin is an administrator.
+	audienceAdmin
+)
+
+// auditView holds who created and last changed a record. Only administrators see it.
+type auditView struct {
+	CreatedBy int `json:"created_by"`
+	UpdatedBy int `json:"updated_by"`
+}
+
+// psychologistAudience returns the audience the caller belongs to for the psychologist's record.
+func psychologistAudience(c *gin.Context, psychologistID int) audience {
+	principal := auth.PrincipalFrom(c)
+	switch {
+	case principal.IsAdmin():
+		return audienceAdmin
+	case principal.IsPsychologist(psychologistID):
+		return audienceSelf
+	default:
+		return audiencePublic
+	}
+}
+
+// customerAudience returns the audience the caller belongs to for the customer's record.
+func customerAudience(c *gin.Context, customerID int) audience {
+	principal := auth.PrincipalFrom(c)
+	switch {
+	case principal.IsAdmin():
+		return audienceAdmin
+	case principal.IsCustomer(customerID):
+		return audienceSelf
+	default:
+		return audiencePublic
+	}
+}
+
+// creatorAudience returns the audience for the response to creating a record: the caller sees what
+// they submitted, and administrators see everything.
+func creatorAudience(c *gin.Context) audience {
+	if auth.PrincipalFrom(c).IsAdmin() {
+		return audienceAdmin
+	}
+
+	return audienceSelf
+}
+
+// respondForbidden responds with 403 to a caller that may not see or change a resource.
+func respondForbidden(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to access this resource"})
+}
+
+// usesPrivateFields reports whether the list parameters filter or sort by any of the private fields.
+// Callers that cannot see a field must not be able to probe its values through a list either.
+func usesPrivateFields(params *models.ListParams, fields ...string) bool {
+	for _, field := range fields {
+		if _, ok := params.Filters[field]; ok {
+			return true
+		}
+		for _, sort := range params.Sort {
+			if sort.Field == field {
+				return true
+			}
+		}
+	}
+
+	return false
+}
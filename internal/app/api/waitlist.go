@@ -0,0 +1,223 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// waitlistRequest is the JSON body used to join a psychologist's waitlist.
+type waitlistRequest struct {
+	PsychologistID   int                     `json:"psychologist_id" binding:"required"`
+	CustomerID       int                     `json:"customer_id" binding:"required"`
+	PreferredWindows []models.WaitlistWindow `json:"preferred_windows"`
+	Note             string                  `json:"note"`
+}
+
+// CreateWaitlistEntry handles adding a customer to a psychologist's waitlist.
+func CreateWaitlistEntry(c *gin.Context) {
+	var req waitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	entry := &models.WaitlistEntry{
+		PsychologistID:   req.PsychologistID,
+		CustomerID:       req.CustomerID,
+		PreferredWindows: req.PreferredWindows,
+		Note:             req.Note,
+	}
+
+	if err := entry.Create(c); err != nil {
+		handleWaitlistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// GetWaitlistEntry handles retrieving a waitlist entry by ID.
+func GetWaitlistEntry(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	entry := &models.WaitlistEntry{ID: id}
+	if _, err := entry.GetByID(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetAllWaitlistEntries handles retrieving the waitlist of a psychologist or the entries of a customer.
+func GetAllWaitlistEntries(c *gin.Context) {
+	psychologistID, customerID, ok := waitlistOwner(c)
+	if !ok {
+		return
+	}
+
+	entries, err := models.GetWaitlistEntries(c, psychologistID, customerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(entries) == 0 {
+		entries = []models.WaitlistEntry{}
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// WithdrawWaitlistEntry handles taking an entry off the waitlist.
+func WithdrawWaitlistEntry(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	entry := &models.WaitlistEntry{ID: id}
+	if err := entry.Withdraw(c); err != nil {
+		handleWaitlistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetAllWaitlistOffers handles retrieving the offers made to a psychologist's or a customer's waitlist entries.
+func GetAllWaitlistOffers(c *gin.Context) {
+	psychologistID, customerID, ok := waitlistOwner(c)
+	if !ok {
+		return
+	}
+
+	offers, err := models.GetWaitlistOffers(c, psychologistID, customerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if len(offers) == 0 {
+		offers = []models.WaitlistOffer{}
+	}
+
+	c.JSON(http.StatusOK, offers)
+}
+
+// GetWaitlistStats handles retrieving how the offers from a psychologist's waitlist converted.
+func GetWaitlistStats(c *gin.Context) {
+	psychologistID, err := strconv.Atoi(c.Query("psychologist"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "psychologist is required"})
+		return
+	}
+
+	stats, err := models.GetWaitlistStats(c, psychologistID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// acceptOfferRequest is the JSON body choosing how the session booked from a waitlist offer is held.
+type acceptOfferRequest struct {
+	Modality         models.Modality `json:"modality" binding:"required"`
+	OfficeLocationID int             `json:"office_location_id"`
+}
+
+// AcceptWaitlistOffer handles booking the slot of a waitlist offer.
+func AcceptWaitlistOffer(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	loc, err := viewerLocation(c, time.UTC)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req acceptOfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	offer := &models.WaitlistOffer{ID: id}
+	appointment, err := offer.Accept(c, req.Modality, req.OfficeLocationID)
+	if err != nil {
+		handleWaitlistError(c, err)
+		return
+	}
+
+	appointment.In(loc)
+	c.JSON(http.StatusCreated, appointment)
+}
+
+// DeclineWaitlistOffer handles turning down a waitlist offer.
+func DeclineWaitlistOffer(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	offer := &models.WaitlistOffer{ID: id}
+	if err := offer.Decline(c); err != nil {
+		handleWaitlistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, offer)
+}
+
+// waitlistOwner reads the "psychologist" or "customer" query parameter that scopes waitlist listings.
+func waitlistOwner(c *gin.Context) (int, int, bool) {
+	psychologistID, _ := strconv.Atoi(c.Query("psychologist"))
+	customerID, _ := strconv.Atoi(c.Query("customer"))
+	if psychologistID == 0 && customerID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "either psychologist or customer is required"})
+		return 0, 0, false
+	}
+
+	return psychologistID, customerID, true
+}
+
+// handleWaitlistError responds to waitlist errors and hands booking errors to handleAppointmentError.
+func handleWaitlistError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidWaitlistWindow):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrAlreadyOnWaitlist), errors.Is(err, models.ErrEntryNotActive),
+		errors.Is(err, models.ErrOfferNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrOfferExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		handleAppointmentError(c, err)
+	}
+}
@@ -0,0 +1,103 @@
+// Package auth identifies who is calling the API and what they are allowed to see.
+package auth
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey is the gin context key the principal of a request is stored under.
+const principalKey = "auth.principal"
+
+// Role is the kind of account a caller signed in with.
+type Role string
+
+// Roles. Callers that have not signed in have no role.
+const (
+	RoleAdmin        Role = "admin"
+	RolePsychologist Role = "psychologist"
+	RoleCustomer     Role = "customer"
+	// RoleAPIKey is the role of integrations calling with an API key instead of a signed in account.
+	RoleAPIKey Role = "api_key"
+)
+
+// Principal is the caller of a request. PsychologistID and CustomerID link the account to the
+// psychologist or customer record it acts as. Callers using an API key have no account; they are
+// identified by the key and limited to its scopes.
+type Principal struct {
+	UserID         int
+	Role           Role
+	PsychologistID int
+	CustomerID     int
+	APIKeyID       int
+	Scopes         []string
+}
+
+// anonymous is the principal of callers that have not signed in.
+var anonymous = &Principal{}
+
+// SetPrincipal stores the caller of the request in its context.
+func SetPrincipal(c *gin.Context, principal *Principal) {
+	c.Set(principalKey, principal)
+}
+
+// PrincipalFrom returns the caller of the request, or an anonymous principal when nobody signed in.
+func PrincipalFrom(c *gin.Context) *Principal {
+	if value, ok := c.Get(principalKey); ok {
+		if principal, ok := value.(*Principal); ok && principal != nil {
+			return principal
+		}
+	}
+
+	return anonymous
+}
+
+// FromContext returns the caller a context carries. Request handlers pass their gin context down to the
+// models, so database hooks can tell who made a change; other contexts, such as background jobs, are anonymous.
+func FromContext(ctx context.Context) *Principal {
+	if principal, ok := ctx.Value(principalKey).(*Principal); ok && principal != nil {
+		return principal
+	}
+
+	return anonymous
+}
+
+// IsAnonymous reports whether the caller has not signed in.
+func (p *Principal) IsAnonymous() bool {
+	return p.Role == ""
+}
+
+// IsAdmin reports whether the caller is an administrator.
+func (p *Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+// IsPsychologist reports whether the caller acts as the given psychologist.
+func (p *Principal) IsPsychologist(psychologistID int) bool {
+	return p.Role == RolePsychologist && psychologistID != 0 && p.PsychologistID == psychologistID
+}
+
+// IsCustomer reports whether the caller acts as the given customer.
+func (p *Principal) IsCustomer(customerID int) bool {
+	return p.Role == RoleCustomer && customerID != 0 && p.CustomerID == customerID
+}
+
+// IsAPIKey reports whether the caller uses an API key.
+func (p *Principal) IsAPIKey() bool {
+	return p.Role == RoleAPIKey
+}
+
+// HasScope reports whether the caller's API key grants the scope, such as "appointments:read".
+func (p *Principal) HasScope(scope string) bool {
+	if !p.IsAPIKey() {
+		return false
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// tokenIssuer identifies the access tokens this API signs.
+const tokenIssuer = "psychologist_app"
+
+// AccessTokenTTL is how long an access token is valid after it is issued.
+var AccessTokenTTL = 15 * time.Minute
+
+// ErrInvalidAccessToken is returned when an access token is malformed, forged or expired.
+var ErrInvalidAccessToken = errors.New("invalid or expired access token")
+
+// signingKey is the secret access tokens are signed with.
+var signingKey []byte
+
+// SetSigningKey sets the secret access tokens are signed and verified with.
+func SetSigningKey(key []byte) {
+	signingKey = key
+}
+
+// accessClaims are the claims of an access token. The subject is the user ID.
+type accessClaims struct {
+	Role           Role `json:"role"`
+	PsychologistID int  `json:"psychologist_id,omitempty"`
+	CustomerID     int  `json:"customer_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// IssueAccessToken signs a short-lived access token for the principal and returns it with its expiry time.
+func IssueAccessToken(principal *Principal) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(AccessTokenTTL)
+
+	claims := accessClaims{
+		Role:           principal.Role,
+		PsychologistID: principal.PsychologistID,
+		CustomerID:     principal.CustomerID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   strconv.Itoa(principal.UserID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(signingKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// ParseAccessToken verifies the signature and expiry of an access token and returns the principal it was issued for.
+func ParseAccessToken(token string) (*Principal, error) {
+	var claims accessClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return signingKey, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil || userID <= 0 {
+		return nil, ErrInvalidAccessToken
+	}
+
+	switch claims.Role {
+	case RoleAdmin, RolePsychologist, RoleCustomer:
+	default:
+		return nil, ErrInvalidAccessToken
+	}
+
+	return &Principal{
+		UserID:         userID,
+		Role:           claims.Role,
+		PsychologistID: claims.PsychologistID,
+		CustomerID:     claims.CustomerID,
+	}, nil
+}
@@ -0,0 +1,288 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// APIKeyPrefix starts every API key, so keys can be told apart from access tokens and found by secret scanners.
+const APIKeyPrefix = "psk_"
+
+// apiKeyUsageResolution is how often the last use of a key is written, so busy keys do not write on every request.
+const apiKeyUsageResolution = time.Minute
+
+// APIKeyResources are the resources API key scopes grant access to, as "<resource>:read" or "<resource>:write".
+var APIKeyResources = []string{
+	"appointments",
+	"availabilities",
+	"calendar_imports",
+	"consultation_pricing",
+	"customer_prices",
+	"customers",
+	"office_locations",
+	"psychologists",
+	"reviews",
+	"specializations",
+	"waitlist",
+}
+
+// Errors returned by API key operations.
+var (
+	ErrInvalidAPIKey      = errors.New("invalid, expired or revoked API key")
+	ErrAPIKeyIPNotAllowed = errors.New("the API key cannot be used from this address")
+	ErrInvalidAPIKeyScope = errors.New("scopes must be a resource followed by :read or :write")
+	ErrInvalidAllowedIP   = errors.New("allowed_ips must be IP addresses or CIDR ranges")
+	ErrAPIKeyExpiry       = errors.New("expires_at must be in the future")
+)
+
+// APIKey represents the api_keys table in the database.
+// It lets an integration call the API without an account. Only a hash of the key is stored; the prefix
+// is kept in the clear so administrators can tell keys apart.
+type APIKey struct {
+	ID         int        `json:"id" pg:",pk"`
+	Name       string     `json:"name" binding:"required" pg:",notnull"`
+	Prefix     string     `json:"prefix" pg:",unique,notnull"`
+	KeyHash    string     `json:"-" pg:",unique,notnull"`
+	Scopes     []string   `json:"scopes" binding:"required,min=1" pg:",array,notnull"`
+	AllowedIPs []string   `json:"allowed_ips" pg:",array"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedBy  int        `json:"created_by" pg:",notnull"`
+	UpdatedBy  int        `json:"updated_by" pg:",notnull"`
+	CreatedAt  time.Time  `json:"created_at" pg:",default:now()"`
+	UpdatedAt  time.Time  `json:"updated_at" pg:",default:now()"`
+
+	// Key is the plain key, only known right after the key is created.
+	Key string `json:"key,omitempty" pg:"-"`
+}
+
+// BeforeInsert is a method for performing additional changes to the api_keys table when INSERT query executes. It adds time in created_at and updated_at columns.
+func (k *APIKey) BeforeInsert(ctx context.Context) (context.Context, error) {
+	k.CreatedAt = time.Now()
+	k.UpdatedAt = k.CreatedAt
+	k.CreatedBy = actingUserID(ctx)
+	k.UpdatedBy = k.CreatedBy
+
+	return ctx, nil
+}
+
+// BeforeUpdate is a method for performing additional changes to the api_keys table when UPDATE query executes. It updates time in updated_at column.
+func (k *APIKey) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	k.UpdatedAt = time.Now()
+	k.UpdatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// validate checks the scopes, the allowed addresses and the expiry of the key.
+func (k *APIKey) validate() error {
+	for _, scope := range k.Scopes {
+		resource, access, ok := strings.Cut(scope, ":")
+		if !ok || (access != "read" && access != "write") || !containsString(APIKeyResources, resource) {
+			return ErrInvalidAPIKeyScope
+		}
+	}
+
+	for _, entry := range k.AllowedIPs {
+		if net.ParseIP(entry) == nil {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return ErrInvalidAllowedIP
+			}
+		}
+	}
+
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		return ErrAPIKeyExpiry
+	}
+
+	return nil
+}
+
+// containsString reports whether the list holds the value.
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Create generates a new key and stores its hash. The plain key is set in Key and cannot be recovered later.
+func (k *APIKey) Create(ctx context.Context) error {
+	if err := k.validate(); err != nil {
+		return err
+	}
+
+	prefix := make([]byte, 4)
+	if _, err := rand.Read(prefix); err != nil {
+		return err
+	}
+	secret, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	k.Prefix = APIKeyPrefix + hex.EncodeToString(prefix)
+	k.Key = k.Prefix + "_" + secret
+	k.KeyHash = hashToken(k.Key)
+	k.LastUsedAt = nil
+	k.LastUsedIP = ""
+	k.RevokedAt = nil
+
+	conn := db.GetConnection()
+	_, err = conn.WithContext(ctx).Model(k).Returning("*").Insert()
+
+	return err
+}
+
+// GetByID retrieves an API key by its ID.
+func (k *APIKey) GetByID(ctx context.Context) (*APIKey, error) {
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(k).WherePK().Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// Update changes the name, scopes, allowed addresses and expiry of the key. The key itself never changes.
+func (k *APIKey) Update(ctx context.Context) error {
+	if err := k.validate(); err != nil {
+		return err
+	}
+
+	conn := db.GetConnection()
+	res, err := conn.WithContext(ctx).Model(k).
+		Column("name", "scopes", "allowed_ips", "expires_at", "updated_at", "updated_by").
+		WherePK().
+		Returning("*").
+		Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+
+	return nil
+}
+
+// Revoke stops the key from working from the next request on. Revoking a revoked key changes nothing.
+func (k *APIKey) Revoke(ctx context.Context) error {
+	if _, err := k.GetByID(ctx); err != nil {
+		return err
+	}
+	if k.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	k.RevokedAt = &now
+
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(k).Column("revoked_at", "updated_at", "updated_by").WherePK().Update()
+
+	return err
+}
+
+// apiKeyListSpec lists the fields API key lists can be sorted and filtered by.
+var apiKeyListSpec = listSpec{
+	sortable: map[string]string{
+		"id":           "id",
+		"name":         "name",
+		"expires_at":   "expires_at",
+		"last_used_at": "last_used_at",
+		"created_at":   "created_at",
+	},
+	defaultSort: []SortField{{Field: "id"}},
+	filters: map[string]filterFunc{
+		"name":   filterString("name"),
+		"prefix": filterString("prefix"),
+	},
+}
+
+// ListAPIKeys retrieves a page of API keys and the number of keys matching the filters.
+func ListAPIKeys(ctx context.Context, params *ListParams) ([]APIKey, int, error) {
+	var keys []APIKey
+	total, err := selectPage(newListQuery(ctx, &keys), params, apiKeyListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return keys, total, nil
+}
+
+// AuthenticateAPIKey finds the key and checks that it can be used from the IP address, recording the use.
+// It returns ErrInvalidAPIKey for unknown, expired and revoked keys.
+func AuthenticateAPIKey(ctx context.Context, key, ip string) (*APIKey, error) {
+	var k APIKey
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(&k).Where("key_hash = ?", hashToken(key)).Select()
+	if errors.Is(err, pg.ErrNoRows) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if k.RevokedAt != nil || (k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+	if !k.allows(ip) {
+		return nil, ErrAPIKeyIPNotAllowed
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyUsageResolution || k.LastUsedIP != ip {
+		k.LastUsedAt = &now
+		k.LastUsedIP = ip
+		if _, err := conn.WithContext(ctx).Model(&k).Column("last_used_at", "last_used_ip").WherePK().Update(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &k, nil
+}
+
+// allows reports whether the key can be used from the IP address. Keys without an allowlist can be used from anywhere.
+func (k *APIKey) allows(ip string) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, entry := range k.AllowedIPs {
+		if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(addr) {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Principal returns the principal requests made with the key run as.
+func (k *APIKey) Principal() *auth.Principal {
+	return &auth.Principal{Role: auth.RoleAPIKey, APIKeyID: k.ID, Scopes: k.Scopes}
+}
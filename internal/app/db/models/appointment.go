@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"github.com/go-pg/pg/v10"
+
 	"github.com/vitalicher97/psychologist_app/internal/app/db"
 )
 
@@ -12,19 +14,26 @@ type Appointment struct {
 	ID             int       `json:"id" binding:"-" pg:",pk"`
 	PsychologistID int       `json:"psychologist_id" binding:"required" pg:",notnull"`
 	CustomerID     int       `json:"customer_id" binding:"required" pg:",notnull"`
+	SeriesID       int       `json:"series_id,omitempty" binding:"-"`
 	StartTime      time.Time `json:"start_time" binding:"required" pg:",notnull"`
 	EndTime        time.Time `json:"end_time" binding:"required" pg:",notnull"`
-	Status         string    `json:"status" binding:"-" pg:",notnull"`
-	CreatedBy      int       `json:"created_by" binding:"-" pg:",notnull"`
-	UpdatedBy      int       `json:"updated_by" binding:"-" pg:",notnull"`
-	CreatedAt      time.Time `json:"created_at" binding:"-" pg:",default:now()"`
-	UpdatedAt      time.Time `json:"updated_at" binding:"-" pg:",default:now()"`
+	// Modality is how the session is held; in-person sessions also name the office they take place at.
+	Modality         Modality          `json:"modality" binding:"required" pg:",notnull"`
+	OfficeLocationID int               `json:"office_location_id,omitempty" binding:"-"`
+	Status           AppointmentStatus `json:"status" binding:"-" pg:",notnull"`
+	Sequence         int               `json:"sequence" binding:"-" pg:",notnull,use_zero"`
+	CreatedBy        int               `json:"created_by" binding:"-" pg:",notnull"`
+	UpdatedBy        int               `json:"updated_by" binding:"-" pg:",notnull"`
+	CreatedAt        time.Time         `json:"created_at" binding:"-" pg:",default:now()"`
+	UpdatedAt        time.Time         `json:"updated_at" binding:"-" pg:",default:now()"`
 }
 
 // BeforeInsert is a method for performing additional changes to the appointment table when INSERT query executes. It adds time in created_at and updated_at columns.
 func (a *Appointment) BeforeInsert(ctx context.Context) (context.Context, error) {
 	a.CreatedAt = time.Now()
 	a.UpdatedAt = a.CreatedAt
+	a.CreatedBy = actingUserID(ctx)
+	a.UpdatedBy = a.CreatedBy
 
 	return ctx, nil
 }
@@ -32,14 +41,28 @@ func (a *Appointment) BeforeInsert(ctx context.Context) (context.Context, error)
 // BeforeUpdate is a method for performing additional changes to the appointment table when UPDATE query executes. It updates time in updated_at column.
 func (a *Appointment) BeforeUpdate(ctx context.Context) (context.Context, error) {
 	a.UpdatedAt = time.Now()
+	a.UpdatedBy = actingUserID(ctx)
 
 	return ctx, nil
 }
 
-// Create inserts a new appointment into the database.
+// Create inserts a new appointment in the requested state, rejecting it when it overlaps another booking.
 func (a *Appointment) Create(ctx context.Context) error {
-	conn := db.GetConnection()
-	_, err := conn.WithContext(ctx).Model(a).Insert()
+	a.Status = StatusRequested
+
+	return a.saveInTransaction(ctx, func(tx *pg.Tx) error {
+		return a.insert(ctx, tx)
+	})
+}
+
+// insert writes the appointment and the first entry of its status history.
+func (a *Appointment) insert(ctx context.Context, tx *pg.Tx) error {
+	if _, err := tx.ModelContext(ctx, a).Insert(); err != nil {
+		return err
+	}
+
+	change := &AppointmentStatusChange{AppointmentID: a.ID, ToStatus: a.Status}
+	_, err := tx.ModelContext(ctx, change).Insert()
 
 	return err
 }
@@ -55,42 +78,92 @@ func (a *Appointment) GetByID(ctx context.Context) (*Appointment, error) {
 	return a, nil
 }
 
-// GetAllAppointments retrieves a list of all appointments.
-func GetAllAppointments(ctx context.Context) ([]Appointment, error) {
-	conn := db.GetConnection()
+// appointmentListSpec lists the fields appointment lists can be sorted and filtered by.
+// The from and to filters select appointments starting within the window.
+var appointmentListSpec = listSpec{
+	sortable: map[string]string{
+		"id":         "id",
+		"start_time": "start_time",
+		"end_time":   "end_time",
+		"status":     "status",
+		"created_at": "created_at",
+	},
+	defaultSort: []SortField{{Field: "start_time"}},
+	filters: map[string]filterFunc{
+		"psychologist": filterInt("psychologist_id"),
+		"customer":     filterInt("customer_id"),
+		"series":       filterInt("series_id"),
+		"status":       filterOneOf("status"),
+		"modality":     filterOneOf("modality"),
+		"office":       filterInt("office_location_id"),
+		"from":         filterTimeFrom("start_time"),
+		"to":           filterTimeTo("start_time"),
+	},
+}
+
+// ListAppointments retrieves a page of appointments and the number of appointments matching the filters.
+func ListAppointments(ctx context.Context, params *ListParams) ([]Appointment, int, error) {
 	var appointments []Appointment
-	err := conn.WithContext(ctx).Model(&appointments).Select()
+	total, err := selectPage(newListQuery(ctx, &appointments), params, appointmentListSpec)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return appointments, nil
+	return appointments, total, nil
 }
 
-// Update modifies an existing appointment's data.
+// Update modifies an existing appointment's data, rejecting the change when it overlaps another booking.
+// The status is left untouched; it only changes through Transition. Every update bumps the sequence
+// number calendar clients use to pick up changes.
 func (a *Appointment) Update(ctx context.Context) error {
-	conn := db.GetConnection()
-	_, err := conn.WithContext(ctx).Model(a).WherePK().Update()
-
-	return err
+	return a.saveInTransaction(ctx, func(tx *pg.Tx) error {
+		_, err := tx.ModelContext(ctx, a).
+			ExcludeColumn("status").
+			Value("sequence", "sequence + 1").
+			WherePK().
+			Returning("sequence").
+			Update()
+
+		return err
+	})
 }
 
 // DeleteByID removes an appointment from the database by its ID.
+// When the appointment still held its time, the freed slot is offered to the waitlist.
 func (a *Appointment) DeleteByID(ctx context.Context) error {
 	conn := db.GetConnection()
-	_, err := conn.WithContext(ctx).Model(a).WherePK().Delete()
+	_, err := conn.WithContext(ctx).Model(a).WherePK().Returning("*").Delete()
+	if err != nil {
+		return err
+	}
 
-	return err
+	if a.PsychologistID != 0 && !a.Status.IsCancelled() {
+		offerFreedSlot(ctx, a)
+	}
+
+	return nil
 }
 
-// GetAppointmentsByPsychologistID retrieves a list of all appointments for a given psychologist.
-func GetAppointmentsByPsychologistID(ctx context.Context, psychologistID int) ([]Appointment, error) {
+// GetAppointmentsByPsychologistIDInRange retrieves the appointments of a psychologist that overlap the [from, to) window.
+func GetAppointmentsByPsychologistIDInRange(ctx context.Context, psychologistID int, from, to time.Time) ([]Appointment, error) {
 	conn := db.GetConnection()
 	var appointments []Appointment
-	err := conn.WithContext(ctx).Model(&appointments).Where("psychologist_id = ?", psychologistID).Select()
+	err := conn.WithContext(ctx).Model(&appointments).
+		Where("psychologist_id = ?", psychologistID).
+		Where("start_time < ? AND end_time > ?", to, from).
+		Order("start_time").
+		Select()
 	if err != nil {
 		return nil, err
 	}
 
 	return appointments, nil
 }
+
+// In converts the appointment times to the given time zone for rendering.
+func (a *Appointment) In(loc *time.Location) {
+	a.StartTime = a.StartTime.In(loc)
+	a.EndTime = a.EndTime.In(loc)
+	a.CreatedAt = a.CreatedAt.In(loc)
+	a.UpdatedAt = a.UpdatedAt.In(loc)
+}
@@ -0,0 +1,252 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+	"github.com/vitalicher97/psychologist_app/internal/app/scheduling"
+)
+
+// Advisory lock namespaces used to serialize bookings of the same psychologist or customer.
+const (
+	psychologistBookingLock = 1
+	customerBookingLock     = 2
+)
+
+// exclusionViolation is the PostgreSQL error code raised when an exclusion constraint is violated.
+const exclusionViolation = "23P01"
+
+// Errors returned when an appointment cannot be booked at the requested time.
+var (
+	ErrInvalidTimeRange        = errors.New("end_time must be after start_time")
+	ErrOutsideAvailability     = errors.New("the requested time is outside the psychologist's availability")
+	ErrPsychologistBusy        = errors.New("the psychologist is busy at the requested time")
+	ErrPsychologistNotVerified = errors.New("the psychologist is not verified and cannot be booked yet")
+	ErrSessionLength           = errors.New("the appointment must last as long as the psychologist's sessions")
+	ErrOffSlotGrid             = errors.New("the appointment must start at one of the psychologist's slots")
+)
+
+// ConflictError is returned when an appointment overlaps other appointments of the same psychologist or customer.
+type ConflictError struct {
+	AppointmentIDs []int
+}
+
+// Error implements the error interface.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("appointment overlaps %d existing appointment(s)", len(e.AppointmentIDs))
+}
+
+// validate checks the appointment fields that do not require the database.
+func (a *Appointment) validate() error {
+	if !a.EndTime.After(a.StartTime) {
+		return ErrInvalidTimeRange
+	}
+
+	return nil
+}
+
+// lockBooking takes transaction-scoped locks on the psychologist and the customer of the appointment,
+// so concurrent bookings touching either of them are checked one after another.
+// The locks are always taken in the same order to avoid deadlocks.
+func (a *Appointment) lockBooking(ctx context.Context, tx *pg.Tx) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?, ?)", psychologistBookingLock, a.PsychologistID); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?, ?)", customerBookingLock, a.CustomerID)
+
+	return err
+}
+
+// findConflicts returns the IDs of appointments that overlap the appointment for its psychologist or customer.
+// Appointments of the same psychologist also conflict when they are closer than the gap the buffers require.
+func (a *Appointment) findConflicts(ctx context.Context, conn orm.DB, gap time.Duration) ([]int, error) {
+	var ids []int
+	err := conn.ModelContext(ctx, (*Appointment)(nil)).
+		Column("id").
+		Where("id != ?", a.ID).
+		Where("status NOT IN (?)", pg.In(cancelledStatuses)).
+		WhereGroup(func(q *pg.Query) (*pg.Query, error) {
+			return q.
+				WhereGroup(func(q *pg.Query) (*pg.Query, error) {
+					return q.Where("psychologist_id = ?", a.PsychologistID).
+						Where("start_time < ? AND end_time > ?", a.EndTime.Add(gap), a.StartTime.Add(-gap)), nil
+				}).
+				WhereOrGroup(func(q *pg.Query) (*pg.Query, error) {
+					return q.Where("customer_id = ?", a.CustomerID).
+						Where("start_time < ? AND end_time > ?", a.EndTime, a.StartTime), nil
+				}), nil
+		}).
+		Order("id").
+		Select(&ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// checkAvailability ensures the appointment falls within the psychologist's opening hours, taking availability
+// exceptions into account. When it is new or moved, it must also be a whole slot of a verified psychologist:
+// as long as a session, starting on the slot step and within the booking window. It also ensures
+// the appointment does not overlap any other booking, slot held for another customer's waitlist offer or imported
+// busy time, including the buffers from the psychologist's scheduling settings.
+// It must run inside the transaction that writes the appointment, after lockBooking.
+func (a *Appointment) checkAvailability(ctx context.Context, tx *pg.Tx, stored *Appointment) error {
+	settings, err := GetSchedulingSettings(ctx, a.PsychologistID)
+	if err != nil {
+		return err
+	}
+
+	moved := stored == nil || !stored.StartTime.Equal(a.StartTime) || !stored.EndTime.Equal(a.EndTime) ||
+		stored.PsychologistID != a.PsychologistID
+	if moved {
+		if err := checkVerified(ctx, tx, a.PsychologistID); err != nil {
+			return err
+		}
+
+		earliest, latest := settings.BookingWindow(time.Now())
+		if a.StartTime.Before(earliest) || a.StartTime.After(latest) {
+			return ErrOutsideBookingWindow
+		}
+	}
+
+	schedule, err := LoadSchedule(ctx, a.PsychologistID, a.StartTime, a.EndTime)
+	if err != nil {
+		return err
+	}
+
+	session := scheduling.Interval{Start: a.StartTime, End: a.EndTime}
+	if !schedule.Covers(session) {
+		return ErrOutsideAvailability
+	}
+
+	if moved {
+		if a.EndTime.Sub(a.StartTime) != settings.SessionLength() {
+			return ErrSessionLength
+		}
+		if !schedule.OnGrid(session, settings.SlotStep()) {
+			return ErrOffSlotGrid
+		}
+	}
+
+	padded := schedule.Padded(session)
+	blocks, err := GetBusyBlocksByPsychologistInRange(ctx, a.PsychologistID, padded.Start, padded.End)
+	if err != nil {
+		return err
+	}
+	if len(blocks) > 0 {
+		return ErrPsychologistBusy
+	}
+
+	gap := settings.BufferBefore() + settings.BufferAfter()
+	held, err := GetHeldSlotsByPsychologistInRange(ctx, a.PsychologistID, a.StartTime.Add(-gap), a.EndTime.Add(gap))
+	if err != nil {
+		return err
+	}
+	for _, offer := range held {
+		if offer.CustomerID != a.CustomerID {
+			return ErrSlotHeld
+		}
+	}
+
+	ids, err := a.findConflicts(ctx, tx, gap)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) > 0 {
+		return &ConflictError{AppointmentIDs: ids}
+	}
+
+	return nil
+}
+
+// checkVerified ensures the psychologist finished onboarding. Appointments made before a psychologist lost
+// their verification are kept; only new bookings are refused.
+func checkVerified(ctx context.Context, conn orm.DB, psychologistID int) error {
+	psychologist := &Psychologist{ID: psychologistID}
+	if err := conn.ModelContext(ctx, psychologist).Column("onboarding_status").WherePK().Select(); err != nil {
+		return err
+	}
+
+	if psychologist.OnboardingStatus != OnboardingVerified {
+		return ErrPsychologistNotVerified
+	}
+
+	return nil
+}
+
+// stored returns the appointment as it is currently saved, or nil when it is new.
+func (a *Appointment) stored(ctx context.Context, tx *pg.Tx) (*Appointment, error) {
+	if a.ID == 0 {
+		return nil, nil
+	}
+
+	stored := &Appointment{ID: a.ID}
+	if err := tx.ModelContext(ctx, stored).WherePK().Select(); err != nil {
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+// checkSessionFormat ensures the modality and office of the appointment are valid for the psychologist.
+// Appointments that keep their stored modality and office are accepted even when the psychologist
+// has stopped offering it since.
+func (a *Appointment) checkSessionFormat(ctx context.Context, tx *pg.Tx, stored *Appointment) error {
+	if stored != nil && stored.Modality == a.Modality && stored.OfficeLocationID == a.OfficeLocationID &&
+		stored.PsychologistID == a.PsychologistID {
+		return nil
+	}
+
+	return checkSessionFormat(ctx, tx, a.PsychologistID, a.Modality, a.OfficeLocationID)
+}
+
+// saveInTransaction validates the appointment and runs the write in a transaction guarded against double booking.
+func (a *Appointment) saveInTransaction(ctx context.Context, write func(tx *pg.Tx) error) error {
+	if err := a.validate(); err != nil {
+		return err
+	}
+
+	conn := db.GetConnection()
+	err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := a.lockBooking(ctx, tx); err != nil {
+			return err
+		}
+
+		stored, err := a.stored(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		if err := a.checkSessionFormat(ctx, tx, stored); err != nil {
+			return err
+		}
+
+		if err := a.checkAvailability(ctx, tx, stored); err != nil {
+			return err
+		}
+
+		return write(tx)
+	})
+
+	var pgErr pg.Error
+	if errors.As(err, &pgErr) && pgErr.Field('C') == exclusionViolation {
+		// Another writer bypassed the advisory locks and the database caught the overlap.
+		ids, findErr := a.findConflicts(ctx, conn, 0)
+		if findErr != nil {
+			return findErr
+		}
+
+		return &ConflictError{AppointmentIDs: ids}
+	}
+
+	return err
+}
@@ -0,0 +1,401 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+	"github.com/vitalicher97/psychologist_app/internal/app/scheduling"
+)
+
+// SeriesScope selects which occurrences of a series an update or cancellation applies to.
+type SeriesScope string
+
+// Series scopes.
+const (
+	ScopeThis      SeriesScope = "this"
+	ScopeFollowing SeriesScope = "following"
+	ScopeAll       SeriesScope = "all"
+)
+
+// Errors returned when a series request is not consistent.
+var (
+	ErrInvalidSeriesScope = errors.New("scope must be one of this, following or all")
+	ErrNotInSeries        = errors.New("the appointment does not belong to the series")
+)
+
+// AppointmentSeries represents the appointment_series table in the database.
+// StartTime and EndTime describe the first occurrence; RRule describes how it repeats.
+type AppointmentSeries struct {
+	tableName struct{} `pg:"appointment_series"`
+
+	ID             int       `json:"id" binding:"-" pg:",pk"`
+	PsychologistID int       `json:"psychologist_id" binding:"required" pg:",notnull"`
+	CustomerID     int       `json:"customer_id" binding:"required" pg:",notnull"`
+	StartTime      time.Time `json:"start_time" binding:"required" pg:",notnull"`
+	EndTime        time.Time `json:"end_time" binding:"required" pg:",notnull"`
+	RRule          string    `json:"rrule" binding:"required" pg:"rrule,notnull"`
+	// Modality and OfficeLocationID are copied to every occurrence.
+	Modality         Modality  `json:"modality" binding:"required" pg:",notnull"`
+	OfficeLocationID int       `json:"office_location_id,omitempty" binding:"-"`
+	CreatedBy        int       `json:"created_by" binding:"-" pg:",notnull"`
+	UpdatedBy        int       `json:"updated_by" binding:"-" pg:",notnull"`
+	CreatedAt        time.Time `json:"created_at" binding:"-" pg:",default:now()"`
+	UpdatedAt        time.Time `json:"updated_at" binding:"-" pg:",default:now()"`
+}
+
+// OccurrenceResult reports what happened to a single occurrence of a series.
+type OccurrenceResult struct {
+	StartTime                 time.Time         `json:"start_time"`
+	EndTime                   time.Time         `json:"end_time"`
+	AppointmentID             int               `json:"appointment_id,omitempty"`
+	Status                    AppointmentStatus `json:"status,omitempty"`
+	Error                     string            `json:"error,omitempty"`
+	ConflictingAppointmentIDs []int             `json:"conflicting_appointment_ids,omitempty"`
+}
+
+// Succeeded reports whether the occurrence was written.
+func (r OccurrenceResult) Succeeded() bool {
+	return r.Error == ""
+}
+
+// BeforeInsert is a method for performing additional changes to the appointment_series table when INSERT query executes. It adds time in created_at and updated_at columns.
+func (s *AppointmentSeries) BeforeInsert(ctx context.Context) (context.Context, error) {
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+	s.CreatedBy = actingUserID(ctx)
+	s.UpdatedBy = s.CreatedBy
+
+	return ctx, nil
+}
+
+// BeforeUpdate is a method for performing additional changes to the appointment_series table when UPDATE query executes. It updates time in updated_at column.
+func (s *AppointmentSeries) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	s.UpdatedAt = time.Now()
+	s.UpdatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// Recurrence parses the series recurrence rule.
+func (s *AppointmentSeries) Recurrence() (scheduling.Recurrence, error) {
+	return scheduling.ParseRecurrence(s.RRule)
+}
+
+// Create inserts the series and books every occurrence it produces. Occurrences that cannot be booked
+// are reported in the results instead of failing the whole series. When no occurrence can be booked
+// the series is removed again.
+func (s *AppointmentSeries) Create(ctx context.Context) ([]OccurrenceResult, error) {
+	recurrence, err := s.Recurrence()
+	if err != nil {
+		return nil, err
+	}
+	if !s.EndTime.After(s.StartTime) {
+		return nil, ErrInvalidTimeRange
+	}
+	s.RRule = recurrence.String()
+
+	loc, err := psychologistLocation(ctx, s.PsychologistID)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := db.GetConnection()
+	if err := checkVerified(ctx, conn.WithContext(ctx), s.PsychologistID); err != nil {
+		return nil, err
+	}
+	if err := checkSessionFormat(ctx, conn.WithContext(ctx), s.PsychologistID, s.Modality, s.OfficeLocationID); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.WithContext(ctx).Model(s).Insert(); err != nil {
+		return nil, err
+	}
+
+	duration := s.EndTime.Sub(s.StartTime)
+	results := make([]OccurrenceResult, 0)
+	booked := 0
+	for _, start := range recurrence.Occurrences(s.StartTime, loc) {
+		appointment := &Appointment{
+			PsychologistID:   s.PsychologistID,
+			CustomerID:       s.CustomerID,
+			SeriesID:         s.ID,
+			StartTime:        start,
+			EndTime:          start.Add(duration),
+			Modality:         s.Modality,
+			OfficeLocationID: s.OfficeLocationID,
+		}
+
+		result, err := occurrenceResult(appointment, appointment.Create(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if result.Succeeded() {
+			booked++
+		}
+		results = append(results, result)
+	}
+
+	if booked == 0 {
+		if _, err := conn.WithContext(ctx).Model(s).WherePK().Delete(); err != nil {
+			return nil, err
+		}
+		s.ID = 0
+	}
+
+	return results, nil
+}
+
+// GetByID retrieves a series by its ID.
+func (s *AppointmentSeries) GetByID(ctx context.Context) (*AppointmentSeries, error) {
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(s).WherePK().Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Appointments retrieves the occurrences of the series in chronological order.
+func (s *AppointmentSeries) Appointments(ctx context.Context) ([]Appointment, error) {
+	conn := db.GetConnection()
+	var appointments []Appointment
+	err := conn.WithContext(ctx).Model(&appointments).Where("series_id = ?", s.ID).Order("start_time").Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return appointments, nil
+}
+
+// Reschedule moves the occurrences selected by scope to the new time of the reference occurrence.
+// The other occurrences keep their date shifted by the same number of days and take the new wall-clock time
+// and duration. Editing "following" splits the series at the reference occurrence.
+func (s *AppointmentSeries) Reschedule(ctx context.Context, scope SeriesScope, referenceID int, startTime, endTime time.Time) ([]OccurrenceResult, error) {
+	if !endTime.After(startTime) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	targets, reference, err := s.targets(ctx, scope, referenceID)
+	if err != nil {
+		return nil, err
+	}
+	// Every occurrence is past or cancelled, so there is nothing to move.
+	if reference == nil {
+		return []OccurrenceResult{}, nil
+	}
+
+	loc, err := psychologistLocation(ctx, s.PsychologistID)
+	if err != nil {
+		return nil, err
+	}
+
+	if scope == ScopeFollowing {
+		if err := s.split(ctx, reference, targets); err != nil {
+			return nil, err
+		}
+	}
+
+	newStart := startTime.In(loc)
+	dayShift := daysBetween(reference.StartTime.In(loc), newStart)
+	duration := endTime.Sub(startTime)
+
+	results := make([]OccurrenceResult, 0, len(targets))
+	for i := range targets {
+		appointment := &targets[i]
+		day := appointment.StartTime.In(loc).AddDate(0, 0, dayShift)
+		appointment.StartTime = time.Date(day.Year(), day.Month(), day.Day(), newStart.Hour(), newStart.Minute(), newStart.Second(), 0, loc)
+		appointment.EndTime = appointment.StartTime.Add(duration)
+
+		result, err := occurrenceResult(appointment, appointment.Update(ctx))
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	if scope == ScopeAll {
+		first := s.StartTime.In(loc).AddDate(0, 0, dayShift)
+		s.StartTime = time.Date(first.Year(), first.Month(), first.Day(), newStart.Hour(), newStart.Minute(), newStart.Second(), 0, loc)
+		s.EndTime = s.StartTime.Add(duration)
+
+		conn := db.GetConnection()
+		if _, err := conn.WithContext(ctx).Model(s).Column("start_time", "end_time", "updated_at", "updated_by").WherePK().Update(); err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// Cancel cancels the occurrences selected by scope and reports the outcome of each one.
+func (s *AppointmentSeries) Cancel(ctx context.Context, scope SeriesScope, referenceID int, status AppointmentStatus, actor, reason string) ([]OccurrenceResult, error) {
+	targets, _, err := s.targets(ctx, scope, referenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]OccurrenceResult, 0, len(targets))
+	for i := range targets {
+		appointment := &targets[i]
+		result, err := occurrenceResult(appointment, appointment.Transition(ctx, status, actor, reason))
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// targets returns the upcoming occurrences selected by scope together with the reference occurrence.
+// Occurrences that are already cancelled or finished are left alone. For the "all" scope the reference
+// defaults to the first upcoming occurrence.
+func (s *AppointmentSeries) targets(ctx context.Context, scope SeriesScope, referenceID int) ([]Appointment, *Appointment, error) {
+	if scope != ScopeThis && scope != ScopeFollowing && scope != ScopeAll {
+		return nil, nil, ErrInvalidSeriesScope
+	}
+
+	appointments, err := s.Appointments(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	var open []Appointment
+	for _, appointment := range appointments {
+		if appointment.StartTime.After(now) && appointment.Status.CanTransitionTo(StatusCancelledByCustomer) {
+			open = append(open, appointment)
+		}
+	}
+
+	var reference *Appointment
+	for i := range open {
+		if open[i].ID == referenceID {
+			reference = &open[i]
+		}
+	}
+	if reference == nil {
+		if scope != ScopeAll || referenceID != 0 {
+			return nil, nil, ErrNotInSeries
+		}
+		if len(open) == 0 {
+			return []Appointment{}, nil, nil
+		}
+		reference = &open[0]
+	}
+	ref := *reference
+
+	switch scope {
+	case ScopeThis:
+		return []Appointment{ref}, &ref, nil
+	case ScopeFollowing:
+		var following []Appointment
+		for _, appointment := range open {
+			if !appointment.StartTime.Before(ref.StartTime) {
+				following = append(following, appointment)
+			}
+		}
+		return following, &ref, nil
+	default:
+		return open, &ref, nil
+	}
+}
+
+// split ends the series just before the reference occurrence and moves the following occurrences to a new series.
+func (s *AppointmentSeries) split(ctx context.Context, reference *Appointment, following []Appointment) error {
+	recurrence, err := s.Recurrence()
+	if err != nil {
+		return err
+	}
+
+	if !reference.StartTime.After(s.StartTime) {
+		// Splitting at the first occurrence keeps the whole series.
+		return nil
+	}
+
+	tail := &AppointmentSeries{
+		PsychologistID:   s.PsychologistID,
+		CustomerID:       s.CustomerID,
+		StartTime:        reference.StartTime,
+		EndTime:          reference.EndTime,
+		RRule:            scheduling.Recurrence{Interval: recurrence.Interval, Count: len(following)}.String(),
+		Modality:         s.Modality,
+		OfficeLocationID: s.OfficeLocationID,
+	}
+
+	conn := db.GetConnection()
+
+	return conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if _, err := tx.ModelContext(ctx, tail).Insert(); err != nil {
+			return err
+		}
+
+		ids := make([]int, 0, len(following))
+		for i := range following {
+			ids = append(ids, following[i].ID)
+			following[i].SeriesID = tail.ID
+		}
+		if len(ids) > 0 {
+			if _, err := tx.ModelContext(ctx, (*Appointment)(nil)).Set("series_id = ?", tail.ID).Where("id IN (?)", pg.In(ids)).Update(); err != nil {
+				return err
+			}
+		}
+
+		s.RRule = scheduling.Recurrence{Interval: recurrence.Interval, Until: reference.StartTime.Add(-time.Second)}.String()
+		_, err := tx.ModelContext(ctx, s).Column("rrule", "updated_at", "updated_by").WherePK().Update()
+
+		return err
+	})
+}
+
+// occurrenceResult turns the outcome of writing an occurrence into a result. Booking errors are reported
+// in the result; any other error is returned so the caller can abort.
+func occurrenceResult(appointment *Appointment, err error) (OccurrenceResult, error) {
+	result := OccurrenceResult{
+		StartTime:     appointment.StartTime,
+		EndTime:       appointment.EndTime,
+		AppointmentID: appointment.ID,
+		Status:        appointment.Status,
+	}
+	if err == nil {
+		return result, nil
+	}
+
+	var conflict *ConflictError
+	var transition *TransitionError
+	switch {
+	case errors.As(err, &conflict):
+		result.ConflictingAppointmentIDs = conflict.AppointmentIDs
+	case errors.As(err, &transition), errors.Is(err, ErrOutsideAvailability),
+		errors.Is(err, ErrOutsideBookingWindow), errors.Is(err, ErrPsychologistBusy), errors.Is(err, ErrSlotHeld),
+		errors.Is(err, ErrPsychologistNotVerified), errors.Is(err, ErrSessionLength), errors.Is(err, ErrOffSlotGrid):
+	default:
+		return OccurrenceResult{}, err
+	}
+	result.Error = err.Error()
+
+	return result, nil
+}
+
+// psychologistLocation returns the time zone of the psychologist.
+func psychologistLocation(ctx context.Context, psychologistID int) (*time.Location, error) {
+	psychologist := &Psychologist{ID: psychologistID}
+	if _, err := psychologist.GetByID(ctx); err != nil {
+		return nil, err
+	}
+
+	return psychologist.Location()
+}
+
+// daysBetween returns the number of calendar days from the date of a to the date of b.
+func daysBetween(a, b time.Time) int {
+	dateA := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	dateB := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(dateB.Sub(dateA).Hours() / 24)
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// AppointmentStatus is a state in the appointment lifecycle.
+type AppointmentStatus string
+
+// Appointment lifecycle states.
+const (
+	StatusRequested               AppointmentStatus = "requested"
+	StatusConfirmed               AppointmentStatus = "confirmed"
+	StatusCancelledByCustomer     AppointmentStatus = "cancelled_by_customer"
+	StatusCancelledByPsychologist AppointmentStatus = "cancelled_by_psychologist"
+	StatusCompleted               AppointmentStatus = "completed"
+	StatusNoShow                  AppointmentStatus = "no_show"
+)
+
+// appointmentTransitions lists the states each state may move to. Final states have no entry.
+var appointmentTransitions = map[AppointmentStatus][]AppointmentStatus{
+	StatusRequested: {StatusConfirmed, StatusCancelledByCustomer, StatusCancelledByPsychologist},
+	StatusConfirmed: {StatusCompleted, StatusNoShow, StatusCancelledByCustomer, StatusCancelledByPsychologist},
+}
+
+// cancelledStatuses lists the states in which an appointment no longer occupies its time.
+var cancelledStatuses = []AppointmentStatus{StatusCancelledByCustomer, StatusCancelledByPsychologist}
+
+// outcomeStatuses lists the states that record how a session went, which can only be reached once it has ended.
+var outcomeStatuses = []AppointmentStatus{StatusCompleted, StatusNoShow}
+
+// AllowedTransitions returns the states the appointment may move to from s.
+func (s AppointmentStatus) AllowedTransitions() []AppointmentStatus {
+	allowed := appointmentTransitions[s]
+	if allowed == nil {
+		return []AppointmentStatus{}
+	}
+
+	return allowed
+}
+
+// CanTransitionTo reports whether the appointment may move from s to the given state.
+func (s AppointmentStatus) CanTransitionTo(to AppointmentStatus) bool {
+	for _, allowed := range appointmentTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsCancelled reports whether s is one of the cancelled states.
+func (s AppointmentStatus) IsCancelled() bool {
+	for _, cancelled := range cancelledStatuses {
+		if s == cancelled {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isOutcome reports whether s records how a session went.
+func (s AppointmentStatus) isOutcome() bool {
+	for _, outcome := range outcomeStatuses {
+		if s == outcome {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowedTransitionsAt returns the states the appointment may move to at now. Until the session has
+// ended it cannot be marked completed or a no-show.
+func (a *Appointment) allowedTransitionsAt(now time.Time) []AppointmentStatus {
+	allowed := a.Status.AllowedTransitions()
+	if !now.Before(a.EndTime) {
+		return allowed
+	}
+
+	upcoming := []AppointmentStatus{}
+	for _, status := range allowed {
+		if !status.isOutcome() {
+			upcoming = append(upcoming, status)
+		}
+	}
+
+	return upcoming
+}
+
+// checkTransition returns a TransitionError when the appointment cannot move to the given state at now.
+func (a *Appointment) checkTransition(to AppointmentStatus, now time.Time) error {
+	allowed := a.allowedTransitionsAt(now)
+	for _, status := range allowed {
+		if status == to {
+			return nil
+		}
+	}
+
+	return &TransitionError{From: a.Status, To: to, Allowed: allowed}
+}
+
+// TransitionError is returned when an appointment cannot move to the requested state.
+type TransitionError struct {
+	From    AppointmentStatus
+	To      AppointmentStatus
+	Allowed []AppointmentStatus
+}
+
+// Error implements the error interface.
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("appointment cannot move from %q to %q", e.From, e.To)
+}
+
+// AppointmentStatusChange represents the appointment_status_history table in the database.
+type AppointmentStatusChange struct {
+	tableName struct{} `pg:"appointment_status_history"`
+
+	ID            int               `json:"id" pg:",pk"`
+	AppointmentID int               `json:"appointment_id" pg:",notnull"`
+	FromStatus    AppointmentStatus `json:"from_status"`
+	ToStatus      AppointmentStatus `json:"to_status" pg:",notnull"`
+	Actor         string            `json:"actor"`
+	Reason        string            `json:"reason"`
+	ChangedBy     int               `json:"changed_by"`
+	CreatedAt     time.Time         `json:"created_at" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the appointment_status_history table when INSERT query executes. It adds time in created_at column.
+func (s *AppointmentStatusChange) BeforeInsert(ctx context.Context) (context.Context, error) {
+	s.CreatedAt = time.Now()
+	s.ChangedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// Transition moves the appointment to the given state and records the change in its history.
+// Sessions can only be marked completed or a no-show once they have ended.
+// Cancelling an appointment offers its slot to the waitlist.
+func (a *Appointment) Transition(ctx context.Context, to AppointmentStatus, actor, reason string) error {
+	conn := db.GetConnection()
+
+	err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := tx.ModelContext(ctx, a).WherePK().For("UPDATE").Select(); err != nil {
+			return err
+		}
+
+		from := a.Status
+		if err := a.checkTransition(to, time.Now()); err != nil {
+			return err
+		}
+
+		a.Status = to
+		_, err := tx.ModelContext(ctx, a).
+			Column("status", "sequence", "updated_at", "updated_by").
+			Value("sequence", "sequence + 1").
+			WherePK().
+			Returning("sequence").
+			Update()
+		if err != nil {
+			return err
+		}
+
+		change := &AppointmentStatusChange{
+			AppointmentID: a.ID,
+			FromStatus:    from,
+			ToStatus:      to,
+			Actor:         actor,
+			Reason:        reason,
+		}
+		_, err = tx.ModelContext(ctx, change).Insert()
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if to.IsCancelled() {
+		offerFreedSlot(ctx, a)
+	}
+
+	return nil
+}
+
+// GetAppointmentStatusHistory retrieves the status changes of an appointment in the order they happened.
+func GetAppointmentStatusHistory(ctx context.Context, appointmentID int) ([]AppointmentStatusChange, error) {
+	conn := db.GetConnection()
+	var history []AppointmentStatusChange
+	err := conn.WithContext(ctx).Model(&history).Where("appointment_id = ?", appointmentID).Order("created_at", "id").Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
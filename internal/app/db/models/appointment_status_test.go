@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAppointmentCheckTransition(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	appointment := &Appointment{Status: StatusConfirmed, StartTime: start, EndTime: start.Add(time.Hour)}
+
+	tests := []struct {
+		name    string
+		to      AppointmentStatus
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "complete before the session", to: StatusCompleted, now: start.Add(-24 * time.Hour), wantErr: true},
+		{name: "complete during the session", to: StatusCompleted, now: start.Add(30 * time.Minute), wantErr: true},
+		{name: "complete once it ends", to: StatusCompleted, now: start.Add(time.Hour)},
+		{name: "no-show before the end", to: StatusNoShow, now: start.Add(59 * time.Minute), wantErr: true},
+		{name: "no-show after the session", to: StatusNoShow, now: start.Add(2 * time.Hour)},
+		{name: "cancel before the session", to: StatusCancelledByCustomer, now: start.Add(-time.Hour)},
+		{name: "confirm again", to: StatusConfirmed, now: start.Add(2 * time.Hour), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := appointment.checkTransition(tt.to, tt.now)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("checkTransition: %v", err)
+				}
+				return
+			}
+
+			var transition *TransitionError
+			if !errors.As(err, &transition) {
+				t.Fatalf("checkTransition error = %v, want a TransitionError", err)
+			}
+			for _, allowed := range transition.Allowed {
+				if allowed == tt.to {
+					t.Errorf("the error lists %q as allowed", tt.to)
+				}
+			}
+		})
+	}
+}
+
+func TestAppointmentAllowedTransitionsAt(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	appointment := &Appointment{Status: StatusConfirmed, StartTime: start, EndTime: start.Add(time.Hour)}
+
+	before := []AppointmentStatus{StatusCancelledByCustomer, StatusCancelledByPsychologist}
+	if got := appointment.allowedTransitionsAt(start); !reflect.DeepEqual(got, before) {
+		t.Errorf("before the end: %v, want %v", got, before)
+	}
+
+	if got := appointment.allowedTransitionsAt(start.Add(time.Hour)); !reflect.DeepEqual(got, StatusConfirmed.AllowedTransitions()) {
+		t.Errorf("after the end: %v, want %v", got, StatusConfirmed.AllowedTransitions())
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/vitalicher97/psychologist_app/internal/app/db"
+	"github.com/vitalicher97/psychologist_app/internal/app/scheduling"
 )
 
 // Availability represents the availability table in the database.
@@ -83,6 +84,8 @@ func (t *TimeOnly) parseTime(strTime string) error {
 func (a *Availability) BeforeInsert(ctx context.Context) (context.Context, error) {
 	a.CreatedAt = time.Now()
 	a.UpdatedAt = a.CreatedAt
+	a.CreatedBy = actingUserID(ctx)
+	a.UpdatedBy = a.CreatedBy
 
 	return ctx, nil
 }
@@ -90,20 +93,35 @@ func (a *Availability) BeforeInsert(ctx context.Context) (context.Context, error
 // BeforeUpdate is a method for performing additional changes to the availability table when UPDATE query executes. It updates the time in updated_at column.
 func (a *Availability) BeforeUpdate(ctx context.Context) (context.Context, error) {
 	a.UpdatedAt = time.Now()
+	a.UpdatedBy = actingUserID(ctx)
 
 	return ctx, nil
 }
 
-// List retrieves all availability records.
-func GetAllAvailabilities(ctx context.Context) ([]Availability, error) {
-	conn := db.GetConnection()
+// availabilityListSpec lists the fields availability lists can be sorted and filtered by.
+var availabilityListSpec = listSpec{
+	sortable: map[string]string{
+		"id":          "id",
+		"day_of_week": "day_of_week",
+		"start_time":  "start_time",
+		"end_time":    "end_time",
+	},
+	defaultSort: []SortField{{Field: "day_of_week"}, {Field: "start_time"}},
+	filters: map[string]filterFunc{
+		"psychologist": filterInt("psychologist_id"),
+		"day_of_week":  filterInt("day_of_week"),
+	},
+}
+
+// ListAvailabilities retrieves a page of availability records and the number of records matching the filters.
+func ListAvailabilities(ctx context.Context, params *ListParams) ([]Availability, int, error) {
 	var availabilities []Availability
-	err := conn.WithContext(ctx).Model(&availabilities).Order("id").Select()
+	total, err := selectPage(newListQuery(ctx, &availabilities), params, availabilityListSpec)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return availabilities, nil
+	return availabilities, total, nil
 }
 
 // GetByID retrieves an availability record by its ID.
@@ -141,14 +159,16 @@ func (a *Availability) DeleteByID(ctx context.Context) error {
 	return err
 }
 
-// GetAvailabilityByPsychologist retrieves all availability records for a psychologist by their ID.
-func GetAvailabilityByPsychologist(ctx context.Context, psychologistID int) ([]Availability, error) {
-	conn := db.GetConnection()
-	var availability []Availability
-	err := conn.WithContext(ctx).Model(&availability).Where("psychologist_id = ?", psychologistID).OrderExpr("day_of_week, start_time").Select()
-	if err != nil {
-		return nil, err
-	}
+// Offset returns the time of day as a duration since midnight.
+func (t TimeOnly) Offset() time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
 
-	return availability, nil
+// Rule converts the availability record into a weekly scheduling rule.
+func (a *Availability) Rule() scheduling.WeeklyRule {
+	return scheduling.WeeklyRule{
+		Weekday: a.DayOfWeek,
+		Start:   a.StartTime.Offset(),
+		End:     a.EndTime.Offset(),
+	}
 }
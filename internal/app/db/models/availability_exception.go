@@ -0,0 +1,223 @@
+package models
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+	"github.com/vitalicher97/psychologist_app/internal/app/scheduling"
+)
+
+// Errors returned when an availability exception is not consistent.
+var (
+	ErrInvalidDateRange      = errors.New("end_date must not be before start_date")
+	ErrExceptionTimeRequired = errors.New("start_time and end_time are required unless all_day is set")
+	ErrInvalidExceptionKind  = errors.New("kind must be either block or extra")
+)
+
+// AvailabilityException represents the availability_exceptions table in the database.
+// It blocks or adds opening hours on specific dates, on top of the weekly availability.
+type AvailabilityException struct {
+	ID             int                      `json:"id" binding:"-" pg:",pk"`
+	PsychologistID int                      `json:"psychologist_id" binding:"required" pg:",notnull"`
+	Kind           scheduling.ExceptionKind `json:"kind" binding:"required" pg:",notnull"`
+	StartDate      DateOnly                 `json:"start_date" binding:"required" pg:",notnull"`
+	EndDate        DateOnly                 `json:"end_date" binding:"required" pg:",notnull"`
+	AllDay         bool                     `json:"all_day" binding:"-" pg:",notnull,use_zero"`
+	StartTime      *TimeOnly                `json:"start_time" binding:"-"`
+	EndTime        *TimeOnly                `json:"end_time" binding:"-"`
+	Reason         string                   `json:"reason" binding:"-"`
+	CreatedBy      int                      `json:"created_by" binding:"-" pg:",notnull"`
+	UpdatedBy      int                      `json:"updated_by" binding:"-" pg:",notnull"`
+	CreatedAt      time.Time                `json:"created_at" binding:"-" pg:",default:now()"`
+	UpdatedAt      time.Time                `json:"updated_at" binding:"-" pg:",default:now()"`
+}
+
+// Custom DateOnly type to handle "DATE"
+type DateOnly struct {
+	time.Time
+}
+
+// Scan method for PostgreSQL compatibility
+func (d *DateOnly) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return d.parseDate(v)
+	case []byte:
+		return d.parseDate(string(v))
+	case time.Time:
+		d.Time = v
+		return nil
+	default:
+		return fmt.Errorf("invalid date format: %T", value)
+	}
+}
+
+// Value method for PostgreSQL INSERT/UPDATE
+func (d DateOnly) Value() (driver.Value, error) {
+	return d.Format(time.DateOnly), nil
+}
+
+// MarshalJSON ensures JSON output remains "YYYY-MM-DD"
+func (d DateOnly) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format(time.DateOnly))
+}
+
+// UnmarshalJSON parses "YYYY-MM-DD"
+func (d *DateOnly) UnmarshalJSON(data []byte) error {
+	var strDate string
+	if err := json.Unmarshal(data, &strDate); err != nil {
+		return err
+	}
+	return d.parseDate(strDate)
+}
+
+// Helper function to parse a date, ignoring any time part the database may add
+func (d *DateOnly) parseDate(strDate string) error {
+	if len(strDate) > len(time.DateOnly) {
+		strDate = strDate[:len(time.DateOnly)]
+	}
+
+	parsedDate, err := time.Parse(time.DateOnly, strDate)
+	if err != nil {
+		return err
+	}
+	d.Time = parsedDate
+	return nil
+}
+
+// BeforeInsert is a method for performing additional changes to the availability_exceptions table when INSERT query executes. It adds time in created_at and updated_at columns.
+func (e *AvailabilityException) BeforeInsert(ctx context.Context) (context.Context, error) {
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+	e.CreatedBy = actingUserID(ctx)
+	e.UpdatedBy = e.CreatedBy
+
+	return ctx, nil
+}
+
+// BeforeUpdate is a method for performing additional changes to the availability_exceptions table when UPDATE query executes. It updates the time in updated_at column.
+func (e *AvailabilityException) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	e.UpdatedAt = time.Now()
+	e.UpdatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// Validate checks that the exception describes a consistent date range and time window.
+func (e *AvailabilityException) Validate() error {
+	if e.Kind != scheduling.ExceptionBlock && e.Kind != scheduling.ExceptionExtra {
+		return ErrInvalidExceptionKind
+	}
+
+	if e.EndDate.Before(e.StartDate.Time) {
+		return ErrInvalidDateRange
+	}
+
+	if e.AllDay {
+		e.StartTime = nil
+		e.EndTime = nil
+		return nil
+	}
+
+	if e.StartTime == nil || e.EndTime == nil {
+		return ErrExceptionTimeRequired
+	}
+
+	return nil
+}
+
+// Exception converts the record into a scheduling exception.
+func (e *AvailabilityException) Exception() scheduling.Exception {
+	exception := scheduling.Exception{
+		Kind:      e.Kind,
+		StartDate: e.StartDate.Time,
+		EndDate:   e.EndDate.Time,
+		AllDay:    e.AllDay,
+	}
+	if !e.AllDay && e.StartTime != nil && e.EndTime != nil {
+		exception.Start = e.StartTime.Offset()
+		exception.End = e.EndTime.Offset()
+	}
+
+	return exception
+}
+
+// Create inserts a new availability exception into the database.
+func (e *AvailabilityException) Create(ctx context.Context) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(e).Insert()
+
+	return err
+}
+
+// GetByID retrieves an availability exception by its ID.
+func (e *AvailabilityException) GetByID(ctx context.Context) (*AvailabilityException, error) {
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(e).WherePK().Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Update modifies an existing availability exception.
+func (e *AvailabilityException) Update(ctx context.Context) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(e).WherePK().Update()
+
+	return err
+}
+
+// DeleteByID removes an availability exception from the database by its ID.
+func (e *AvailabilityException) DeleteByID(ctx context.Context) error {
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(e).WherePK().Delete()
+
+	return err
+}
+
+// GetAllAvailabilityExceptions retrieves all availability exceptions.
+func GetAllAvailabilityExceptions(ctx context.Context) ([]AvailabilityException, error) {
+	conn := db.GetConnection()
+	var exceptions []AvailabilityException
+	err := conn.WithContext(ctx).Model(&exceptions).Order("start_date", "id").Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return exceptions, nil
+}
+
+// GetAvailabilityExceptionsByPsychologist retrieves the exceptions of a psychologist that touch the dates between from and to inclusive.
+func GetAvailabilityExceptionsByPsychologist(ctx context.Context, psychologistID int, from, to time.Time) ([]AvailabilityException, error) {
+	conn := db.GetConnection()
+	var exceptions []AvailabilityException
+	err := conn.WithContext(ctx).Model(&exceptions).
+		Where("psychologist_id = ?", psychologistID).
+		Where("start_date <= ? AND end_date >= ?", to.Format(time.DateOnly), from.Format(time.DateOnly)).
+		Order("start_date", "id").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return exceptions, nil
+}
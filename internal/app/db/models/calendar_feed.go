@@ -0,0 +1,144 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// Calendar feed owners.
+const (
+	FeedOwnerPsychologist = "psychologist"
+	FeedOwnerCustomer     = "customer"
+)
+
+// calendarFeedHistory is how far back a calendar feed lists appointments.
+const calendarFeedHistory = 90 * 24 * time.Hour
+
+// CalendarFeed represents the calendar_feeds table in the database.
+// Only a hash of the feed token is stored; the token itself is shown once when the feed is issued.
+type CalendarFeed struct {
+	ID        int        `json:"id" pg:",pk"`
+	OwnerType string     `json:"owner_type" pg:",notnull"`
+	OwnerID   int        `json:"owner_id" pg:",notnull"`
+	TokenHash string     `json:"-" pg:",notnull"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedBy int        `json:"created_by" pg:",notnull"`
+	CreatedAt time.Time  `json:"created_at" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the calendar_feeds table when INSERT query executes. It adds time in created_at column.
+func (f *CalendarFeed) BeforeInsert(ctx context.Context) (context.Context, error) {
+	f.CreatedAt = time.Now()
+	f.CreatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// IssueCalendarFeed revokes the current feed of the owner, if any, and issues a new one.
+// It returns the plain token, which cannot be recovered later.
+func IssueCalendarFeed(ctx context.Context, ownerType string, ownerID int) (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	conn := db.GetConnection()
+	err = conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := revokeCalendarFeed(ctx, tx, ownerType, ownerID); err != nil {
+			return err
+		}
+
+		feed := &CalendarFeed{OwnerType: ownerType, OwnerID: ownerID, TokenHash: hashToken(token)}
+		_, err := tx.ModelContext(ctx, feed).Insert()
+
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// RevokeCalendarFeed revokes the current feed of the owner so its token stops working.
+func RevokeCalendarFeed(ctx context.Context, ownerType string, ownerID int) error {
+	conn := db.GetConnection()
+
+	return conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		return revokeCalendarFeed(ctx, tx, ownerType, ownerID)
+	})
+}
+
+// GetCalendarFeed retrieves the active feed of the owner matching the token.
+// It returns pg.ErrNoRows when the token is unknown or revoked.
+func GetCalendarFeed(ctx context.Context, ownerType string, ownerID int, token string) (*CalendarFeed, error) {
+	conn := db.GetConnection()
+	feed := &CalendarFeed{}
+	err := conn.WithContext(ctx).Model(feed).
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Where("token_hash = ?", hashToken(token)).
+		Where("revoked_at IS NULL").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// Appointments retrieves the appointments shown in the feed, starting calendarFeedHistory ago.
+func (f *CalendarFeed) Appointments(ctx context.Context) ([]Appointment, error) {
+	column := "psychologist_id"
+	if f.OwnerType == FeedOwnerCustomer {
+		column = "customer_id"
+	}
+
+	conn := db.GetConnection()
+	var appointments []Appointment
+	err := conn.WithContext(ctx).Model(&appointments).
+		Where("? = ?", pg.Ident(column), f.OwnerID).
+		Where("end_time > ?", time.Now().Add(-calendarFeedHistory)).
+		Order("start_time").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return appointments, nil
+}
+
+// revokeCalendarFeed marks the active feed of the owner as revoked.
+func revokeCalendarFeed(ctx context.Context, tx *pg.Tx, ownerType string, ownerID int) error {
+	_, err := tx.ModelContext(ctx, (*CalendarFeed)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Where("revoked_at IS NULL").
+		Update()
+
+	return err
+}
+
+// newToken returns a random URL-safe token.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex-encoded SHA-256 hash under which a token is stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,470 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+	"github.com/vitalicher97/psychologist_app/internal/app/ical"
+	"github.com/vitalicher97/psychologist_app/internal/app/scheduling"
+)
+
+const (
+	// maxCalendarSize limits how much of an imported calendar is read.
+	maxCalendarSize = 5 << 20
+	// importHorizon is how far ahead imported events become busy blocks.
+	importHorizon = 365 * 24 * time.Hour
+	// sourceFetchTimeout limits how long reading a remote calendar may take.
+	sourceFetchTimeout = 15 * time.Second
+)
+
+// Errors returned when a calendar import cannot be read.
+var (
+	ErrCalendarTooLarge  = errors.New("the calendar file is too large")
+	ErrUnsupportedSource = errors.New("source must be an https:// URL of a public host")
+	ErrNoSource          = errors.New("the import has no source to re-read; upload the file instead")
+	ErrSourceUnavailable = errors.New("the calendar source could not be read")
+)
+
+// errPrivateAddress is returned when a calendar source resolves to an address inside the network.
+var errPrivateAddress = errors.New("the calendar source resolves to a private address")
+
+// deniedPrefixes are the address ranges calendar sources may not be fetched from: private, shared,
+// loopback, link-local, reserved, documentation, benchmarking and multicast ranges, which can reach
+// services inside the network in cloud environments, and the IPv6 ranges that translate or embed
+// IPv4 addresses.
+var deniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.88.99.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/96"),
+	netip.MustParsePrefix("::ffff:0:0/96"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/23"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("fec0::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// sourceClient downloads remote calendars. It only connects to public addresses, checked after the
+// host name is resolved so a name pointing inside the network cannot be used to reach internal services,
+// and it only follows redirects to other https URLs.
+var sourceClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:               nil,
+		DialContext:         (&net.Dialer{Timeout: sourceFetchTimeout, Control: dialPublicOnly}).DialContext,
+		TLSHandshakeTimeout: sourceFetchTimeout,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 || req.URL.Scheme != "https" {
+			return ErrUnsupportedSource
+		}
+		return nil
+	},
+}
+
+// CalendarImport represents the calendar_imports table in the database.
+// Each import owns a batch of busy blocks that is replaced whenever the calendar is imported again.
+type CalendarImport struct {
+	ID             int        `json:"id" binding:"-" pg:",pk"`
+	PsychologistID int        `json:"psychologist_id" binding:"required" pg:",notnull"`
+	Name           string     `json:"name" binding:"-"`
+	Source         string     `json:"source" binding:"-"`
+	EventCount     int        `json:"event_count" binding:"-" pg:",notnull,use_zero"`
+	LastImportedAt *time.Time `json:"last_imported_at" binding:"-"`
+	LastError      string     `json:"last_error" binding:"-"`
+	CreatedBy      int        `json:"created_by" binding:"-" pg:",notnull"`
+	UpdatedBy      int        `json:"updated_by" binding:"-" pg:",notnull"`
+	CreatedAt      time.Time  `json:"created_at" binding:"-" pg:",default:now()"`
+	UpdatedAt      time.Time  `json:"updated_at" binding:"-" pg:",default:now()"`
+}
+
+// BusyBlock represents the external_busy_blocks table in the database.
+// It is a span of time in which a psychologist cannot be booked because of an imported event.
+type BusyBlock struct {
+	tableName struct{} `pg:"external_busy_blocks"`
+
+	ID             int       `json:"id" pg:",pk"`
+	ImportID       int       `json:"import_id" pg:",notnull"`
+	PsychologistID int       `json:"psychologist_id" pg:",notnull"`
+	UID            string    `json:"uid" pg:"uid"`
+	StartTime      time.Time `json:"start_time" pg:",notnull"`
+	EndTime        time.Time `json:"end_time" pg:",notnull"`
+	CreatedAt      time.Time `json:"created_at" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the calendar_imports table when INSERT query executes. It adds time in created_at and updated_at columns.
+func (ci *CalendarImport) BeforeInsert(ctx context.Context) (context.Context, error) {
+	ci.CreatedAt = time.Now()
+	ci.UpdatedAt = ci.CreatedAt
+	ci.CreatedBy = actingUserID(ctx)
+	ci.UpdatedBy = ci.CreatedBy
+
+	return ctx, nil
+}
+
+// BeforeUpdate is a method for performing additional changes to the calendar_imports table when UPDATE query executes. It updates time in updated_at column.
+func (ci *CalendarImport) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	ci.UpdatedAt = time.Now()
+	ci.UpdatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// BeforeInsert is a method for performing additional changes to the external_busy_blocks table when INSERT query executes. It adds time in created_at column.
+func (b *BusyBlock) BeforeInsert(ctx context.Context) (context.Context, error) {
+	b.CreatedAt = time.Now()
+
+	return ctx, nil
+}
+
+// Create inserts a new calendar import into the database.
+func (ci *CalendarImport) Create(ctx context.Context) error {
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(ci).Insert()
+
+	return err
+}
+
+// GetByID retrieves a calendar import by its ID.
+func (ci *CalendarImport) GetByID(ctx context.Context) (*CalendarImport, error) {
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(ci).WherePK().Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return ci, nil
+}
+
+// DeleteByID removes a calendar import and its busy blocks from the database by its ID.
+func (ci *CalendarImport) DeleteByID(ctx context.Context) error {
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(ci).WherePK().Delete()
+
+	return err
+}
+
+// GetCalendarImportsByPsychologist retrieves the calendar imports of a psychologist.
+func GetCalendarImportsByPsychologist(ctx context.Context, psychologistID int) ([]CalendarImport, error) {
+	conn := db.GetConnection()
+	var imports []CalendarImport
+	err := conn.WithContext(ctx).Model(&imports).Where("psychologist_id = ?", psychologistID).Order("id").Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return imports, nil
+}
+
+// Import parses the calendar and replaces the busy blocks of the import with its events.
+// Importing the same calendar again leaves the same set of blocks.
+func (ci *CalendarImport) Import(ctx context.Context, r io.Reader) error {
+	loc, err := psychologistLocation(ctx, ci.PsychologistID)
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxCalendarSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxCalendarSize {
+		return ErrCalendarTooLarge
+	}
+
+	events, err := ical.Parse(bytes.NewReader(data), loc)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	blocks := busyBlocks(events, now.Add(-24*time.Hour), now.Add(importHorizon))
+
+	conn := db.GetConnection()
+
+	return conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if _, err := tx.ModelContext(ctx, (*BusyBlock)(nil)).Where("import_id = ?", ci.ID).Delete(); err != nil {
+			return err
+		}
+
+		for i := range blocks {
+			blocks[i].ImportID = ci.ID
+			blocks[i].PsychologistID = ci.PsychologistID
+		}
+		if len(blocks) > 0 {
+			if _, err := tx.ModelContext(ctx, &blocks).Insert(); err != nil {
+				return err
+			}
+		}
+
+		ci.EventCount = len(blocks)
+		ci.LastImportedAt = &now
+		ci.LastError = ""
+		_, err := tx.ModelContext(ctx, ci).Column("event_count", "last_imported_at", "last_error", "updated_at").WherePK().Update()
+
+		return err
+	})
+}
+
+// Refresh re-reads the import from its source and replaces its busy blocks.
+// A failure is recorded on the import, keeping the previous blocks in place.
+func (ci *CalendarImport) Refresh(ctx context.Context) error {
+	if ci.Source == "" {
+		return ErrNoSource
+	}
+
+	err := ci.refresh(ctx)
+	if err != nil {
+		ci.LastError = err.Error()
+		conn := db.GetConnection()
+		if _, updateErr := conn.WithContext(ctx).Model(ci).Column("last_error", "updated_at").WherePK().Update(); updateErr != nil {
+			return updateErr
+		}
+	}
+
+	return err
+}
+
+// refresh opens the source and imports it. Why a source could not be read is only logged, so callers
+// cannot use imports to probe which files exist or what internal services answer.
+func (ci *CalendarImport) refresh(ctx context.Context) error {
+	body, err := openCalendarSource(ctx, ci.Source)
+	if errors.Is(err, ErrUnsupportedSource) {
+		return err
+	}
+	if err != nil {
+		log.Printf("Failed to read the source of calendar import %d: %v", ci.ID, err)
+		return ErrSourceUnavailable
+	}
+	defer body.Close()
+
+	return ci.Import(ctx, body)
+}
+
+// RefreshCalendarImports re-reads every import that has a source. It is meant to run periodically.
+func RefreshCalendarImports(ctx context.Context) error {
+	conn := db.GetConnection()
+	var imports []CalendarImport
+	err := conn.WithContext(ctx).Model(&imports).Where("source IS NOT NULL AND source != ''").Order("id").Select()
+	if err != nil {
+		return err
+	}
+
+	for i := range imports {
+		if err := imports[i].Refresh(ctx); err != nil {
+			log.Printf("Failed to refresh calendar import %d: %v", imports[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateCalendarSource checks that the source is a kind of location the importer can read.
+// Sources are https URLs of public hosts. Local file paths and file:// URLs are only accepted
+// when allowLocal is set, which is reserved for administrators.
+func ValidateCalendarSource(source string, allowLocal bool) error {
+	parsed, err := url.Parse(source)
+	if err != nil {
+		return ErrUnsupportedSource
+	}
+
+	switch parsed.Scheme {
+	case "", "file":
+		if allowLocal {
+			return nil
+		}
+		return ErrUnsupportedSource
+	case "https":
+		host := parsed.Hostname()
+		if host == "" || strings.EqualFold(host, "localhost") {
+			return ErrUnsupportedSource
+		}
+		if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+			return ErrUnsupportedSource
+		}
+		return nil
+	default:
+		return ErrUnsupportedSource
+	}
+}
+
+// isPublicIP reports whether the address is reachable on the internet rather than inside the network
+// or on the machine itself. IPv4 addresses written in IPv6 form are checked as IPv4.
+func isPublicIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range deniedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dialPublicOnly refuses connections to addresses that are not public.
+func dialPublicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return errPrivateAddress
+	}
+
+	return nil
+}
+
+// openCalendarSource opens a local file or downloads a calendar over https. Sources were checked by
+// ValidateCalendarSource when they were stored; local ones can only have been set by an administrator.
+func openCalendarSource(ctx context.Context, source string) (io.ReadCloser, error) {
+	parsed, err := url.Parse(source)
+	if err != nil {
+		return nil, ErrUnsupportedSource
+	}
+
+	switch parsed.Scheme {
+	case "https":
+		ctx, cancel := context.WithTimeout(ctx, sourceFetchTimeout)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		resp, err := sourceClient.Do(req)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			cancel()
+			return nil, fmt.Errorf("fetching %s: unexpected status %s", source, resp.Status)
+		}
+
+		return &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}, nil
+	case "file":
+		return os.Open(parsed.Path)
+	case "":
+		return os.Open(source)
+	default:
+		return nil, ErrUnsupportedSource
+	}
+}
+
+// cancelOnClose releases the request context once the response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the request context.
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+
+	return c.ReadCloser.Close()
+}
+
+// busyBlocks turns the opaque, non-cancelled events into blocks within the [from, to) window.
+// Recurring events are expanded, leaving out the instances that an override moves or cancels.
+// A rule that cannot be expanded only blocks the event's first instance.
+func busyBlocks(events []ical.Event, from, to time.Time) []BusyBlock {
+	overridden := make(map[string][]time.Time)
+	for _, event := range events {
+		if !event.RecurrenceID.IsZero() {
+			overridden[event.UID] = append(overridden[event.UID], event.RecurrenceID)
+		}
+	}
+
+	window := scheduling.Interval{Start: from, End: to}
+	blocks := make([]BusyBlock, 0, len(events))
+	for _, event := range events {
+		if event.Transparent || event.Status == ical.StatusCancelled || !event.End.After(event.Start) {
+			continue
+		}
+
+		duration := event.End.Sub(event.Start)
+		starts, err := event.Occurrences(from.Add(-duration), to)
+		if err != nil {
+			log.Printf("Failed to expand the recurrence rule %q of imported event %q: %v", event.RRule, event.UID, err)
+			starts = []time.Time{event.Start}
+		}
+
+		for _, start := range starts {
+			if event.RecurrenceID.IsZero() && isOverridden(overridden[event.UID], start) {
+				continue
+			}
+
+			block := scheduling.Interval{Start: start, End: start.Add(duration)}
+			if block.Overlaps(window) {
+				blocks = append(blocks, BusyBlock{UID: event.UID, StartTime: block.Start, EndTime: block.End})
+			}
+		}
+	}
+
+	return blocks
+}
+
+// isOverridden reports whether one of the recurrence IDs names the instance starting at start.
+func isOverridden(recurrenceIDs []time.Time, start time.Time) bool {
+	for _, id := range recurrenceIDs {
+		if id.Equal(start) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetBusyBlocksByPsychologistInRange retrieves the busy blocks of a psychologist that overlap the [from, to) window.
+func GetBusyBlocksByPsychologistInRange(ctx context.Context, psychologistID int, from, to time.Time) ([]BusyBlock, error) {
+	conn := db.GetConnection()
+	var blocks []BusyBlock
+	err := conn.WithContext(ctx).Model(&blocks).
+		Where("psychologist_id = ?", psychologistID).
+		Where("start_time < ? AND end_time > ?", to, from).
+		Order("start_time").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/ical"
+)
+
+func TestBusyBlocks(t *testing.T) {
+	// 2 March 2026 is a Monday.
+	monday := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return monday.AddDate(0, 0, n) }
+	from, to := monday, monday.AddDate(0, 0, 14)
+
+	tests := []struct {
+		name   string
+		events []ical.Event
+		want   []time.Time
+	}{
+		{
+			name:   "Google weekly on several days",
+			events: []ical.Event{{UID: "a", Start: day(-28), End: day(-28).Add(time.Hour), RRule: "FREQ=WEEKLY;WKST=MO;BYDAY=MO,WE"}},
+			want:   []time.Time{day(0), day(2), day(7), day(9)},
+		},
+		{
+			name:   "instance running into the window",
+			events: []ical.Event{{UID: "a", Start: day(-1).Add(23 * time.Hour), End: day(0).Add(time.Hour)}},
+			want:   []time.Time{day(-1).Add(23 * time.Hour)},
+		},
+		{
+			name: "excluded dates and overrides",
+			events: []ical.Event{
+				{UID: "a", Start: day(0), End: day(0).Add(time.Hour), RRule: "FREQ=DAILY;COUNT=5", ExDates: []time.Time{day(1)}},
+				{UID: "a", Start: day(2).Add(3 * time.Hour), End: day(2).Add(4 * time.Hour), RecurrenceID: day(2)},
+				{UID: "a", Start: day(3), End: day(3).Add(time.Hour), RecurrenceID: day(3), Status: ical.StatusCancelled},
+			},
+			want: []time.Time{day(0), day(4), day(2).Add(3 * time.Hour)},
+		},
+		{
+			name: "free and cancelled events",
+			events: []ical.Event{
+				{UID: "a", Start: day(0), End: day(0).Add(time.Hour), Transparent: true},
+				{UID: "b", Start: day(1), End: day(1).Add(time.Hour), Status: ical.StatusCancelled},
+			},
+			want: nil,
+		},
+		{
+			name:   "unsupported rule blocks the first instance",
+			events: []ical.Event{{UID: "a", Start: day(1), End: day(1).Add(time.Hour), RRule: "FREQ=MONTHLY;BYDAY=1TU"}},
+			want:   []time.Time{day(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []time.Time
+			for _, block := range busyBlocks(tt.events, from, to) {
+				got = append(got, block.StartTime)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("busyBlocks start at %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "8.8.8.8", want: true},
+		{ip: "2606:4700:4700::1111", want: true},
+		{ip: "2001:4860:4860::8888", want: true},
+		{ip: "0.0.0.0"},
+		{ip: "10.1.2.3"},
+		{ip: "100.64.0.1"},
+		{ip: "100.127.255.254"},
+		{ip: "127.0.0.1"},
+		{ip: "169.254.169.254"},
+		{ip: "172.16.0.1"},
+		{ip: "192.0.0.170"},
+		{ip: "192.0.2.1"},
+		{ip: "192.168.1.1"},
+		{ip: "198.18.0.1"},
+		{ip: "198.19.255.255"},
+		{ip: "198.51.100.7"},
+		{ip: "203.0.113.9"},
+		{ip: "224.0.0.251"},
+		{ip: "255.255.255.255"},
+		{ip: "::"},
+		{ip: "::1"},
+		{ip: "::ffff:10.0.0.1"},
+		{ip: "::ffff:169.254.169.254"},
+		{ip: "64:ff9b::a9fe:a9fe"},
+		{ip: "64:ff9b:1::a00:1"},
+		{ip: "2001:db8::1"},
+		{ip: "2002:a00:1::1"},
+		{ip: "fd00:ec2::254"},
+		{ip: "fe80::1"},
+		{ip: "ff02::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCalendarSource(t *testing.T) {
+	tests := []struct {
+		source     string
+		allowLocal bool
+		wantErr    bool
+	}{
+		{source: "https://calendar.google.com/calendar/ical/basic.ics"},
+		{source: "https://93.184.216.34/basic.ics"},
+		{source: "/var/calendars/basic.ics", allowLocal: true},
+		{source: "/var/calendars/basic.ics", wantErr: true},
+		{source: "file:///etc/passwd", wantErr: true},
+		{source: "http://calendar.google.com/basic.ics", wantErr: true},
+		{source: "https://localhost/basic.ics", wantErr: true},
+		{source: "https://100.64.0.1/basic.ics", wantErr: true},
+		{source: "https://198.18.0.1/basic.ics", wantErr: true},
+		{source: "https://[::ffff:127.0.0.1]/basic.ics", wantErr: true},
+		{source: "https://[64:ff9b::a9fe:a9fe]/latest/meta-data", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			err := ValidateCalendarSource(tt.source, tt.allowLocal)
+			if tt.wantErr && !errors.Is(err, ErrUnsupportedSource) {
+				t.Errorf("ValidateCalendarSource error = %v, want ErrUnsupportedSource", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("ValidateCalendarSource: %v", err)
+			}
+		})
+	}
+}
+
+func TestDialPublicOnly(t *testing.T) {
+	for _, address := range []string{"100.64.0.1:443", "[::ffff:10.0.0.1]:443", "[64:ff9b::a00:1]:443"} {
+		if err := dialPublicOnly("tcp", address, nil); !errors.Is(err, errPrivateAddress) {
+			t.Errorf("dialPublicOnly(%s) = %v, want errPrivateAddress", address, err)
+		}
+	}
+
+	if err := dialPublicOnly("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("dialPublicOnly(93.184.216.34:443) = %v, want nil", err)
+	}
+}
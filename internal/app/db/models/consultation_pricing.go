@@ -26,6 +26,8 @@ type ConsultationPricing struct {
 func (c *ConsultationPricing) BeforeInsert(ctx context.Context) (context.Context, error) {
 	c.CreatedAt = time.Now()
 	c.UpdatedAt = c.CreatedAt
+	c.CreatedBy = actingUserID(ctx)
+	c.UpdatedBy = c.CreatedBy
 
 	return ctx, nil
 }
@@ -34,6 +36,7 @@ func (c *ConsultationPricing) BeforeInsert(ctx context.Context) (context.Context
 // It updates time in updated_at column
 func (c *ConsultationPricing) BeforeUpdate(ctx context.Context) (context.Context, error) {
 	c.UpdatedAt = time.Now()
+	c.UpdatedBy = actingUserID(ctx)
 
 	return ctx, nil
 }
@@ -73,26 +76,28 @@ func (c *ConsultationPricing) GetByID(ctx context.Context) (*ConsultationPricing
 	return c, nil
 }
 
-// GetAll retrieves all consultation pricing entries.
-func GetAllConsultationPricing(ctx context.Context) ([]ConsultationPricing, error) {
-	conn := db.GetConnection()
-	var pricingList []ConsultationPricing
-	err := conn.WithContext(ctx).Model(&pricingList).Select()
-	if err != nil {
-		return nil, err
-	}
-
-	return pricingList, nil
+// consultationPricingListSpec lists the fields consultation pricing lists can be sorted and filtered by.
+var consultationPricingListSpec = listSpec{
+	sortable: map[string]string{
+		"id":         "id",
+		"price":      "price",
+		"currency":   "currency",
+		"created_at": "created_at",
+	},
+	defaultSort: []SortField{{Field: "id"}},
+	filters: map[string]filterFunc{
+		"psychologist": filterInt("psychologist_id"),
+		"currency":     filterString("currency"),
+	},
 }
 
-// GetConsultationPricingByPsychologistID retrieves consultation pricing entries by psychologist ID.
-func GetConsultationPricingByPsychologistID(ctx context.Context, psychologistID int) ([]ConsultationPricing, error) {
-	conn := db.GetConnection()
+// ListConsultationPricing retrieves a page of consultation pricing entries and the number of entries matching the filters.
+func ListConsultationPricing(ctx context.Context, params *ListParams) ([]ConsultationPricing, int, error) {
 	var pricingList []ConsultationPricing
-	err := conn.WithContext(ctx).Model(&pricingList).Where("psychologist_id = ?", psychologistID).Select()
+	total, err := selectPage(newListQuery(ctx, &pricingList), params, consultationPricingListSpec)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return pricingList, nil
+	return pricingList, total, nil
 }
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// CredentialDocument represents the credential_documents table in the database.
+// It is a diploma, license or certificate a psychologist uploaded for verification.
+type CredentialDocument struct {
+	ID             int `json:"id" pg:",pk"`
+	PsychologistID int `json:"psychologist_id" pg:",notnull"`
+	// StorageName is the name the file is kept under in the document storage.
+	StorageName  string    `json:"-" pg:",notnull"`
+	OriginalName string    `json:"original_name" pg:",notnull"`
+	ContentType  string    `json:"content_type" pg:",notnull"`
+	Size         int64     `json:"size" pg:",notnull"`
+	CreatedBy    int       `json:"created_by" pg:",notnull"`
+	CreatedAt    time.Time `json:"created_at" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the credential_documents table when INSERT query executes. It adds time in created_at column.
+func (d *CredentialDocument) BeforeInsert(ctx context.Context) (context.Context, error) {
+	d.CreatedAt = time.Now()
+	d.CreatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// Create records an uploaded document while the psychologist's profile is a draft.
+func (d *CredentialDocument) Create(ctx context.Context) error {
+	conn := db.GetConnection()
+
+	return conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := lockDraft(ctx, tx, d.PsychologistID); err != nil {
+			return err
+		}
+
+		_, err := tx.ModelContext(ctx, d).Returning("*").Insert()
+
+		return err
+	})
+}
+
+// GetByID retrieves a document of the psychologist by its ID.
+func (d *CredentialDocument) GetByID(ctx context.Context) (*CredentialDocument, error) {
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(d).WherePK().Where("psychologist_id = ?", d.PsychologistID).Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// DeleteByID removes a document of the psychologist while their profile is a draft.
+// The stored file is left to the caller.
+func (d *CredentialDocument) DeleteByID(ctx context.Context) error {
+	conn := db.GetConnection()
+
+	return conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := lockDraft(ctx, tx, d.PsychologistID); err != nil {
+			return err
+		}
+
+		res, err := tx.ModelContext(ctx, d).WherePK().Where("psychologist_id = ?", d.PsychologistID).Returning("*").Delete()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return pg.ErrNoRows
+		}
+
+		return nil
+	})
+}
+
+// GetCredentialDocumentsByPsychologist retrieves the documents of a psychologist in upload order.
+func GetCredentialDocumentsByPsychologist(ctx context.Context, psychologistID int) ([]CredentialDocument, error) {
+	conn := db.GetConnection()
+	var documents []CredentialDocument
+	err := conn.WithContext(ctx).Model(&documents).Where("psychologist_id = ?", psychologistID).Order("id").Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// lockDraft locks the psychologist's row and ensures their profile is still a draft.
+func lockDraft(ctx context.Context, tx *pg.Tx, psychologistID int) error {
+	psychologist := &Psychologist{ID: psychologistID}
+	if err := tx.ModelContext(ctx, psychologist).Column("onboarding_status").WherePK().For("UPDATE").Select(); err != nil {
+		return err
+	}
+	if psychologist.OnboardingStatus != OnboardingDraft {
+		return ErrOnboardingLocked
+	}
+
+	return nil
+}
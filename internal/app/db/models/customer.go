@@ -2,8 +2,12 @@ package models
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/go-pg/pg/v10"
+
 	"github.com/vitalicher97/psychologist_app/internal/app/db"
 )
 
@@ -25,6 +29,8 @@ type Customer struct {
 func (c *Customer) BeforeInsert(ctx context.Context) (context.Context, error) {
 	c.CreatedAt = time.Now()
 	c.UpdatedAt = c.CreatedAt
+	c.CreatedBy = actingUserID(ctx)
+	c.UpdatedBy = c.CreatedBy
 
 	return ctx, nil
 }
@@ -33,6 +39,7 @@ func (c *Customer) BeforeInsert(ctx context.Context) (context.Context, error) {
 // It updates the time in updated_at column
 func (c *Customer) BeforeUpdate(ctx context.Context) (context.Context, error) {
 	c.UpdatedAt = time.Now()
+	c.UpdatedBy = actingUserID(ctx)
 
 	return ctx, nil
 }
@@ -68,16 +75,54 @@ func GetCustomerByEmail(ctx context.Context, email string) (*Customer, error) {
 	return &customer, nil
 }
 
-// GetAll retrieves all customers.
-func GetAllCustomers(ctx context.Context) ([]Customer, error) {
+// customerListSpec lists the fields customer lists can be sorted and filtered by.
+var customerListSpec = listSpec{
+	sortable: map[string]string{
+		"id":         "id",
+		"first_name": "first_name",
+		"last_name":  "last_name",
+		"email":      "email",
+		"created_at": "created_at",
+	},
+	defaultSort: []SortField{{Field: "id"}},
+	filters: map[string]filterFunc{
+		"id":           filterInt("id"),
+		"phone":        filterString("phone"),
+		"psychologist": filterBookedWith,
+	},
+}
+
+// filterBookedWith matches customers who have booked an appointment with the psychologist with the ID,
+// whatever became of it.
+func filterBookedWith(q *pg.Query, value string, _ *time.Location) error {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("must be a number")
+	}
+	q.Where("EXISTS (SELECT 1 FROM appointments AS a WHERE a.customer_id = customer.id AND a.psychologist_id = ?)", id)
+
+	return nil
+}
+
+// HasBookedWith reports whether the customer has booked an appointment with the psychologist,
+// whatever became of it.
+func HasBookedWith(ctx context.Context, customerID, psychologistID int) (bool, error) {
 	conn := db.GetConnection()
+
+	return conn.WithContext(ctx).Model((*Appointment)(nil)).
+		Where("customer_id = ? AND psychologist_id = ?", customerID, psychologistID).
+		Exists()
+}
+
+// ListCustomers retrieves a page of customers and the number of customers matching the filters.
+func ListCustomers(ctx context.Context, params *ListParams) ([]Customer, int, error) {
 	var customers []Customer
-	err := conn.WithContext(ctx).Model(&customers).Select()
+	total, err := selectPage(newListQuery(ctx, &customers), params, customerListSpec)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return customers, nil
+	return customers, total, nil
 }
 
 // Update modifies an existing customer's data.
@@ -95,3 +140,24 @@ func (c *Customer) DeleteByID(ctx context.Context) error {
 
 	return err
 }
+
+// GetCustomersByIDs retrieves the customers with the given IDs, keyed by ID.
+func GetCustomersByIDs(ctx context.Context, ids []int) (map[int]Customer, error) {
+	customers := make(map[int]Customer, len(ids))
+	if len(ids) == 0 {
+		return customers, nil
+	}
+
+	conn := db.GetConnection()
+	var list []Customer
+	err := conn.WithContext(ctx).Model(&list).Where("id IN (?)", pg.In(ids)).Select()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, customer := range list {
+		customers[customer.ID] = customer
+	}
+
+	return customers, nil
+}
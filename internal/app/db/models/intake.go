@@ -0,0 +1,446 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+	"github.com/vitalicher97/psychologist_app/internal/app/matching"
+)
+
+// Errors returned when an intake question is configured incorrectly.
+var (
+	ErrUnknownIntakeKey        = errors.New("key must be one of concerns, gender, language, budget, modality, time_of_day")
+	ErrDuplicateIntakeQuestion = errors.New("a question for this key already exists")
+	ErrIntakeOptionsNotAllowed = errors.New("options cannot be set for this question")
+)
+
+// PsychologistGenders lists the genders a psychologist profile can state.
+var PsychologistGenders = []string{"female", "male", "non_binary"}
+
+// IntakeOption is a choice offered by an intake question.
+type IntakeOption struct {
+	Value string `json:"value" binding:"required,max=50"`
+	Label string `json:"label" binding:"required,max=200"`
+}
+
+// IntakeQuestion represents the intake_questions table in the database.
+// It configures how the questionnaire asks about one matching criterion and how much that criterion counts.
+// Inactive questions are neither asked nor scored.
+type IntakeQuestion struct {
+	ID       int                `json:"id" binding:"-" pg:",pk"`
+	Key      matching.Criterion `json:"key" binding:"required" pg:",unique,notnull"`
+	Prompt   string             `json:"prompt" binding:"required,max=500" pg:",notnull"`
+	HelpText string             `json:"help_text" binding:"max=1000"`
+	// Options restricts the answers to the listed values. The options of the concerns question are
+	// always the specializations, and the budget question has none.
+	Options  []IntakeOption `json:"options" binding:"dive" pg:",type:jsonb"`
+	Required bool           `json:"required" pg:",use_zero,notnull"`
+	Position int            `json:"position" pg:",use_zero,notnull"`
+	// Weight is how much the criterion counts towards the match score; zero uses the default weight.
+	Weight    float64   `json:"weight" binding:"min=0,max=10" pg:",use_zero,notnull"`
+	Active    bool      `json:"active" pg:",use_zero,notnull"`
+	CreatedBy int       `json:"created_by" binding:"-" pg:",notnull"`
+	UpdatedBy int       `json:"updated_by" binding:"-" pg:",notnull"`
+	CreatedAt time.Time `json:"created_at" binding:"-" pg:",default:now()"`
+	UpdatedAt time.Time `json:"updated_at" binding:"-" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the intake_questions table when INSERT query executes. It adds time in created_at and updated_at columns.
+func (q *IntakeQuestion) BeforeInsert(ctx context.Context) (context.Context, error) {
+	q.CreatedAt = time.Now()
+	q.UpdatedAt = q.CreatedAt
+	q.CreatedBy = actingUserID(ctx)
+	q.UpdatedBy = q.CreatedBy
+
+	return ctx, nil
+}
+
+// BeforeUpdate is a method for performing additional changes to the intake_questions table when UPDATE query executes. It updates time in updated_at column.
+func (q *IntakeQuestion) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	q.UpdatedAt = time.Now()
+	q.UpdatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// validate checks the key and that every option is a value the criterion understands.
+func (q *IntakeQuestion) validate() error {
+	known := false
+	for _, criterion := range matching.Criteria {
+		known = known || q.Key == criterion
+	}
+	if !known {
+		return ErrUnknownIntakeKey
+	}
+
+	if (q.Key == matching.Concerns || q.Key == matching.Budget) && len(q.Options) > 0 {
+		return ErrIntakeOptionsNotAllowed
+	}
+
+	for i, option := range q.Options {
+		value, err := normalizeIntakeChoice(q.Key, option.Value)
+		if err != nil {
+			return &IntakeAnswerError{Key: q.Key, Message: fmt.Sprintf("option %q: %s", option.Value, err)}
+		}
+		q.Options[i].Value = value
+	}
+
+	return nil
+}
+
+// ListIntakeQuestions retrieves every intake question, including inactive ones, in the order they are asked.
+func ListIntakeQuestions(ctx context.Context) ([]IntakeQuestion, error) {
+	conn := db.GetConnection()
+	var questions []IntakeQuestion
+	err := conn.WithContext(ctx).Model(&questions).Order("position", "id").Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return questions, nil
+}
+
+// GetIntakeQuestionnaire retrieves the active intake questions in the order they are asked.
+// The options of the concerns question are filled in from the specializations.
+func GetIntakeQuestionnaire(ctx context.Context) ([]IntakeQuestion, error) {
+	conn := db.GetConnection()
+	var questions []IntakeQuestion
+	err := conn.WithContext(ctx).Model(&questions).Where("active").Order("position", "id").Select()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range questions {
+		if questions[i].Key != matching.Concerns {
+			continue
+		}
+
+		var specializations []Specialization
+		err := conn.WithContext(ctx).Model(&specializations).Order("name", "id").Select()
+		if err != nil {
+			return nil, err
+		}
+		questions[i].Options = make([]IntakeOption, len(specializations))
+		for j, s := range specializations {
+			questions[i].Options[j] = IntakeOption{Value: strconv.Itoa(s.ID), Label: s.Name}
+		}
+	}
+
+	return questions, nil
+}
+
+// GetByID retrieves an intake question by its ID.
+func (q *IntakeQuestion) GetByID(ctx context.Context) (*IntakeQuestion, error) {
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(q).WherePK().Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// Create inserts a new intake question into the database.
+func (q *IntakeQuestion) Create(ctx context.Context) error {
+	if err := q.validate(); err != nil {
+		return err
+	}
+
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(q).Returning("*").Insert()
+
+	return duplicateIntakeQuestion(err)
+}
+
+// Update modifies an existing intake question.
+func (q *IntakeQuestion) Update(ctx context.Context) error {
+	if err := q.validate(); err != nil {
+		return err
+	}
+
+	conn := db.GetConnection()
+	res, err := conn.WithContext(ctx).Model(q).ExcludeColumn("created_by", "created_at").WherePK().Returning("*").Update()
+	if err != nil {
+		return duplicateIntakeQuestion(err)
+	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteByID removes an intake question from the database by its ID.
+func (q *IntakeQuestion) DeleteByID(ctx context.Context) error {
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(q).WherePK().Delete()
+
+	return err
+}
+
+// duplicateIntakeQuestion turns a unique violation on the key into ErrDuplicateIntakeQuestion.
+func duplicateIntakeQuestion(err error) error {
+	var pgErr pg.Error
+	if errors.As(err, &pgErr) && pgErr.Field('C') == uniqueViolation {
+		return ErrDuplicateIntakeQuestion
+	}
+
+	return err
+}
+
+// IntakeAnswers are a customer's answers to the intake questionnaire, keyed like the questions.
+// Questions left unanswered express no preference.
+type IntakeAnswers struct {
+	// Concerns are the IDs of the specializations the customer needs help with.
+	Concerns  []int             `json:"concerns,omitempty"`
+	Gender    string            `json:"gender,omitempty"`
+	Language  string            `json:"language,omitempty"`
+	Budget    *matching.Money   `json:"budget,omitempty"`
+	Modality  string            `json:"modality,omitempty"`
+	TimeOfDay []matching.Period `json:"time_of_day,omitempty"`
+}
+
+// IntakeAnswerError is returned when an answer does not fit its question.
+type IntakeAnswerError struct {
+	Key     matching.Criterion
+	Message string
+}
+
+// Error implements the error interface.
+func (e *IntakeAnswerError) Error() string {
+	return fmt.Sprintf("invalid answer to %s: %s", e.Key, e.Message)
+}
+
+// answered reports whether the answers state a preference for the criterion.
+func (a *IntakeAnswers) answered(key matching.Criterion) bool {
+	switch key {
+	case matching.Concerns:
+		return len(a.Concerns) > 0
+	case matching.Gender:
+		return a.Gender != ""
+	case matching.Language:
+		return a.Language != ""
+	case matching.Budget:
+		return a.Budget != nil
+	case matching.Modality:
+		return a.Modality != ""
+	case matching.TimeOfDay:
+		return len(a.TimeOfDay) > 0
+	}
+
+	return false
+}
+
+// choices returns the single-choice and multiple-choice answers to the criterion.
+func (a *IntakeAnswers) choices(key matching.Criterion) []string {
+	switch key {
+	case matching.Gender:
+		return []string{a.Gender}
+	case matching.Language:
+		return []string{a.Language}
+	case matching.Modality:
+		return []string{a.Modality}
+	case matching.TimeOfDay:
+		periods := make([]string, len(a.TimeOfDay))
+		for i, p := range a.TimeOfDay {
+			periods[i] = string(p)
+		}
+		return periods
+	}
+
+	return nil
+}
+
+// normalizeIntakeChoice checks that the value is a valid answer to the criterion and returns it normalized.
+func normalizeIntakeChoice(key matching.Criterion, value string) (string, error) {
+	switch key {
+	case matching.Gender:
+		for _, gender := range PsychologistGenders {
+			if value == gender {
+				return value, nil
+			}
+		}
+		return "", errors.New("must be one of " + strings.Join(PsychologistGenders, ", "))
+	case matching.Language:
+		return NormalizeLanguageCode(value)
+	case matching.Modality:
+		if !Modality(value).IsValid() {
+			return "", ErrInvalidModality
+		}
+	case matching.TimeOfDay:
+		if !matching.Period(value).IsValid() {
+			return "", errors.New("must be morning, afternoon or evening")
+		}
+	}
+
+	return value, nil
+}
+
+// validate checks the answers against the active questions: every required question must be answered,
+// questions that are not asked must not be, and choices must be among the question's options.
+func (a *IntakeAnswers) validate(ctx context.Context, questions []IntakeQuestion) error {
+	asked := make(map[matching.Criterion]IntakeQuestion, len(questions))
+	for _, q := range questions {
+		asked[q.Key] = q
+	}
+
+	for _, key := range matching.Criteria {
+		q, ok := asked[key]
+		if !a.answered(key) {
+			if ok && q.Required {
+				return &IntakeAnswerError{Key: key, Message: "an answer is required"}
+			}
+			continue
+		}
+		if !ok {
+			return &IntakeAnswerError{Key: key, Message: "the question is not asked"}
+		}
+
+		if err := a.validateAnswer(ctx, q); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateAnswer checks and normalizes the answer to a single question.
+func (a *IntakeAnswers) validateAnswer(ctx context.Context, q IntakeQuestion) error {
+	switch q.Key {
+	case matching.Concerns:
+		count, err := db.GetConnection().WithContext(ctx).Model((*Specialization)(nil)).
+			Where("id IN (?)", pg.In(a.Concerns)).
+			Count()
+		if err != nil {
+			return err
+		}
+		if count != len(uniqueInts(a.Concerns)) {
+			return &IntakeAnswerError{Key: q.Key, Message: "unknown specialization"}
+		}
+		a.Concerns = uniqueInts(a.Concerns)
+
+		return nil
+	case matching.Budget:
+		if a.Budget.Amount <= 0 {
+			return &IntakeAnswerError{Key: q.Key, Message: "the amount must be positive"}
+		}
+		a.Budget.Currency = strings.ToUpper(strings.TrimSpace(a.Budget.Currency))
+		if a.Budget.Currency == "" {
+			return &IntakeAnswerError{Key: q.Key, Message: "the currency is required"}
+		}
+
+		return nil
+	}
+
+	choices := a.choices(q.Key)
+	for i, choice := range choices {
+		value, err := normalizeIntakeChoice(q.Key, choice)
+		if err != nil {
+			return &IntakeAnswerError{Key: q.Key, Message: err.Error()}
+		}
+		if len(q.Options) > 0 && !hasIntakeOption(q.Options, value) {
+			return &IntakeAnswerError{Key: q.Key, Message: fmt.Sprintf("%q is not one of the options", value)}
+		}
+		choices[i] = value
+	}
+
+	switch q.Key {
+	case matching.Gender:
+		a.Gender = choices[0]
+	case matching.Language:
+		a.Language = choices[0]
+	case matching.Modality:
+		a.Modality = choices[0]
+	}
+
+	return nil
+}
+
+// hasIntakeOption reports whether one of the options has the value.
+func hasIntakeOption(options []IntakeOption, value string) bool {
+	for _, option := range options {
+		if option.Value == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// uniqueInts returns the values without duplicates, keeping their order.
+func uniqueInts(values []int) []int {
+	seen := make(map[int]bool, len(values))
+	unique := make([]int, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+
+	return unique
+}
+
+// preferences converts the answers into matching preferences.
+func (a *IntakeAnswers) preferences() matching.Preferences {
+	return matching.Preferences{
+		Concerns: a.Concerns,
+		Gender:   a.Gender,
+		Language: a.Language,
+		Budget:   a.Budget,
+		Modality: a.Modality,
+		Periods:  a.TimeOfDay,
+	}
+}
+
+// IntakeResponse represents the intake_responses table in the database.
+// It records a filled in questionnaire. Responses from visitors who are not signed in have no customer.
+type IntakeResponse struct {
+	ID         int           `json:"id" binding:"-" pg:",pk"`
+	CustomerID *int          `json:"customer_id" binding:"-"`
+	Answers    IntakeAnswers `json:"answers" binding:"required" pg:",type:jsonb,notnull"`
+	CreatedBy  int           `json:"created_by" binding:"-" pg:",notnull"`
+	CreatedAt  time.Time     `json:"created_at" binding:"-" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the intake_responses table when INSERT query executes. It adds time in created_at column.
+func (r *IntakeResponse) BeforeInsert(ctx context.Context) (context.Context, error) {
+	r.CreatedAt = time.Now()
+	r.CreatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// Create validates the answers against the active questionnaire and stores the response.
+func (r *IntakeResponse) Create(ctx context.Context) error {
+	questions, err := GetIntakeQuestionnaire(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.Answers.validate(ctx, questions); err != nil {
+		return err
+	}
+
+	conn := db.GetConnection()
+	_, err = conn.WithContext(ctx).Model(r).Returning("*").Insert()
+
+	return err
+}
+
+// GetByID retrieves an intake response by its ID.
+func (r *IntakeResponse) GetByID(ctx context.Context) (*IntakeResponse, error) {
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(r).WherePK().Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// languageCodePattern matches ISO 639-1 and ISO 639-2 language codes.
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// ErrInvalidLanguageCode is returned when a language is not given as an ISO 639 code.
+var ErrInvalidLanguageCode = errors.New("languages must be ISO 639 codes, e.g. uk or en")
+
+// PsychologistLanguage represents the psychologist_languages table in the database.
+// It records a language a psychologist holds sessions in.
+type PsychologistLanguage struct {
+	ID             int       `json:"id" pg:",pk"`
+	PsychologistID int       `json:"psychologist_id" pg:",notnull"`
+	LanguageCode   string    `json:"language_code" pg:",notnull"`
+	CreatedBy      int       `json:"created_by" pg:",notnull"`
+	CreatedAt      time.Time `json:"created_at" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the psychologist_languages table when INSERT query executes. It adds time in created_at column.
+func (l *PsychologistLanguage) BeforeInsert(ctx context.Context) (context.Context, error) {
+	l.CreatedAt = time.Now()
+	l.CreatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// NormalizeLanguageCode lower-cases a language code and checks that it looks like an ISO 639 code.
+func NormalizeLanguageCode(code string) (string, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if !languageCodePattern.MatchString(code) {
+		return "", ErrInvalidLanguageCode
+	}
+
+	return code, nil
+}
+
+// GetLanguagesByPsychologist retrieves the language codes of a psychologist in alphabetical order.
+func GetLanguagesByPsychologist(ctx context.Context, psychologistID int) ([]string, error) {
+	conn := db.GetConnection()
+	var codes []string
+	err := conn.WithContext(ctx).Model((*PsychologistLanguage)(nil)).
+		Column("language_code").
+		Where("psychologist_id = ?", psychologistID).
+		Order("language_code").
+		Select(&codes)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// SetPsychologistLanguages replaces the languages of a psychologist with the given codes.
+func SetPsychologistLanguages(ctx context.Context, psychologistID int, codes []string) error {
+	languages := make([]PsychologistLanguage, 0, len(codes))
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		code, err := NormalizeLanguageCode(code)
+		if err != nil {
+			return err
+		}
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		languages = append(languages, PsychologistLanguage{PsychologistID: psychologistID, LanguageCode: code})
+	}
+
+	conn := db.GetConnection()
+
+	return conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		_, err := tx.ModelContext(ctx, (*PsychologistLanguage)(nil)).Where("psychologist_id = ?", psychologistID).Delete()
+		if err != nil || len(languages) == 0 {
+			return err
+		}
+
+		_, err = tx.ModelContext(ctx, &languages).Insert()
+
+		return err
+	})
+}
+
+// AddPsychologistLanguage adds a language to the ones a psychologist holds sessions in. Adding it again has no effect.
+func AddPsychologistLanguage(ctx context.Context, psychologistID int, code string) error {
+	code, err := NormalizeLanguageCode(code)
+	if err != nil {
+		return err
+	}
+
+	language := &PsychologistLanguage{PsychologistID: psychologistID, LanguageCode: code}
+
+	conn := db.GetConnection()
+	_, err = conn.WithContext(ctx).Model(language).OnConflict("(psychologist_id, language_code) DO NOTHING").Insert()
+
+	return err
+}
+
+// RemovePsychologistLanguage removes a language from the ones a psychologist holds sessions in.
+func RemovePsychologistLanguage(ctx context.Context, psychologistID int, code string) error {
+	code, err := NormalizeLanguageCode(code)
+	if err != nil {
+		return err
+	}
+
+	conn := db.GetConnection()
+	_, err = conn.WithContext(ctx).Model((*PsychologistLanguage)(nil)).
+		Where("psychologist_id = ? AND language_code = ?", psychologistID, code).
+		Delete()
+
+	return err
+}
@@ -0,0 +1,214 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// Page sizes used by list queries.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// MaxPage is the highest page number a list can be asked for, which keeps offsets from overflowing.
+const MaxPage = 10000
+
+// SortField is a field a list is ordered by.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ListParams describes which page of a list to return, its order and its filters.
+type ListParams struct {
+	// Page is the 1-based page number.
+	Page  int
+	Limit int
+	Sort  []SortField
+	// Filters holds the raw filter values keyed by filter name.
+	Filters map[string]string
+	// Location is the time zone dates without a time are interpreted in. Nil means UTC.
+	Location *time.Location
+}
+
+// ListParamError is returned when a list parameter names an unknown field or has an invalid value.
+type ListParamError struct {
+	Param   string
+	Message string
+}
+
+// Error implements the error interface.
+func (e *ListParamError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Param, e.Message)
+}
+
+// filterFunc narrows a list query down using the raw value of a filter.
+type filterFunc func(q *pg.Query, value string, loc *time.Location) error
+
+// listSpec lists the fields a list can be sorted and filtered by.
+type listSpec struct {
+	// sortable maps sort field names to their columns.
+	sortable    map[string]string
+	defaultSort []SortField
+	filters     map[string]filterFunc
+}
+
+// Offset returns the number of rows skipped before the page.
+func (p *ListParams) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// normalize fills in the defaults and clamps the page number and size.
+func (p *ListParams) normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Page > MaxPage {
+		p.Page = MaxPage
+	}
+	if p.Limit < 1 {
+		p.Limit = DefaultPageSize
+	}
+	if p.Limit > MaxPageSize {
+		p.Limit = MaxPageSize
+	}
+	if p.Location == nil {
+		p.Location = time.UTC
+	}
+}
+
+// newListQuery starts a list query on model.
+func newListQuery(ctx context.Context, model interface{}) *pg.Query {
+	conn := db.GetConnection()
+
+	return conn.WithContext(ctx).Model(model)
+}
+
+// selectPage applies the parameters to the query, selects the page and counts all matching rows.
+func selectPage(query *pg.Query, params *ListParams, spec listSpec) (int, error) {
+	params.normalize()
+
+	if err := params.apply(query, spec); err != nil {
+		return 0, err
+	}
+
+	return query.Limit(params.Limit).Offset(params.Offset()).SelectAndCount()
+}
+
+// apply adds the filters and order of the parameters to the query. Rows without a value for a sort field come last.
+func (p *ListParams) apply(query *pg.Query, spec listSpec) error {
+	for name, value := range p.Filters {
+		filter, ok := spec.filters[name]
+		if !ok {
+			return &ListParamError{Param: name, Message: "unknown filter"}
+		}
+		if err := filter(query, value, p.Location); err != nil {
+			return &ListParamError{Param: name, Message: err.Error()}
+		}
+	}
+
+	sorts := p.Sort
+	if len(sorts) == 0 {
+		sorts = spec.defaultSort
+	}
+	for _, sort := range sorts {
+		column, ok := spec.sortable[sort.Field]
+		if !ok {
+			return &ListParamError{Param: "sort", Message: fmt.Sprintf("cannot sort by %q", sort.Field)}
+		}
+
+		direction := "ASC"
+		if sort.Desc {
+			direction = "DESC"
+		}
+		query.OrderExpr("? "+direction+" NULLS LAST", pg.Ident(column))
+	}
+
+	// Break ties by ID so pages do not overlap.
+	query.Order("id")
+
+	return nil
+}
+
+// filterInt matches rows whose column equals the integer value.
+func filterInt(column string) filterFunc {
+	return func(q *pg.Query, value string, _ *time.Location) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		q.Where("? = ?", pg.Ident(column), n)
+
+		return nil
+	}
+}
+
+// filterString matches rows whose column equals the value, ignoring case.
+func filterString(column string) filterFunc {
+	return func(q *pg.Query, value string, _ *time.Location) error {
+		q.Where("lower(?) = lower(?)", pg.Ident(column), value)
+
+		return nil
+	}
+}
+
+// filterOneOf matches rows whose column equals any of the comma separated values.
+func filterOneOf(column string) filterFunc {
+	return func(q *pg.Query, value string, _ *time.Location) error {
+		values := strings.Split(value, ",")
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+		q.Where("? IN (?)", pg.Ident(column), pg.In(values))
+
+		return nil
+	}
+}
+
+// filterTimeFrom matches rows whose column is at or after the time.
+func filterTimeFrom(column string) filterFunc {
+	return func(q *pg.Query, value string, loc *time.Location) error {
+		t, err := parseListTime(value, loc)
+		if err != nil {
+			return err
+		}
+		q.Where("? >= ?", pg.Ident(column), t)
+
+		return nil
+	}
+}
+
+// filterTimeTo matches rows whose column is before the time.
+func filterTimeTo(column string) filterFunc {
+	return func(q *pg.Query, value string, loc *time.Location) error {
+		t, err := parseListTime(value, loc)
+		if err != nil {
+			return err
+		}
+		q.Where("? < ?", pg.Ident(column), t)
+
+		return nil
+	}
+}
+
+// parseListTime parses either a date ("2006-01-02"), taken as midnight in loc, or an RFC 3339 timestamp.
+func parseListTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be a date or an RFC 3339 timestamp")
+	}
+
+	return t, nil
+}
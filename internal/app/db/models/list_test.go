@@ -0,0 +1,34 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestListParamsOffset(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    ListParams
+		wantPage  int
+		wantLimit int
+		want      int
+	}{
+		{name: "defaults", params: ListParams{}, wantPage: 1, wantLimit: DefaultPageSize, want: 0},
+		{name: "third page", params: ListParams{Page: 3, Limit: 10}, wantPage: 3, wantLimit: 10, want: 20},
+		{name: "page size over the maximum", params: ListParams{Page: 2, Limit: 1000}, wantPage: 2, wantLimit: MaxPageSize, want: MaxPageSize},
+		{name: "page over the maximum", params: ListParams{Page: math.MaxInt, Limit: MaxPageSize}, wantPage: MaxPage, wantLimit: MaxPageSize, want: (MaxPage - 1) * MaxPageSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			params.normalize()
+			if params.Page != tt.wantPage || params.Limit != tt.wantLimit {
+				t.Errorf("normalize gives page %d of %d, want page %d of %d", params.Page, params.Limit, tt.wantPage, tt.wantLimit)
+			}
+			if got := params.Offset(); got != tt.want {
+				t.Errorf("Offset = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// Passwordless sign-in limits. They can be changed at startup.
+var (
+	// LoginChallengeTTL is how long a login link or code can be used.
+	LoginChallengeTTL = 15 * time.Minute
+	// LoginRequestWindow is the period the per email and per IP request limits count over.
+	LoginRequestWindow = time.Hour
+	// LoginRequestsPerEmail is how many links and codes can be requested for one email within the window.
+	LoginRequestsPerEmail = 5
+	// LoginRequestsPerIP is how many links and codes one IP address can request within the window.
+	LoginRequestsPerIP = 20
+	// LoginCodeAttempts is how many wrong codes are accepted before the code stops working.
+	LoginCodeAttempts = 5
+)
+
+// Errors returned by passwordless sign-in.
+var (
+	ErrInvalidLoginLink = errors.New("invalid or expired login link")
+	ErrInvalidLoginCode = errors.New("invalid or expired login code")
+)
+
+// LoginRateLimitError is returned when too many login links or codes were requested.
+type LoginRateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginRateLimitError) Error() string {
+	return "too many sign-in requests, try again later"
+}
+
+// LoginChallenge represents the login_challenges table in the database.
+// It is a single-use login link and code sent to a customer. Only hashes of the token and code are stored.
+// Requests for emails no customer has are recorded too, without a customer, so they count towards the limits.
+type LoginChallenge struct {
+	ID         int    `pg:",pk"`
+	Email      string `pg:",notnull"`
+	CustomerID *int
+	TokenHash  string    `pg:",unique,notnull"`
+	CodeHash   string    `pg:",notnull"`
+	Attempts   int       `pg:",notnull,use_zero"`
+	RequestIP  string    `pg:",notnull"`
+	ExpiresAt  time.Time `pg:",notnull"`
+	UsedAt     *time.Time
+	CreatedAt  time.Time `pg:",default:now()"`
+
+	// Token and Code are the plain link token and code, only known right after the challenge is created.
+	Token string `pg:"-"`
+	Code  string `pg:"-"`
+}
+
+// BeforeInsert is a method for performing additional changes to the login_challenges table when INSERT query executes. It adds time in created_at column.
+func (l *LoginChallenge) BeforeInsert(ctx context.Context) (context.Context, error) {
+	l.CreatedAt = time.Now()
+
+	return ctx, nil
+}
+
+// newLoginCode returns a random 6-digit code.
+func newLoginCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// RequestLogin creates a login link and code for the customer with the email. It returns a nil challenge,
+// and no error, when no customer has the email, so callers respond the same either way.
+// It returns a *LoginRateLimitError when the email or the IP address asked too often.
+func RequestLogin(ctx context.Context, email, ip string) (*LoginChallenge, error) {
+	email = NormalizeEmail(email)
+
+	if err := checkLoginRequestLimit(ctx, "email", email, LoginRequestsPerEmail); err != nil {
+		return nil, err
+	}
+	if err := checkLoginRequestLimit(ctx, "request_ip", ip, LoginRequestsPerIP); err != nil {
+		return nil, err
+	}
+
+	challenge := &LoginChallenge{Email: email, RequestIP: ip, ExpiresAt: time.Now().Add(LoginChallengeTTL)}
+
+	var err error
+	if challenge.Token, err = newToken(); err != nil {
+		return nil, err
+	}
+	if challenge.Code, err = newLoginCode(); err != nil {
+		return nil, err
+	}
+	challenge.TokenHash = hashToken(challenge.Token)
+	challenge.CodeHash = hashToken(challenge.Code)
+
+	customer, err := GetCustomerByEmail(ctx, email)
+	if err != nil && !errors.Is(err, pg.ErrNoRows) {
+		return nil, err
+	}
+	if customer != nil {
+		challenge.CustomerID = &customer.ID
+	}
+
+	conn := db.GetConnection()
+	if _, err := conn.WithContext(ctx).Model(challenge).Insert(); err != nil {
+		return nil, err
+	}
+
+	if customer == nil {
+		return nil, nil
+	}
+
+	return challenge, nil
+}
+
+// checkLoginRequestLimit returns a *LoginRateLimitError when the column already has limit requests
+// with the value within the request window.
+func checkLoginRequestLimit(ctx context.Context, column, value string, limit int) error {
+	since := time.Now().Add(-LoginRequestWindow)
+
+	var recent []time.Time
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model((*LoginChallenge)(nil)).
+		Column("created_at").
+		Where("? = ?", pg.Ident(column), value).
+		Where("created_at > ?", since).
+		Order("created_at DESC").
+		Limit(limit).
+		Select(&recent)
+	if err != nil {
+		return err
+	}
+	if len(recent) < limit {
+		return nil
+	}
+
+	// The oldest of the counted requests leaves the window first.
+	retryAfter := time.Until(recent[len(recent)-1].Add(LoginRequestWindow))
+
+	return &LoginRateLimitError{RetryAfter: max(retryAfter, time.Second)}
+}
+
+// LoginWithLink signs in with the token of a login link and returns the customer's account.
+func LoginWithLink(ctx context.Context, token string) (*User, error) {
+	var user *User
+
+	conn := db.GetConnection()
+	err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		var challenge LoginChallenge
+		err := tx.ModelContext(ctx, &challenge).
+			Where("token_hash = ?", hashToken(token)).
+			Where("customer_id IS NOT NULL").
+			For("UPDATE").
+			Select()
+		if errors.Is(err, pg.ErrNoRows) {
+			return ErrInvalidLoginLink
+		}
+		if err != nil {
+			return err
+		}
+		if !challenge.usable() {
+			return ErrInvalidLoginLink
+		}
+
+		user, err = completeLogin(ctx, tx, &challenge)
+
+		return err
+	})
+	if err != nil {
+		return nil, accountConflict(err)
+	}
+
+	return user, nil
+}
+
+// LoginWithCode signs in with the code last sent to the email and returns the customer's account.
+// Every wrong code counts against the challenge, which stops working after LoginCodeAttempts of them.
+func LoginWithCode(ctx context.Context, email, code string) (*User, error) {
+	var user *User
+	wrongCode := false
+
+	conn := db.GetConnection()
+	err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		var challenge LoginChallenge
+		err := tx.ModelContext(ctx, &challenge).
+			Where("email = ?", NormalizeEmail(email)).
+			Where("customer_id IS NOT NULL").
+			Order("created_at DESC").
+			Limit(1).
+			For("UPDATE").
+			Select()
+		if errors.Is(err, pg.ErrNoRows) {
+			return ErrInvalidLoginCode
+		}
+		if err != nil {
+			return err
+		}
+		if !challenge.usable() || challenge.Attempts >= LoginCodeAttempts {
+			return ErrInvalidLoginCode
+		}
+
+		if subtle.ConstantTimeCompare([]byte(hashToken(code)), []byte(challenge.CodeHash)) != 1 {
+			challenge.Attempts++
+			wrongCode = true
+			_, err := tx.ModelContext(ctx, &challenge).Column("attempts").WherePK().Update()
+			return err
+		}
+
+		user, err = completeLogin(ctx, tx, &challenge)
+
+		return err
+	})
+	if err != nil {
+		return nil, accountConflict(err)
+	}
+	if wrongCode {
+		return nil, ErrInvalidLoginCode
+	}
+
+	return user, nil
+}
+
+// usable reports whether the challenge has neither been used nor expired.
+func (l *LoginChallenge) usable() bool {
+	return l.UsedAt == nil && time.Now().Before(l.ExpiresAt)
+}
+
+// completeLogin uses up the challenge and returns the account of its customer, creating one without
+// a password for customers who never signed up.
+func completeLogin(ctx context.Context, tx orm.DB, challenge *LoginChallenge) (*User, error) {
+	now := time.Now()
+	challenge.UsedAt = &now
+	if _, err := tx.ModelContext(ctx, challenge).Column("used_at").WherePK().Update(); err != nil {
+		return nil, err
+	}
+
+	var user User
+	err := tx.ModelContext(ctx, &user).Where("customer_id = ?", *challenge.CustomerID).Select()
+	if errors.Is(err, pg.ErrNoRows) {
+		user = User{Email: challenge.Email, Role: auth.RoleCustomer, CustomerID: challenge.CustomerID}
+		err = user.insert(ctx, tx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user.LastLoginAt = &now
+	if _, err := tx.ModelContext(ctx, &user).Column("last_login_at").WherePK().Update(); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// Modality is a way a session can be held.
+type Modality string
+
+// Session modalities.
+const (
+	ModalityOnline   Modality = "online"
+	ModalityInPerson Modality = "in_person"
+)
+
+// Errors returned when the modality or office of a session is not consistent.
+var (
+	ErrInvalidModality          = errors.New("modality must be either online or in_person")
+	ErrModalityNotOffered       = errors.New("the psychologist does not offer sessions in this modality")
+	ErrOfficeLocationRequired   = errors.New("office_location_id is required for in_person sessions")
+	ErrOfficeLocationNotAllowed = errors.New("office_location_id can only be set for in_person sessions")
+	ErrUnknownOfficeLocation    = errors.New("the office location does not belong to the psychologist")
+)
+
+// PsychologistModality represents the psychologist_modalities table in the database.
+// It records a modality a psychologist offers sessions in.
+type PsychologistModality struct {
+	ID             int       `json:"id" pg:",pk"`
+	PsychologistID int       `json:"psychologist_id" pg:",notnull"`
+	Modality       Modality  `json:"modality" pg:",notnull"`
+	CreatedBy      int       `json:"created_by" pg:",notnull"`
+	CreatedAt      time.Time `json:"created_at" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the psychologist_modalities table when INSERT query executes. It adds time in created_at column.
+func (m *PsychologistModality) BeforeInsert(ctx context.Context) (context.Context, error) {
+	m.CreatedAt = time.Now()
+	m.CreatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// IsValid reports whether the modality is one of the known modalities.
+func (m Modality) IsValid() bool {
+	return m == ModalityOnline || m == ModalityInPerson
+}
+
+// GetModalitiesByPsychologist retrieves the modalities a psychologist offers in alphabetical order.
+func GetModalitiesByPsychologist(ctx context.Context, psychologistID int) ([]Modality, error) {
+	conn := db.GetConnection()
+	var modalities []Modality
+	err := conn.WithContext(ctx).Model((*PsychologistModality)(nil)).
+		Column("modality").
+		Where("psychologist_id = ?", psychologistID).
+		Order("modality").
+		Select(&modalities)
+	if err != nil {
+		return nil, err
+	}
+
+	return modalities, nil
+}
+
+// SetPsychologistModalities replaces the modalities a psychologist offers.
+func SetPsychologistModalities(ctx context.Context, psychologistID int, modalities []Modality) error {
+	links := make([]PsychologistModality, 0, len(modalities))
+	seen := make(map[Modality]bool, len(modalities))
+	for _, modality := range modalities {
+		if !modality.IsValid() {
+			return ErrInvalidModality
+		}
+		if seen[modality] {
+			continue
+		}
+		seen[modality] = true
+		links = append(links, PsychologistModality{PsychologistID: psychologistID, Modality: modality})
+	}
+
+	conn := db.GetConnection()
+
+	return conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		_, err := tx.ModelContext(ctx, (*PsychologistModality)(nil)).Where("psychologist_id = ?", psychologistID).Delete()
+		if err != nil || len(links) == 0 {
+			return err
+		}
+
+		_, err = tx.ModelContext(ctx, &links).Insert()
+
+		return err
+	})
+}
+
+// AddPsychologistModality adds a modality to the ones a psychologist offers. Adding it again has no effect.
+func AddPsychologistModality(ctx context.Context, psychologistID int, modality Modality) error {
+	if !modality.IsValid() {
+		return ErrInvalidModality
+	}
+
+	link := &PsychologistModality{PsychologistID: psychologistID, Modality: modality}
+
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(link).OnConflict("(psychologist_id, modality) DO NOTHING").Insert()
+
+	return err
+}
+
+// RemovePsychologistModality stops a psychologist from offering a modality.
+// Appointments already booked in that modality are kept.
+func RemovePsychologistModality(ctx context.Context, psychologistID int, modality Modality) error {
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model((*PsychologistModality)(nil)).
+		Where("psychologist_id = ? AND modality = ?", psychologistID, modality).
+		Delete()
+
+	return err
+}
+
+// checkSessionFormat ensures the psychologist offers the modality and that in-person sessions, and only those,
+// take place at one of the psychologist's offices.
+func checkSessionFormat(ctx context.Context, conn orm.DB, psychologistID int, modality Modality, officeLocationID int) error {
+	if !modality.IsValid() {
+		return ErrInvalidModality
+	}
+
+	switch {
+	case modality == ModalityInPerson && officeLocationID == 0:
+		return ErrOfficeLocationRequired
+	case modality != ModalityInPerson && officeLocationID != 0:
+		return ErrOfficeLocationNotAllowed
+	}
+
+	offered, err := conn.ModelContext(ctx, (*PsychologistModality)(nil)).
+		Where("psychologist_id = ? AND modality = ?", psychologistID, modality).
+		Exists()
+	if err != nil {
+		return err
+	}
+	if !offered {
+		return ErrModalityNotOffered
+	}
+
+	if officeLocationID != 0 {
+		owned, err := conn.ModelContext(ctx, (*OfficeLocation)(nil)).
+			Where("id = ? AND psychologist_id = ?", officeLocationID, psychologistID).
+			Exists()
+		if err != nil {
+			return err
+		}
+		if !owned {
+			return ErrUnknownOfficeLocation
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// foreignKeyViolation is the PostgreSQL error code raised when a row is still referenced by another table.
+const foreignKeyViolation = "23503"
+
+// ErrOfficeLocationInUse is returned when an office location that appointments take place at is deleted.
+var ErrOfficeLocationInUse = errors.New("the office location is used by appointments")
+
+// OfficeLocation represents the office_locations table in the database.
+// It is an address where a psychologist holds in-person sessions.
+type OfficeLocation struct {
+	ID             int       `json:"id" binding:"-" pg:",pk"`
+	PsychologistID int       `json:"psychologist_id" binding:"required" pg:",notnull"`
+	Label          string    `json:"label" binding:"max=100"`
+	AddressLine1   string    `json:"address_line1" binding:"required,max=200" pg:"address_line1,notnull"`
+	AddressLine2   string    `json:"address_line2" binding:"max=200" pg:"address_line2"`
+	City           string    `json:"city" binding:"required,max=100" pg:",notnull"`
+	PostalCode     string    `json:"postal_code" binding:"max=20"`
+	Country        string    `json:"country" binding:"required,iso3166_1_alpha2" pg:",notnull"`
+	CreatedBy      int       `json:"created_by" binding:"-" pg:",notnull"`
+	UpdatedBy      int       `json:"updated_by" binding:"-" pg:",notnull"`
+	CreatedAt      time.Time `json:"created_at" binding:"-" pg:",default:now()"`
+	UpdatedAt      time.Time `json:"updated_at" binding:"-" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the office_locations table when INSERT query executes. It adds time in created_at and updated_at columns.
+func (o *OfficeLocation) BeforeInsert(ctx context.Context) (context.Context, error) {
+	o.CreatedAt = time.Now()
+	o.UpdatedAt = o.CreatedAt
+	o.CreatedBy = actingUserID(ctx)
+	o.UpdatedBy = o.CreatedBy
+
+	return ctx, nil
+}
+
+// BeforeUpdate is a method for performing additional changes to the office_locations table when UPDATE query executes. It updates time in updated_at column.
+func (o *OfficeLocation) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	o.UpdatedAt = time.Now()
+	o.UpdatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// officeLocationListSpec lists the fields office location lists can be sorted and filtered by.
+var officeLocationListSpec = listSpec{
+	sortable: map[string]string{
+		"id":         "id",
+		"city":       "city",
+		"country":    "country",
+		"created_at": "created_at",
+	},
+	defaultSort: []SortField{{Field: "id"}},
+	filters: map[string]filterFunc{
+		"psychologist": filterInt("psychologist_id"),
+		"city":         filterString("city"),
+		"country":      filterString("country"),
+	},
+}
+
+// ListOfficeLocations retrieves a page of office locations and the number of office locations matching the filters.
+func ListOfficeLocations(ctx context.Context, params *ListParams) ([]OfficeLocation, int, error) {
+	var locations []OfficeLocation
+	total, err := selectPage(newListQuery(ctx, &locations), params, officeLocationListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return locations, total, nil
+}
+
+// GetOfficeLocationsByPsychologist retrieves the office locations of a psychologist.
+func GetOfficeLocationsByPsychologist(ctx context.Context, psychologistID int) ([]OfficeLocation, error) {
+	conn := db.GetConnection()
+	var locations []OfficeLocation
+	err := conn.WithContext(ctx).Model(&locations).Where("psychologist_id = ?", psychologistID).Order("id").Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return locations, nil
+}
+
+// GetByID retrieves an office location by its ID.
+func (o *OfficeLocation) GetByID(ctx context.Context) (*OfficeLocation, error) {
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(o).WherePK().Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// Create inserts a new office location into the database.
+func (o *OfficeLocation) Create(ctx context.Context) error {
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(o).Returning("*").Insert()
+
+	return err
+}
+
+// Update modifies an existing office location. It cannot be moved to another psychologist.
+func (o *OfficeLocation) Update(ctx context.Context) error {
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(o).ExcludeColumn("psychologist_id").WherePK().Returning("psychologist_id").Update()
+
+	return err
+}
+
+// DeleteByID removes an office location from the database by its ID.
+// Office locations that appointments take place at cannot be deleted.
+func (o *OfficeLocation) DeleteByID(ctx context.Context) error {
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(o).WherePK().Delete()
+
+	var pgErr pg.Error
+	if errors.As(err, &pgErr) && pgErr.Field('C') == foreignKeyViolation {
+		return ErrOfficeLocationInUse
+	}
+
+	return err
+}
@@ -0,0 +1,249 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// OnboardingStatus is a state in the onboarding of a psychologist. Only verified psychologists are listed publicly.
+type OnboardingStatus string
+
+// Onboarding states. A rejected submission goes back to draft with the reason recorded.
+const (
+	OnboardingDraft     OnboardingStatus = "draft"
+	OnboardingSubmitted OnboardingStatus = "submitted"
+	OnboardingVerified  OnboardingStatus = "verified"
+	OnboardingSuspended OnboardingStatus = "suspended"
+)
+
+// onboardingTransitions lists the states each onboarding state may move to.
+var onboardingTransitions = map[OnboardingStatus][]OnboardingStatus{
+	OnboardingDraft:     {OnboardingSubmitted},
+	OnboardingSubmitted: {OnboardingVerified, OnboardingDraft},
+	OnboardingVerified:  {OnboardingSuspended},
+	OnboardingSuspended: {OnboardingVerified},
+}
+
+// Errors returned by onboarding operations.
+var (
+	ErrLicenseIncomplete = errors.New("license_number, license_issuer and at least one credential document are required before submitting")
+	ErrReasonRequired    = errors.New("a reason is required")
+	ErrOnboardingLocked  = errors.New("license details and credential documents can only be changed while the profile is a draft")
+)
+
+// OnboardingTransitionError is returned when a psychologist cannot move to the requested onboarding state.
+type OnboardingTransitionError struct {
+	From    OnboardingStatus
+	To      OnboardingStatus
+	Allowed []OnboardingStatus
+}
+
+// Error implements the error interface.
+func (e *OnboardingTransitionError) Error() string {
+	return fmt.Sprintf("profile cannot move from %q to %q", e.From, e.To)
+}
+
+// OnboardingChange represents the psychologist_onboarding_history table in the database.
+type OnboardingChange struct {
+	tableName struct{} `pg:"psychologist_onboarding_history"`
+
+	ID             int              `json:"id" pg:",pk"`
+	PsychologistID int              `json:"psychologist_id" pg:",notnull"`
+	FromStatus     OnboardingStatus `json:"from_status" pg:",notnull"`
+	ToStatus       OnboardingStatus `json:"to_status" pg:",notnull"`
+	Reason         string           `json:"reason"`
+	ChangedBy      int              `json:"changed_by"`
+	CreatedAt      time.Time        `json:"created_at" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the psychologist_onboarding_history table when INSERT query executes. It adds time in created_at column.
+func (o *OnboardingChange) BeforeInsert(ctx context.Context) (context.Context, error) {
+	o.CreatedAt = time.Now()
+	o.ChangedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// AllowedTransitions returns the states a psychologist may move to from s.
+func (s OnboardingStatus) AllowedTransitions() []OnboardingStatus {
+	allowed := onboardingTransitions[s]
+	if allowed == nil {
+		return []OnboardingStatus{}
+	}
+
+	return allowed
+}
+
+// CanTransitionTo reports whether a psychologist may move from s to the given state.
+func (s OnboardingStatus) CanTransitionTo(to OnboardingStatus) bool {
+	for _, allowed := range onboardingTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetLicense records the psychologist's license while their profile is a draft.
+func (p *Psychologist) SetLicense(ctx context.Context, number, issuer string) error {
+	conn := db.GetConnection()
+
+	return conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := tx.ModelContext(ctx, p).WherePK().For("UPDATE").Select(); err != nil {
+			return err
+		}
+		if p.OnboardingStatus != OnboardingDraft {
+			return ErrOnboardingLocked
+		}
+
+		p.LicenseNumber = strings.TrimSpace(number)
+		p.LicenseIssuer = strings.TrimSpace(issuer)
+		_, err := tx.ModelContext(ctx, p).Column("license_number", "license_issuer", "updated_at", "updated_by").WherePK().Update()
+
+		return err
+	})
+}
+
+// Submit sends the draft profile to the admin review queue. The license and at least one credential
+// document must be provided first.
+func (p *Psychologist) Submit(ctx context.Context) error {
+	return p.transitionOnboarding(ctx, OnboardingSubmitted, "", func(tx *pg.Tx) error {
+		if p.LicenseNumber == "" || p.LicenseIssuer == "" {
+			return ErrLicenseIncomplete
+		}
+
+		documents, err := tx.ModelContext(ctx, (*CredentialDocument)(nil)).Where("psychologist_id = ?", p.ID).Exists()
+		if err != nil {
+			return err
+		}
+		if !documents {
+			return ErrLicenseIncomplete
+		}
+
+		now := time.Now()
+		p.SubmittedAt = &now
+		p.StatusReason = ""
+
+		return nil
+	})
+}
+
+// Approve verifies a submitted profile, which lists the psychologist in the public directory.
+func (p *Psychologist) Approve(ctx context.Context) error {
+	return p.transitionOnboarding(ctx, OnboardingVerified, "", func(*pg.Tx) error {
+		now := time.Now()
+		p.VerifiedAt = &now
+		p.StatusReason = ""
+
+		return nil
+	})
+}
+
+// Reject sends a submitted profile back to draft with the reason it was not accepted.
+func (p *Psychologist) Reject(ctx context.Context, reason string) error {
+	return p.transitionOnboarding(ctx, OnboardingDraft, reason, nil)
+}
+
+// Suspend removes a verified psychologist from the public directory.
+func (p *Psychologist) Suspend(ctx context.Context, reason string) error {
+	return p.transitionOnboarding(ctx, OnboardingSuspended, reason, nil)
+}
+
+// Reinstate lists a suspended psychologist in the public directory again.
+func (p *Psychologist) Reinstate(ctx context.Context) error {
+	return p.transitionOnboarding(ctx, OnboardingVerified, "", func(*pg.Tx) error {
+		p.StatusReason = ""
+
+		return nil
+	})
+}
+
+// transitionOnboarding moves the psychologist to the given onboarding state and records the change.
+// Moving back to draft or to suspended requires a reason. prepare runs after the state is checked and
+// may reject the change or fill in the dates that go with the new state.
+func (p *Psychologist) transitionOnboarding(ctx context.Context, to OnboardingStatus, reason string, prepare func(tx *pg.Tx) error) error {
+	reason = strings.TrimSpace(reason)
+	if (to == OnboardingDraft || to == OnboardingSuspended) && reason == "" {
+		return ErrReasonRequired
+	}
+
+	conn := db.GetConnection()
+
+	return conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := tx.ModelContext(ctx, p).WherePK().For("UPDATE").Select(); err != nil {
+			return err
+		}
+
+		from := p.OnboardingStatus
+		if !from.CanTransitionTo(to) {
+			return &OnboardingTransitionError{From: from, To: to, Allowed: from.AllowedTransitions()}
+		}
+
+		if reason != "" {
+			p.StatusReason = reason
+		}
+		if prepare != nil {
+			if err := prepare(tx); err != nil {
+				return err
+			}
+		}
+
+		p.OnboardingStatus = to
+		_, err := tx.ModelContext(ctx, p).
+			Column("onboarding_status", "submitted_at", "verified_at", "status_reason", "updated_at", "updated_by").
+			WherePK().
+			Update()
+		if err != nil {
+			return err
+		}
+
+		change := &OnboardingChange{PsychologistID: p.ID, FromStatus: from, ToStatus: to, Reason: reason}
+		_, err = tx.ModelContext(ctx, change).Insert()
+
+		return err
+	})
+}
+
+// onboardingQueueSpec lists the fields the review queue can be sorted by. Oldest submissions come first.
+var onboardingQueueSpec = listSpec{
+	sortable: map[string]string{
+		"id":           "id",
+		"submitted_at": "submitted_at",
+		"last_name":    "last_name",
+	},
+	defaultSort: []SortField{{Field: "submitted_at"}},
+	filters:     map[string]filterFunc{},
+}
+
+// ListOnboardingQueue retrieves a page of profiles waiting for review and the number of such profiles.
+func ListOnboardingQueue(ctx context.Context, params *ListParams) ([]Psychologist, int, error) {
+	var psychologists []Psychologist
+	query := newListQuery(ctx, &psychologists).Where("onboarding_status = ?", OnboardingSubmitted)
+
+	total, err := selectPage(query, params, onboardingQueueSpec)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return psychologists, total, nil
+}
+
+// GetOnboardingHistory retrieves the onboarding changes of a psychologist in chronological order.
+func GetOnboardingHistory(ctx context.Context, psychologistID int) ([]OnboardingChange, error) {
+	conn := db.GetConnection()
+	var history []OnboardingChange
+	err := conn.WithContext(ctx).Model(&history).Where("psychologist_id = ?", psychologistID).Order("created_at", "id").Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
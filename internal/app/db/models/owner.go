@@ -0,0 +1,81 @@
+package models
+
+import (
+	"context"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// Owner is the psychologist and the customer a record belongs to.
+// Records that only belong to a psychologist leave CustomerID zero.
+type Owner struct {
+	PsychologistID int
+	CustomerID     int
+}
+
+// ownerOf selects the owner columns of the record of the model's table with the ID.
+// It returns pg.ErrNoRows when there is no such record.
+func ownerOf(ctx context.Context, model interface{}, id int, withCustomer bool) (Owner, error) {
+	var owner Owner
+
+	conn := db.GetConnection()
+	query := conn.WithContext(ctx).Model(model).Column("psychologist_id").Where("id = ?", id)
+	if !withCustomer {
+		err := query.Select(&owner.PsychologistID)
+		return owner, err
+	}
+
+	err := query.Column("customer_id").Select(&owner.PsychologistID, &owner.CustomerID)
+
+	return owner, err
+}
+
+// AvailabilityOwner returns the owner of the weekly availability with the ID.
+func AvailabilityOwner(ctx context.Context, id int) (Owner, error) {
+	return ownerOf(ctx, (*Availability)(nil), id, false)
+}
+
+// AvailabilityExceptionOwner returns the owner of the availability exception with the ID.
+func AvailabilityExceptionOwner(ctx context.Context, id int) (Owner, error) {
+	return ownerOf(ctx, (*AvailabilityException)(nil), id, false)
+}
+
+// OfficeLocationOwner returns the owner of the office location with the ID.
+func OfficeLocationOwner(ctx context.Context, id int) (Owner, error) {
+	return ownerOf(ctx, (*OfficeLocation)(nil), id, false)
+}
+
+// ConsultationPricingOwner returns the owner of the consultation price with the ID.
+func ConsultationPricingOwner(ctx context.Context, id int) (Owner, error) {
+	return ownerOf(ctx, (*ConsultationPricing)(nil), id, false)
+}
+
+// CalendarImportOwner returns the owner of the calendar import with the ID.
+func CalendarImportOwner(ctx context.Context, id int) (Owner, error) {
+	return ownerOf(ctx, (*CalendarImport)(nil), id, false)
+}
+
+// AppointmentOwner returns the psychologist and customer of the appointment with the ID.
+func AppointmentOwner(ctx context.Context, id int) (Owner, error) {
+	return ownerOf(ctx, (*Appointment)(nil), id, true)
+}
+
+// AppointmentSeriesOwner returns the psychologist and customer of the appointment series with the ID.
+func AppointmentSeriesOwner(ctx context.Context, id int) (Owner, error) {
+	return ownerOf(ctx, (*AppointmentSeries)(nil), id, true)
+}
+
+// ReviewOwner returns the reviewed psychologist and the customer who wrote the review with the ID.
+func ReviewOwner(ctx context.Context, id int) (Owner, error) {
+	return ownerOf(ctx, (*Review)(nil), id, true)
+}
+
+// WaitlistEntryOwner returns the psychologist and customer of the waitlist entry with the ID.
+func WaitlistEntryOwner(ctx context.Context, id int) (Owner, error) {
+	return ownerOf(ctx, (*WaitlistEntry)(nil), id, true)
+}
+
+// WaitlistOfferOwner returns the psychologist and customer of the waitlist offer with the ID.
+func WaitlistOfferOwner(ctx context.Context, id int) (Owner, error) {
+	return ownerOf(ctx, (*WaitlistOffer)(nil), id, true)
+}
@@ -2,8 +2,13 @@ package models
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+
 	"github.com/vitalicher97/psychologist_app/internal/app/db"
 )
 
@@ -15,16 +20,52 @@ type Psychologist struct {
 	Email          string    `json:"email" binding:"required,email" pg:",unique,notnull"`
 	ProfilePicture string    `json:"profile_picture" binding:"-"`
 	Bio            string    `json:"bio" binding:"-"`
+	TimeZone       string    `json:"time_zone" binding:"-" pg:",notnull"`
+	Gender         string    `json:"gender" binding:"omitempty,oneof=female male non_binary"`
 	CreatedBy      int       `json:"created_by" binding:"-" pg:",notnull"`
 	UpdatedBy      int       `json:"updated_by" binding:"-" pg:",notnull"`
 	CreatedAt      time.Time `json:"created_at" binding:"-" pg:",default:now()"`
 	UpdatedAt      time.Time `json:"updated_at" binding:"-" pg:",default:now()"`
+
+	// AverageRating and ReviewCount summarise the visible reviews; the database keeps them up to date.
+	AverageRating *float64 `json:"average_rating" binding:"-"`
+	ReviewCount   int      `json:"review_count" binding:"-" pg:",use_zero"`
+
+	// The onboarding fields only change through the onboarding workflow. StatusReason is why the
+	// profile was last rejected or suspended.
+	OnboardingStatus OnboardingStatus `json:"onboarding_status" binding:"-" pg:",notnull"`
+	LicenseNumber    string           `json:"license_number" binding:"-"`
+	LicenseIssuer    string           `json:"license_issuer" binding:"-"`
+	SubmittedAt      *time.Time       `json:"submitted_at" binding:"-"`
+	VerifiedAt       *time.Time       `json:"verified_at" binding:"-"`
+	StatusReason     string           `json:"status_reason" binding:"-"`
+
+	// Specializations, Languages, Modalities and OfficeLocations are only filled in when requested
+	// through the matching Load method.
+	Specializations []Specialization `json:"specializations,omitempty" binding:"-" pg:"-"`
+	Languages       []string         `json:"languages,omitempty" binding:"-" pg:"-"`
+	Modalities      []Modality       `json:"modalities,omitempty" binding:"-" pg:"-"`
+	OfficeLocations []OfficeLocation `json:"office_locations,omitempty" binding:"-" pg:"-"`
+}
+
+// managedPsychologistColumns are the columns Update leaves alone because other operations maintain them.
+var managedPsychologistColumns = []string{
+	"profile_picture", "average_rating", "review_count",
+	"onboarding_status", "license_number", "license_issuer", "submitted_at", "verified_at", "status_reason",
 }
 
+// ErrInvalidTimeZone is returned when a psychologist's time zone is not a known IANA zone name.
+var ErrInvalidTimeZone = errors.New("time_zone must be an IANA time zone name, e.g. Europe/Kyiv")
+
+// DefaultTimeZone is used for psychologists that have not set their time zone.
+const DefaultTimeZone = "UTC"
+
 // BeforeInsert is a method for performing additional changes to the psychologists table when INSERT query executes. It add time in created_at and updated_at column
 func (p *Psychologist) BeforeInsert(ctx context.Context) (context.Context, error) {
 	p.CreatedAt = time.Now()
 	p.UpdatedAt = p.CreatedAt
+	p.CreatedBy = actingUserID(ctx)
+	p.UpdatedBy = p.CreatedBy
 
 	return ctx, nil
 }
@@ -32,20 +73,95 @@ func (p *Psychologist) BeforeInsert(ctx context.Context) (context.Context, error
 // BeforeUpdate is a method for performing additional changes to the psychologists table when UPDATE query executes. It updates time in updated_at column
 func (p *Psychologist) BeforeUpdate(ctx context.Context) (context.Context, error) {
 	p.UpdatedAt = time.Now()
+	p.UpdatedBy = actingUserID(ctx)
 
 	return ctx, nil
 }
 
-// List retrieves all psychologists.
-func GetAllPsychologists(ctx context.Context) ([]Psychologist, error) {
-	conn := db.GetConnection()
-	var psychologists []Psychologist
-	err := conn.WithContext(ctx).Model(&psychologists).Order("id").Select()
+// psychologistListSpec lists the fields psychologist lists can be sorted and filtered by.
+// The language, modality, city and country filters match psychologists with at least one of the comma separated values.
+var psychologistListSpec = listSpec{
+	sortable: map[string]string{
+		"id":           "id",
+		"first_name":   "first_name",
+		"last_name":    "last_name",
+		"email":        "email",
+		"rating":       "average_rating",
+		"review_count": "review_count",
+		"created_at":   "created_at",
+	},
+	defaultSort: []SortField{{Field: "id"}},
+	filters: map[string]filterFunc{
+		"email":     filterString("email"),
+		"time_zone": filterString("time_zone"),
+		"status":    filterOneOf("onboarding_status"),
+		"gender":    filterOneOf("gender"),
+		"language":  filterRelated("psychologist_languages", "language_code"),
+		"modality":  filterRelated("psychologist_modalities", "modality"),
+		"city":      filterRelated("office_locations", "city"),
+		"country":   filterRelated("office_locations", "country"),
+	},
+}
+
+// filterRelated matches psychologists with a row in the related table whose column equals any of the
+// comma separated values, ignoring case.
+func filterRelated(table, column string) filterFunc {
+	return func(q *pg.Query, value string, _ *time.Location) error {
+		values := strings.Split(value, ",")
+		for i := range values {
+			values[i] = strings.ToLower(strings.TrimSpace(values[i]))
+		}
+		q.Where("EXISTS (SELECT 1 FROM ? AS related WHERE related.psychologist_id = psychologist.id AND lower(related.?) IN (?))",
+			pg.Ident(table), pg.Ident(column), pg.In(values))
+
+		return nil
+	}
+}
+
+// PsychologistListItem is a psychologist in a list, with its rank and snippet when the list is a text search.
+type PsychologistListItem struct {
+	tableName struct{} `pg:"psychologists,alias:psychologist,discard_unknown_columns"`
+
+	Psychologist
+	SearchRank float64 `json:"search_rank,omitempty"`
+	// Snippet is an HTML excerpt of the bio, escaped, with the matched words wrapped in <mark> tags.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// ListPsychologists retrieves a page of psychologists and the number of psychologists matching the filters.
+// A text search narrows the list down to matching names and bios, ranked by relevance unless another order is requested.
+func ListPsychologists(ctx context.Context, params *ListParams, text TextSearch) ([]PsychologistListItem, int, error) {
+	if err := text.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	var psychologists []PsychologistListItem
+	query := newListQuery(ctx, &psychologists).ColumnExpr("psychologist.*")
+
+	spec := psychologistListSpec
+	if text.Query != "" {
+		text.apply(query)
+		if len(params.Sort) == 0 {
+			params.Sort = []SortField{{Field: "relevance", Desc: true}}
+		}
+		spec.sortable = map[string]string{"relevance": "search_rank"}
+		for field, column := range psychologistListSpec.sortable {
+			spec.sortable[field] = column
+		}
+	}
+
+	total, err := selectPage(query, params, spec)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return psychologists, nil
+	for i := range psychologists {
+		if psychologists[i].Snippet != "" {
+			psychologists[i].Snippet = highlightSnippet(psychologists[i].Snippet)
+		}
+	}
+
+	return psychologists, total, nil
 }
 
 // GetByID retrieves a psychologist by their ID.
@@ -59,22 +175,152 @@ func (p *Psychologist) GetByID(ctx context.Context) (*Psychologist, error) {
 	return p, nil
 }
 
+// LoadSpecializations fills in the psychologist's specializations.
+func (p *Psychologist) LoadSpecializations(ctx context.Context) error {
+	specializations, err := GetSpecializationsByPsychologist(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	p.Specializations = specializations
+
+	return nil
+}
+
+// LoadLanguages fills in the languages the psychologist holds sessions in.
+func (p *Psychologist) LoadLanguages(ctx context.Context) error {
+	languages, err := GetLanguagesByPsychologist(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	p.Languages = languages
+
+	return nil
+}
+
+// LoadModalities fills in the modalities the psychologist offers.
+func (p *Psychologist) LoadModalities(ctx context.Context) error {
+	modalities, err := GetModalitiesByPsychologist(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	p.Modalities = modalities
+
+	return nil
+}
+
+// LoadOfficeLocations fills in the psychologist's office locations.
+func (p *Psychologist) LoadOfficeLocations(ctx context.Context) error {
+	locations, err := GetOfficeLocationsByPsychologist(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	p.OfficeLocations = locations
+
+	return nil
+}
+
+// Location returns the time zone the psychologist's availability is expressed in.
+// "Local" is rejected: it names the server's zone, so slots would shift between deployments.
+func (p *Psychologist) Location() (*time.Location, error) {
+	if p.TimeZone == "" {
+		return time.UTC, nil
+	}
+	if p.TimeZone == "Local" {
+		return nil, ErrInvalidTimeZone
+	}
+
+	loc, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+
+	return loc, nil
+}
+
+// validate fills in the default time zone and checks that the time zone is known.
+func (p *Psychologist) validate() error {
+	if p.TimeZone == "" {
+		p.TimeZone = DefaultTimeZone
+	}
+
+	_, err := p.Location()
+
+	return err
+}
+
 // Create inserts a new psychologist into the database.
 func (p *Psychologist) Create(ctx context.Context) error {
-	conn := db.GetConnection()
-	_, err := conn.WithContext(ctx).Model(p).Returning("*").Insert()
+	return p.insert(ctx, db.GetConnection())
+}
+
+// insert stores a new psychologist as a draft profile using the given connection or transaction.
+func (p *Psychologist) insert(ctx context.Context, conn orm.DB) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	p.AverageRating = nil
+	p.ReviewCount = 0
+	p.OnboardingStatus = OnboardingDraft
+	p.LicenseNumber = ""
+	p.LicenseIssuer = ""
+	p.SubmittedAt = nil
+	p.VerifiedAt = nil
+	p.StatusReason = ""
+
+	_, err := conn.ModelContext(ctx, p).Returning("*").Insert()
 
 	return err
 }
 
 // Update modifies an existing psychologist's data.
+// The managed columns are left untouched: the profile picture only changes through SetProfilePicture,
+// the onboarding fields through the onboarding workflow, and the rating summary is maintained by the database.
 func (p *Psychologist) Update(ctx context.Context) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+
 	conn := db.GetConnection()
-	_, err := conn.WithContext(ctx).Model(p).WherePK().Update()
+	_, err := conn.WithContext(ctx).Model(p).
+		ExcludeColumn(managedPsychologistColumns...).
+		WherePK().
+		Returning(strings.Join(managedPsychologistColumns, ", ")).
+		Update()
 
 	return err
 }
 
+// SetProfilePicture replaces the psychologist's profile picture and returns the previous one.
+func (p *Psychologist) SetProfilePicture(ctx context.Context, picture string) (string, error) {
+	var previous string
+
+	conn := db.GetConnection()
+	err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := tx.ModelContext(ctx, p).WherePK().For("UPDATE").Select(); err != nil {
+			return err
+		}
+
+		previous = p.ProfilePicture
+		p.ProfilePicture = picture
+		_, err := tx.ModelContext(ctx, p).Column("profile_picture", "updated_at", "updated_by").WherePK().Update()
+
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return previous, nil
+}
+
+// IsProfilePictureInUse reports whether any psychologist still uses the picture.
+func IsProfilePictureInUse(ctx context.Context, picture string) (bool, error) {
+	conn := db.GetConnection()
+
+	return conn.WithContext(ctx).Model((*Psychologist)(nil)).Where("profile_picture = ?", picture).Exists()
+}
+
 // Delete removes a psychologist from the database by their ID.
 func (p *Psychologist) DeleteByID(ctx context.Context) error {
 	conn := db.GetConnection()
@@ -82,3 +328,24 @@ func (p *Psychologist) DeleteByID(ctx context.Context) error {
 
 	return err
 }
+
+// GetPsychologistsByIDs retrieves the psychologists with the given IDs, keyed by ID.
+func GetPsychologistsByIDs(ctx context.Context, ids []int) (map[int]Psychologist, error) {
+	psychologists := make(map[int]Psychologist, len(ids))
+	if len(ids) == 0 {
+		return psychologists, nil
+	}
+
+	conn := db.GetConnection()
+	var list []Psychologist
+	err := conn.WithContext(ctx).Model(&list).Where("id IN (?)", pg.In(ids)).Select()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, psychologist := range list {
+		psychologists[psychologist.ID] = psychologist
+	}
+
+	return psychologists, nil
+}
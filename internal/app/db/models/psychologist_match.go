@@ -0,0 +1,175 @@
+package models
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+	"github.com/vitalicher97/psychologist_app/internal/app/matching"
+)
+
+// Match list sizes.
+const (
+	DefaultMatchLimit = 10
+	MaxMatchLimit     = 50
+)
+
+// PsychologistMatch is a verified psychologist ranked by how well they match an intake response.
+type PsychologistMatch struct {
+	Psychologist Psychologist
+	matching.Result
+}
+
+// MatchPsychologists scores every verified psychologist against the answers and returns the best limit matches.
+// Each criterion counts with the weight of its question. Psychologists with the same score are ranked by
+// their average rating.
+func MatchPsychologists(ctx context.Context, answers IntakeAnswers, limit int) ([]PsychologistMatch, error) {
+	if limit <= 0 {
+		limit = DefaultMatchLimit
+	}
+	limit = min(limit, MaxMatchLimit)
+
+	questions, err := GetIntakeQuestionnaire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	weights := make(map[matching.Criterion]float64, len(questions))
+	for _, q := range questions {
+		if q.Weight > 0 {
+			weights[q.Key] = q.Weight
+		}
+	}
+
+	conn := db.GetConnection()
+	var psychologists []Psychologist
+	err = conn.WithContext(ctx).Model(&psychologists).Where("onboarding_status = ?", OnboardingVerified).Select()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := loadMatchCandidates(ctx, psychologists)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := answers.preferences()
+	matches := make([]PsychologistMatch, len(psychologists))
+	for i, p := range psychologists {
+		matches[i] = PsychologistMatch{Psychologist: p, Result: matching.Score(prefs, *candidates[p.ID], weights)}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if ra, rb := ratingOf(a.Psychologist), ratingOf(b.Psychologist); ra != rb {
+			return ra > rb
+		}
+		return a.Psychologist.ID < b.Psychologist.ID
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches, nil
+}
+
+// ratingOf returns the psychologist's average rating, or zero when they have no reviews.
+func ratingOf(p Psychologist) float64 {
+	if p.AverageRating == nil {
+		return 0
+	}
+
+	return *p.AverageRating
+}
+
+// loadMatchCandidates loads what matching needs to know about the psychologists, keyed by psychologist ID.
+func loadMatchCandidates(ctx context.Context, psychologists []Psychologist) (map[int]*matching.Candidate, error) {
+	candidates := make(map[int]*matching.Candidate, len(psychologists))
+	ids := make([]int, len(psychologists))
+	for i, p := range psychologists {
+		ids[i] = p.ID
+		candidates[p.ID] = &matching.Candidate{
+			ID:              p.ID,
+			Gender:          p.Gender,
+			Specializations: make(map[int]string),
+			Prices:          make(map[string]float64),
+		}
+	}
+	if len(ids) == 0 {
+		return candidates, nil
+	}
+
+	conn := db.GetConnection().WithContext(ctx)
+
+	var specializations []struct {
+		PsychologistID int
+		ID             int
+		Name           string
+	}
+	err := conn.Model((*PsychologistSpecialization)(nil)).
+		ColumnExpr("psychologist_specialization.psychologist_id, s.id, s.name").
+		Join("JOIN specializations AS s ON s.id = psychologist_specialization.specialization_id").
+		Where("psychologist_specialization.psychologist_id IN (?)", pg.In(ids)).
+		Select(&specializations)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range specializations {
+		candidates[s.PsychologistID].Specializations[s.ID] = s.Name
+	}
+
+	var languages []PsychologistLanguage
+	err = conn.Model(&languages).Where("psychologist_id IN (?)", pg.In(ids)).Order("language_code").Select()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range languages {
+		c := candidates[l.PsychologistID]
+		c.Languages = append(c.Languages, l.LanguageCode)
+	}
+
+	var modalities []PsychologistModality
+	err = conn.Model(&modalities).Where("psychologist_id IN (?)", pg.In(ids)).Select()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range modalities {
+		c := candidates[m.PsychologistID]
+		c.Modalities = append(c.Modalities, string(m.Modality))
+	}
+
+	var prices []struct {
+		PsychologistID int
+		Currency       string
+		Price          float64
+	}
+	err = conn.Model((*ConsultationPricing)(nil)).
+		Column("psychologist_id", "currency").
+		ColumnExpr("min(price) AS price").
+		Where("psychologist_id IN (?)", pg.In(ids)).
+		Group("psychologist_id", "currency").
+		Select(&prices)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range prices {
+		candidates[p.PsychologistID].Prices[p.Currency] = p.Price
+	}
+
+	var availabilities []Availability
+	err = conn.Model(&availabilities).Where("psychologist_id IN (?)", pg.In(ids)).Select()
+	if err != nil {
+		return nil, err
+	}
+	for i := range availabilities {
+		c := candidates[availabilities[i].PsychologistID]
+		c.Weekly = append(c.Weekly, availabilities[i].Rule())
+	}
+
+	return candidates, nil
+}
@@ -0,0 +1,373 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"html"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+	"github.com/vitalicher97/psychologist_app/internal/app/scheduling"
+)
+
+// Orders in which psychologist search results can be sorted.
+const (
+	SortRelevance = "relevance"
+	SortPrice     = "price"
+	SortPriceDesc = "-price"
+	SortNextSlot  = "next_slot"
+)
+
+// Languages a text search can be run in.
+const (
+	TextSearchEnglish   = "en"
+	TextSearchUkrainian = "uk"
+)
+
+// textSearchConfigs maps text search languages to PostgreSQL text search configurations.
+var textSearchConfigs = map[string]string{
+	TextSearchEnglish:   "english",
+	TextSearchUkrainian: "ukrainian",
+}
+
+// Markers that text search snippets wrap matches in. They are private-use characters, so they are
+// turned into <mark> tags only after the bio itself has been escaped for HTML.
+const (
+	snippetStart = "\ue000"
+	snippetStop  = "\ue001"
+)
+
+// snippetOptions controls the length and highlighting of text search snippets.
+const snippetOptions = `StartSel="` + snippetStart + `", StopSel="` + snippetStop + `", MaxWords=35, MinWords=15, MaxFragments=2`
+
+// nextSlotHorizon is how far ahead the next free slot of a psychologist is looked for.
+const nextSlotHorizon = 31 * 24 * time.Hour
+
+// nextSlotCandidates caps how many matches a search sorted by next slot computes slots for.
+const nextSlotCandidates = 200
+
+// Errors returned when a psychologist search is not consistent.
+var (
+	ErrCurrencyRequired  = errors.New("currency is required to filter or sort by price")
+	ErrInvalidPriceRange = errors.New("min_price must not be greater than max_price")
+	ErrInvalidSearchSort = errors.New("sort must be one of relevance, price, -price or next_slot")
+	ErrInvalidSearchLang = errors.New("lang must be either uk or en")
+)
+
+// TextSearch is a full-text query over psychologist names and bios.
+// Both the English and the Ukrainian configurations are matched; the language only picks how snippets
+// are highlighted and is detected from the query when empty.
+type TextSearch struct {
+	Query    string
+	Language string
+}
+
+// PsychologistSearch holds the filters of a psychologist directory search. Zero values do not filter.
+type PsychologistSearch struct {
+	// Name matches psychologists whose first or last name contains every word.
+	Name string
+	// SpecializationIDs matches psychologists with at least one of the specializations.
+	SpecializationIDs []int
+	// Language matches psychologists holding sessions in the ISO 639 language.
+	Language string
+	// Modality matches psychologists offering sessions in that modality.
+	Modality Modality
+	// City matches psychologists with an office in the city, ignoring case.
+	City string
+	// Currency selects the consultation prices that MinPrice, MaxPrice and price sorting look at.
+	Currency string
+	MinPrice *float64
+	MaxPrice *float64
+	// Weekday matches psychologists with weekly availability on that day.
+	Weekday *time.Weekday
+	// Date matches psychologists with opening hours on that calendar date, taking exceptions into account.
+	Date *time.Time
+	// Sort is one of the Sort constants; relevance is the default.
+	Sort string
+}
+
+// PsychologistSearchResult is a psychologist matched by a search.
+type PsychologistSearchResult struct {
+	tableName struct{} `pg:"psychologists,alias:psychologist,discard_unknown_columns"`
+
+	Psychologist
+	// Price is the lowest consultation price in the searched currency.
+	Price    *float64 `json:"price,omitempty"`
+	Currency string   `json:"currency,omitempty" pg:"-"`
+	// Relevance grows with the number of matched name words and specializations.
+	Relevance float64 `json:"relevance"`
+	// NextAvailableAt is the start of the first bookable slot; it is only filled in when sorting by next slot.
+	NextAvailableAt *time.Time `json:"next_available_at,omitempty" pg:"-"`
+}
+
+// Validate normalizes the search and checks that its filters are consistent.
+func (s *PsychologistSearch) Validate() error {
+	s.Currency = strings.ToUpper(strings.TrimSpace(s.Currency))
+	if s.Sort == "" {
+		s.Sort = SortRelevance
+	}
+
+	switch s.Sort {
+	case SortRelevance, SortNextSlot:
+	case SortPrice, SortPriceDesc:
+		if s.Currency == "" {
+			return ErrCurrencyRequired
+		}
+	default:
+		return ErrInvalidSearchSort
+	}
+
+	if (s.MinPrice != nil || s.MaxPrice != nil) && s.Currency == "" {
+		return ErrCurrencyRequired
+	}
+	if s.MinPrice != nil && s.MaxPrice != nil && *s.MinPrice > *s.MaxPrice {
+		return ErrInvalidPriceRange
+	}
+
+	if s.Modality != "" && !s.Modality.IsValid() {
+		return ErrInvalidModality
+	}
+
+	if s.Language != "" {
+		code, err := NormalizeLanguageCode(s.Language)
+		if err != nil {
+			return err
+		}
+		s.Language = code
+	}
+
+	return nil
+}
+
+// SearchPsychologists finds a page of the verified psychologists matching every filter of the search in a single
+// query, and the number of matches. Only the page and limit of the list parameters apply.
+// Sorting by next slot loads the schedules of the nextSlotCandidates most relevant matches in one batch and
+// ranks only those.
+func SearchPsychologists(ctx context.Context, search *PsychologistSearch, params *ListParams) ([]PsychologistSearchResult, int, error) {
+	if err := search.Validate(); err != nil {
+		return nil, 0, err
+	}
+	params.normalize()
+
+	conn := db.GetConnection()
+	var results []PsychologistSearchResult
+	query := conn.WithContext(ctx).Model(&results).ColumnExpr("psychologist.*")
+	search.apply(query)
+
+	var total int
+	var err error
+	if search.Sort == SortNextSlot {
+		err = query.Limit(nextSlotCandidates).Select()
+		total = len(results)
+	} else {
+		total, err = query.Limit(params.Limit).Offset(params.Offset()).SelectAndCount()
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range results {
+		results[i].Currency = search.Currency
+		if results[i].Price == nil {
+			results[i].Currency = ""
+		}
+	}
+
+	if search.Sort == SortNextSlot {
+		if err := sortByNextSlot(ctx, results); err != nil {
+			return nil, 0, err
+		}
+		results = results[min(params.Offset(), total):min(params.Offset()+params.Limit, total)]
+	}
+
+	return results, total, nil
+}
+
+// apply adds the columns, filters and order of the search to the query.
+func (s *PsychologistSearch) apply(query *pg.Query) {
+	var relevance []string
+	var relevanceArgs []interface{}
+
+	query.Where("psychologist.onboarding_status = ?", OnboardingVerified)
+
+	for _, word := range strings.Fields(s.Name) {
+		contains := "%" + escapeLike(word) + "%"
+		prefix := escapeLike(word) + "%"
+		query.Where("(psychologist.first_name ILIKE ? OR psychologist.last_name ILIKE ?)", contains, contains)
+
+		relevance = append(relevance, "CASE WHEN psychologist.first_name ILIKE ? OR psychologist.last_name ILIKE ? THEN 2 ELSE 1 END")
+		relevanceArgs = append(relevanceArgs, prefix, prefix)
+	}
+
+	if len(s.SpecializationIDs) > 0 {
+		specializations := pg.In(s.SpecializationIDs)
+		query.Where(`EXISTS (SELECT 1 FROM psychologist_specializations AS ps
+			WHERE ps.psychologist_id = psychologist.id AND ps.specialization_id IN (?))`, specializations)
+
+		relevance = append(relevance, `(SELECT count(*) FROM psychologist_specializations AS ps
+			WHERE ps.psychologist_id = psychologist.id AND ps.specialization_id IN (?))`)
+		relevanceArgs = append(relevanceArgs, specializations)
+	}
+
+	if len(relevance) > 0 {
+		query.ColumnExpr("("+strings.Join(relevance, " + ")+") AS relevance", relevanceArgs...)
+	}
+
+	if s.Language != "" {
+		query.Where(`EXISTS (SELECT 1 FROM psychologist_languages AS pl
+			WHERE pl.psychologist_id = psychologist.id AND pl.language_code = ?)`, s.Language)
+	}
+
+	if s.Modality != "" {
+		query.Where(`EXISTS (SELECT 1 FROM psychologist_modalities AS pm
+			WHERE pm.psychologist_id = psychologist.id AND pm.modality = ?)`, s.Modality)
+	}
+
+	if city := strings.TrimSpace(s.City); city != "" {
+		query.Where(`EXISTS (SELECT 1 FROM office_locations AS ol
+			WHERE ol.psychologist_id = psychologist.id AND lower(ol.city) = lower(?))`, city)
+	}
+
+	if s.Currency != "" {
+		query.ColumnExpr("pricing.price").
+			Join(`LEFT JOIN LATERAL (SELECT min(cp.price) AS price FROM consultation_pricing AS cp
+				WHERE cp.psychologist_id = psychologist.id AND cp.currency = ?) AS pricing ON true`, s.Currency)
+		if s.MinPrice != nil {
+			query.Where("pricing.price >= ?", *s.MinPrice)
+		}
+		if s.MaxPrice != nil {
+			query.Where("pricing.price <= ?", *s.MaxPrice)
+		}
+	}
+
+	if s.Weekday != nil {
+		query.Where(`EXISTS (SELECT 1 FROM availabilities AS av
+			WHERE av.psychologist_id = psychologist.id AND av.day_of_week = ?)`, int(*s.Weekday))
+	}
+
+	if s.Date != nil {
+		date := s.Date.Format(time.DateOnly)
+		query.Where(`((EXISTS (SELECT 1 FROM availabilities AS av
+				WHERE av.psychologist_id = psychologist.id AND av.day_of_week = ?)
+			AND NOT EXISTS (SELECT 1 FROM availability_exceptions AS ex
+				WHERE ex.psychologist_id = psychologist.id AND ex.kind = ? AND ex.all_day
+				AND ? BETWEEN ex.start_date AND ex.end_date))
+			OR EXISTS (SELECT 1 FROM availability_exceptions AS ex
+				WHERE ex.psychologist_id = psychologist.id AND ex.kind = ?
+				AND ? BETWEEN ex.start_date AND ex.end_date))`,
+			int(s.Date.Weekday()), scheduling.ExceptionBlock, date, scheduling.ExceptionExtra, date)
+	}
+
+	switch s.Sort {
+	case SortPrice:
+		query.OrderExpr("pricing.price ASC NULLS LAST")
+	case SortPriceDesc:
+		query.OrderExpr("pricing.price DESC NULLS LAST")
+	case SortRelevance, SortNextSlot:
+		if len(relevance) > 0 {
+			query.OrderExpr("relevance DESC")
+		}
+	}
+	query.Order("psychologist.id")
+}
+
+// sortByNextSlot fills in the next bookable slot of every result and orders the results by it,
+// putting psychologists without a free slot within the horizon last.
+func sortByNextSlot(ctx context.Context, results []PsychologistSearchResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(results))
+	for i := range results {
+		ids[i] = results[i].ID
+	}
+
+	now := time.Now()
+	schedules, err := LoadSchedules(ctx, ids, now, now.Add(nextSlotHorizon))
+	if err != nil {
+		return err
+	}
+
+	settings, err := GetSchedulingSettingsByPsychologists(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range results {
+		schedule, ok := schedules[results[i].ID]
+		if !ok {
+			continue
+		}
+
+		opts := settings[results[i].ID].SlotOptions(now, 0)
+		if slots := schedule.Slots(now, now.Add(nextSlotHorizon), opts); len(slots) > 0 {
+			start := slots[0].Start
+			results[i].NextAvailableAt = &start
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].NextAvailableAt, results[j].NextAvailableAt
+		if a == nil || b == nil {
+			return a != nil
+		}
+
+		return a.Before(*b)
+	})
+
+	return nil
+}
+
+// Validate checks the language of the text search.
+func (t *TextSearch) Validate() error {
+	if t.Language != "" {
+		if _, ok := textSearchConfigs[t.Language]; !ok {
+			return ErrInvalidSearchLang
+		}
+	}
+
+	return nil
+}
+
+// config returns the text search configuration used for snippets.
+func (t *TextSearch) config() string {
+	if t.Language != "" {
+		return textSearchConfigs[t.Language]
+	}
+
+	for _, r := range t.Query {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return textSearchConfigs[TextSearchUkrainian]
+		}
+	}
+
+	return textSearchConfigs[TextSearchEnglish]
+}
+
+// apply restricts the query to psychologists matching the text and selects their rank and bio snippet.
+// The snippet marks matches with the snippet markers, which are first removed from the bio itself.
+func (t *TextSearch) apply(query *pg.Query) {
+	tsquery := pg.SafeQuery("(websearch_to_tsquery('english', ?) || websearch_to_tsquery('ukrainian', ?))", t.Query, t.Query)
+
+	query.ColumnExpr("ts_rank_cd(psychologist.search_vector, ?) AS search_rank", tsquery).
+		ColumnExpr("ts_headline(?::regconfig, translate(coalesce(psychologist.bio, ''), ?, ''), ?, ?) AS snippet",
+			t.config(), snippetStart+snippetStop, tsquery, snippetOptions).
+		Where("psychologist.search_vector @@ ?", tsquery)
+}
+
+// highlightSnippet escapes a text search snippet for HTML and wraps its matches in <mark> tags.
+// Bios are free text, so any markup in them is shown as text rather than rendered.
+func highlightSnippet(snippet string) string {
+	return strings.NewReplacer(snippetStart, "<mark>", snippetStop, "</mark>").Replace(html.EscapeString(snippet))
+}
+
+// escapeLike escapes the characters that have a special meaning in LIKE patterns.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -0,0 +1,40 @@
+package models
+
+import "testing"
+
+func TestHighlightSnippet(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{
+			name:    "plain text",
+			snippet: "Works with " + snippetStart + "anxiety" + snippetStop + " in adults",
+			want:    "Works with <mark>anxiety</mark> in adults",
+		},
+		{
+			name:    "script tag",
+			snippet: "<script>alert(1)</script> " + snippetStart + "anxiety" + snippetStop,
+			want:    "&lt;script&gt;alert(1)&lt;/script&gt; <mark>anxiety</mark>",
+		},
+		{
+			name:    "image with a handler",
+			snippet: `<img src=x onerror="alert(1)"> ` + snippetStart + "CBT" + snippetStop + " & more",
+			want:    "&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>CBT</mark> &amp; more",
+		},
+		{
+			name:    "mark tags in the bio",
+			snippet: "<mark>not a match</mark>",
+			want:    "&lt;mark&gt;not a match&lt;/mark&gt;",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightSnippet(tt.snippet); got != tt.want {
+				t.Errorf("highlightSnippet = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPsychologistValidateTimeZone(t *testing.T) {
+	tests := []struct {
+		timeZone string
+		want     string
+		wantErr  bool
+	}{
+		{timeZone: "", want: DefaultTimeZone},
+		{timeZone: "UTC", want: "UTC"},
+		{timeZone: "Europe/Kyiv", want: "Europe/Kyiv"},
+		{timeZone: "Local", wantErr: true},
+		{timeZone: "Europe/Atlantis", wantErr: true},
+		{timeZone: "../../etc/passwd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timeZone, func(t *testing.T) {
+			p := &Psychologist{TimeZone: tt.timeZone}
+			err := p.validate()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTimeZone) {
+					t.Errorf("validate error = %v, want ErrInvalidTimeZone", err)
+				}
+				return
+			}
+			if err != nil {
+				if tt.timeZone != "" && tt.timeZone != "UTC" {
+					t.Skipf("time zone data unavailable: %v", err)
+				}
+				t.Fatalf("validate: %v", err)
+			}
+			if p.TimeZone != tt.want {
+				t.Errorf("TimeZone = %q, want %q", p.TimeZone, tt.want)
+			}
+		})
+	}
+}
+
+func TestPsychologistLocationRejectsServerZone(t *testing.T) {
+	if _, err := (&Psychologist{TimeZone: "Local"}).Location(); !errors.Is(err, ErrInvalidTimeZone) {
+		t.Errorf("Location error = %v, want ErrInvalidTimeZone", err)
+	}
+}
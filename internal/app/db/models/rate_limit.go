@@ -0,0 +1,63 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+	"github.com/vitalicher97/psychologist_app/internal/app/ratelimit"
+)
+
+// RateLimitBucket represents the rate_limit_buckets table in the database.
+type RateLimitBucket struct {
+	Key       string    `pg:",pk"`
+	Tokens    float64   `pg:",notnull,use_zero"`
+	UpdatedAt time.Time `pg:",notnull,use_zero"`
+}
+
+// RateLimitStore keeps rate limit buckets in the database, so every instance of the API shares them.
+type RateLimitStore struct{}
+
+// Take counts a request against the bucket of the key. The bucket row is locked while it is updated,
+// so concurrent requests of different instances are counted one after the other.
+func (RateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	var result ratelimit.Result
+
+	conn := db.GetConnection()
+	err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		// New buckets start full; a zero UpdatedAt tells Take so.
+		row := &RateLimitBucket{Key: key}
+		_, err := tx.ModelContext(ctx, row).OnConflict("(key) DO NOTHING").Insert()
+		if err != nil {
+			return err
+		}
+
+		if err := tx.ModelContext(ctx, row).WherePK().For("UPDATE").Select(); err != nil {
+			return err
+		}
+
+		bucket := ratelimit.Bucket{Tokens: row.Tokens, UpdatedAt: row.UpdatedAt}
+		result = bucket.Take(limit, time.Now())
+
+		row.Tokens = bucket.Tokens
+		row.UpdatedAt = bucket.UpdatedAt
+		_, err = tx.ModelContext(ctx, row).Column("tokens", "updated_at").WherePK().Update()
+
+		return err
+	})
+
+	return result, err
+}
+
+// DeleteIdleRateLimitBuckets removes buckets that have not been used for a day. They would be full again
+// under any sensible limit, which is how a missing bucket starts too.
+func DeleteIdleRateLimitBuckets(ctx context.Context) error {
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model((*RateLimitBucket)(nil)).
+		Where("updated_at < ?", time.Now().Add(-24*time.Hour)).
+		Delete()
+
+	return err
+}
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// ReviewEditWindow is how long after posting a customer can still change their review.
+var ReviewEditWindow = 14 * 24 * time.Hour
+
+// Errors returned by review operations.
+var (
+	ErrNoCompletedAppointment = errors.New("only customers with a completed appointment can review the psychologist")
+	ErrAlreadyReviewed        = errors.New("the customer has already reviewed the psychologist")
+	ErrReviewEditWindowClosed = errors.New("the review can no longer be edited")
+	ErrAlreadyReplied         = errors.New("the psychologist has already replied to the review")
+)
+
+// Review represents the reviews table in the database.
+// A customer can review each psychologist once, after at least one completed appointment with them.
+type Review struct {
+	ID             int        `json:"id" binding:"-" pg:",pk"`
+	PsychologistID int        `json:"psychologist_id" binding:"required" pg:",notnull"`
+	CustomerID     int        `json:"customer_id" binding:"required" pg:",notnull"`
+	Rating         int        `json:"rating" binding:"required,min=1,max=5" pg:",notnull"`
+	Text           string     `json:"text" binding:"max=5000"`
+	Reply          string     `json:"reply,omitempty" binding:"-"`
+	RepliedAt      *time.Time `json:"replied_at,omitempty" binding:"-"`
+	Hidden         bool       `json:"hidden" binding:"-" pg:",notnull,use_zero"`
+	HiddenReason   string     `json:"hidden_reason,omitempty" binding:"-"`
+	EditableUntil  time.Time  `json:"editable_until" binding:"-" pg:",notnull"`
+	CreatedBy      int        `json:"created_by" binding:"-" pg:",notnull"`
+	UpdatedBy      int        `json:"updated_by" binding:"-" pg:",notnull"`
+	CreatedAt      time.Time  `json:"created_at" binding:"-" pg:",default:now()"`
+	UpdatedAt      time.Time  `json:"updated_at" binding:"-" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the reviews table when INSERT query executes. It adds time in created_at and updated_at columns.
+func (r *Review) BeforeInsert(ctx context.Context) (context.Context, error) {
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = r.CreatedAt
+	r.CreatedBy = actingUserID(ctx)
+	r.UpdatedBy = r.CreatedBy
+
+	return ctx, nil
+}
+
+// BeforeUpdate is a method for performing additional changes to the reviews table when UPDATE query executes. It updates time in updated_at column.
+func (r *Review) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	r.UpdatedAt = time.Now()
+	r.UpdatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// reviewListSpec lists the fields review lists can be sorted and filtered by.
+// Hidden reviews are left out unless the hidden filter asks for them.
+var reviewListSpec = listSpec{
+	sortable: map[string]string{
+		"id":         "id",
+		"rating":     "rating",
+		"created_at": "created_at",
+	},
+	defaultSort: []SortField{{Field: "created_at", Desc: true}},
+	filters: map[string]filterFunc{
+		"psychologist": filterInt("psychologist_id"),
+		"customer":     filterInt("customer_id"),
+		"rating":       filterInt("rating"),
+		"min_rating":   filterMinInt("rating"),
+		"hidden":       filterBool("hidden"),
+	},
+}
+
+// filterMinInt matches rows whose column is at least the integer value.
+func filterMinInt(column string) filterFunc {
+	return func(q *pg.Query, value string, _ *time.Location) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		q.Where("? >= ?", pg.Ident(column), n)
+
+		return nil
+	}
+}
+
+// filterBool matches rows whose column equals the boolean value.
+func filterBool(column string) filterFunc {
+	return func(q *pg.Query, value string, _ *time.Location) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		q.Where("? = ?", pg.Ident(column), b)
+
+		return nil
+	}
+}
+
+// ListReviews retrieves a page of reviews and the number of reviews matching the filters.
+func ListReviews(ctx context.Context, params *ListParams) ([]Review, int, error) {
+	var reviews []Review
+	query := newListQuery(ctx, &reviews)
+	if _, ok := params.Filters["hidden"]; !ok {
+		query.Where("hidden = false")
+	}
+
+	total, err := selectPage(query, params, reviewListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, total, nil
+}
+
+// GetByID retrieves a review by its ID.
+func (r *Review) GetByID(ctx context.Context) (*Review, error) {
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(r).WherePK().Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Create posts the review after checking that the customer completed an appointment with the psychologist.
+func (r *Review) Create(ctx context.Context) error {
+	r.Reply = ""
+	r.RepliedAt = nil
+	r.Hidden = false
+	r.HiddenReason = ""
+	r.EditableUntil = time.Now().Add(ReviewEditWindow)
+
+	conn := db.GetConnection()
+	err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		completed, err := tx.ModelContext(ctx, (*Appointment)(nil)).
+			Where("psychologist_id = ? AND customer_id = ?", r.PsychologistID, r.CustomerID).
+			Where("status = ?", StatusCompleted).
+			Exists()
+		if err != nil {
+			return err
+		}
+		if !completed {
+			return ErrNoCompletedAppointment
+		}
+
+		_, err = tx.ModelContext(ctx, r).Returning("*").Insert()
+
+		return err
+	})
+
+	var pgErr pg.Error
+	if errors.As(err, &pgErr) && pgErr.Field('C') == uniqueViolation {
+		return ErrAlreadyReviewed
+	}
+
+	return err
+}
+
+// Edit changes the rating and text of the review while its edit window is open.
+func (r *Review) Edit(ctx context.Context, rating int, text string) error {
+	conn := db.GetConnection()
+
+	return conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := tx.ModelContext(ctx, r).WherePK().For("UPDATE").Select(); err != nil {
+			return err
+		}
+		if time.Now().After(r.EditableUntil) {
+			return ErrReviewEditWindowClosed
+		}
+
+		r.Rating = rating
+		r.Text = text
+		_, err := tx.ModelContext(ctx, r).Column("rating", "text", "updated_at", "updated_by").WherePK().Update()
+
+		return err
+	})
+}
+
+// AddReply records the psychologist's reply. A review can be replied to only once.
+func (r *Review) AddReply(ctx context.Context, reply string) error {
+	conn := db.GetConnection()
+
+	return conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := tx.ModelContext(ctx, r).WherePK().For("UPDATE").Select(); err != nil {
+			return err
+		}
+		if r.RepliedAt != nil {
+			return ErrAlreadyReplied
+		}
+
+		now := time.Now()
+		r.Reply = reply
+		r.RepliedAt = &now
+		_, err := tx.ModelContext(ctx, r).Column("reply", "replied_at", "updated_at", "updated_by").WherePK().Update()
+
+		return err
+	})
+}
+
+// SetHidden hides the review from customers, or shows it again. Hidden reviews do not count towards the rating.
+func (r *Review) SetHidden(ctx context.Context, hidden bool, reason string) error {
+	r.Hidden = hidden
+	r.HiddenReason = reason
+	if !hidden {
+		r.HiddenReason = ""
+	}
+
+	conn := db.GetConnection()
+	res, err := conn.WithContext(ctx).Model(r).Column("hidden", "hidden_reason", "updated_at", "updated_by").WherePK().Returning("*").Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+	"github.com/vitalicher97/psychologist_app/internal/app/scheduling"
+)
+
+// LoadSchedule builds the schedule of a psychologist for the [from, to) window from their availability,
+// availability exceptions, appointments, slots held for waitlist offers and busy blocks imported from
+// external calendars. The weekly availability and exception dates are interpreted in the psychologist's
+// time zone, and appointments and held slots are padded with the buffers from the scheduling settings.
+func LoadSchedule(ctx context.Context, psychologistID int, from, to time.Time) (*scheduling.Schedule, error) {
+	schedules, err := LoadSchedules(ctx, []int{psychologistID}, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, ok := schedules[psychologistID]
+	if !ok {
+		return nil, pg.ErrNoRows
+	}
+
+	return schedule, nil
+}
+
+// LoadSchedules builds the schedules of several psychologists for the [from, to) window, keyed by
+// psychologist ID, with one query per source of opening hours or busy time. Unknown IDs are left out.
+func LoadSchedules(ctx context.Context, psychologistIDs []int, from, to time.Time) (map[int]*scheduling.Schedule, error) {
+	schedules := make(map[int]*scheduling.Schedule, len(psychologistIDs))
+	if len(psychologistIDs) == 0 {
+		return schedules, nil
+	}
+
+	psychologists, err := GetPsychologistsByIDs(ctx, psychologistIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := GetSchedulingSettingsByPsychologists(ctx, psychologistIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var maxGap time.Duration
+	for id, psychologist := range psychologists {
+		loc, err := psychologist.Location()
+		if err != nil {
+			return nil, err
+		}
+
+		s := settings[id]
+		schedules[id] = &scheduling.Schedule{
+			Location:     loc,
+			BufferBefore: s.BufferBefore(),
+			BufferAfter:  s.BufferAfter(),
+		}
+		if gap := s.BufferBefore() + s.BufferAfter(); gap > maxGap {
+			maxGap = gap
+		}
+	}
+
+	conn := db.GetConnection()
+	ids := pg.In(psychologistIDs)
+
+	var availability []Availability
+	err = conn.WithContext(ctx).Model(&availability).
+		Where("psychologist_id IN (?)", ids).
+		OrderExpr("day_of_week, start_time").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	// Widen the date range by two days on each side: it covers any time zone offset as well as
+	// exception windows that cross midnight.
+	var exceptions []AvailabilityException
+	err = conn.WithContext(ctx).Model(&exceptions).
+		Where("psychologist_id IN (?)", ids).
+		Where("start_date <= ? AND end_date >= ?", to.UTC().AddDate(0, 0, 2).Format(time.DateOnly), from.UTC().AddDate(0, 0, -2).Format(time.DateOnly)).
+		Order("start_date", "id").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	// Appointments and held slots just outside the window still matter when their buffers reach into it.
+	var appointments []Appointment
+	err = conn.WithContext(ctx).Model(&appointments).
+		Where("psychologist_id IN (?)", ids).
+		Where("status NOT IN (?)", pg.In(cancelledStatuses)).
+		Where("start_time < ? AND end_time > ?", to.Add(maxGap), from.Add(-maxGap)).
+		Order("start_time").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	var held []WaitlistOffer
+	err = conn.WithContext(ctx).Model(&held).
+		Where("psychologist_id IN (?)", ids).
+		Where("status = ? AND expires_at > ?", OfferPending, time.Now()).
+		Where("start_time < ? AND end_time > ?", to.Add(maxGap), from.Add(-maxGap)).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []BusyBlock
+	err = conn.WithContext(ctx).Model(&blocks).
+		Where("psychologist_id IN (?)", ids).
+		Where("start_time < ? AND end_time > ?", to, from).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range availability {
+		if schedule, ok := schedules[availability[i].PsychologistID]; ok {
+			schedule.Weekly = append(schedule.Weekly, availability[i].Rule())
+		}
+	}
+	for i := range exceptions {
+		if schedule, ok := schedules[exceptions[i].PsychologistID]; ok {
+			schedule.Exceptions = append(schedule.Exceptions, exceptions[i].Exception())
+		}
+	}
+	for _, appointment := range appointments {
+		if schedule, ok := schedules[appointment.PsychologistID]; ok {
+			schedule.Busy = append(schedule.Busy, schedule.Padded(scheduling.Interval{Start: appointment.StartTime, End: appointment.EndTime}))
+		}
+	}
+	for _, offer := range held {
+		if schedule, ok := schedules[offer.PsychologistID]; ok {
+			schedule.Busy = append(schedule.Busy, schedule.Padded(scheduling.Interval{Start: offer.StartTime, End: offer.EndTime}))
+		}
+	}
+	for _, block := range blocks {
+		if schedule, ok := schedules[block.PsychologistID]; ok {
+			schedule.Busy = append(schedule.Busy, scheduling.Interval{Start: block.StartTime, End: block.EndTime})
+		}
+	}
+
+	return schedules, nil
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+	"github.com/vitalicher97/psychologist_app/internal/app/scheduling"
+)
+
+// Defaults used for psychologists that have not saved their scheduling settings.
+const (
+	defaultSessionMinutes = 60
+	defaultMaxAdvanceDays = 365
+)
+
+// ErrOutsideBookingWindow is returned when an appointment starts too soon or too far ahead.
+var ErrOutsideBookingWindow = errors.New("the requested time is outside the psychologist's booking window")
+
+// SchedulingSettings represents the psychologist_scheduling_settings table in the database.
+type SchedulingSettings struct {
+	tableName struct{} `pg:"psychologist_scheduling_settings"`
+
+	PsychologistID      int       `json:"psychologist_id" binding:"-" pg:",pk"`
+	SessionMinutes      int       `json:"session_minutes" binding:"required,min=5,max=480" pg:",notnull"`
+	BufferBeforeMinutes int       `json:"buffer_before_minutes" binding:"min=0,max=240" pg:",notnull,use_zero"`
+	BufferAfterMinutes  int       `json:"buffer_after_minutes" binding:"min=0,max=240" pg:",notnull,use_zero"`
+	SlotStepMinutes     int       `json:"slot_step_minutes" binding:"required,min=5,max=480" pg:",notnull"`
+	MinNoticeMinutes    int       `json:"min_notice_minutes" binding:"min=0" pg:",notnull,use_zero"`
+	MaxAdvanceDays      int       `json:"max_advance_days" binding:"required,min=1,max=730" pg:",notnull"`
+	UpdatedBy           int       `json:"updated_by" binding:"-" pg:",notnull"`
+	CreatedAt           time.Time `json:"created_at" binding:"-" pg:",default:now()"`
+	UpdatedAt           time.Time `json:"updated_at" binding:"-" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the psychologist_scheduling_settings table when INSERT query executes. It adds time in created_at and updated_at columns.
+func (s *SchedulingSettings) BeforeInsert(ctx context.Context) (context.Context, error) {
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+	s.UpdatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// DefaultSchedulingSettings returns the settings used until a psychologist saves their own.
+func DefaultSchedulingSettings(psychologistID int) *SchedulingSettings {
+	return &SchedulingSettings{
+		PsychologistID:  psychologistID,
+		SessionMinutes:  defaultSessionMinutes,
+		SlotStepMinutes: defaultSessionMinutes,
+		MaxAdvanceDays:  defaultMaxAdvanceDays,
+	}
+}
+
+// GetSchedulingSettings retrieves the scheduling settings of a psychologist, falling back to the defaults.
+func GetSchedulingSettings(ctx context.Context, psychologistID int) (*SchedulingSettings, error) {
+	conn := db.GetConnection()
+	settings := &SchedulingSettings{PsychologistID: psychologistID}
+	err := conn.WithContext(ctx).Model(settings).WherePK().Select()
+	if errors.Is(err, pg.ErrNoRows) {
+		return DefaultSchedulingSettings(psychologistID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// GetSchedulingSettingsByPsychologists retrieves the scheduling settings of several psychologists keyed by
+// psychologist ID, falling back to the defaults for those that have none.
+func GetSchedulingSettingsByPsychologists(ctx context.Context, psychologistIDs []int) (map[int]*SchedulingSettings, error) {
+	settings := make(map[int]*SchedulingSettings, len(psychologistIDs))
+	if len(psychologistIDs) == 0 {
+		return settings, nil
+	}
+
+	conn := db.GetConnection()
+	var list []SchedulingSettings
+	err := conn.WithContext(ctx).Model(&list).Where("psychologist_id IN (?)", pg.In(psychologistIDs)).Select()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range list {
+		settings[list[i].PsychologistID] = &list[i]
+	}
+	for _, id := range psychologistIDs {
+		if _, ok := settings[id]; !ok {
+			settings[id] = DefaultSchedulingSettings(id)
+		}
+	}
+
+	return settings, nil
+}
+
+// Save inserts or replaces the scheduling settings of the psychologist.
+func (s *SchedulingSettings) Save(ctx context.Context) error {
+	s.UpdatedAt = time.Now()
+
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(s).
+		OnConflict("(psychologist_id) DO UPDATE").
+		Set("session_minutes = EXCLUDED.session_minutes").
+		Set("buffer_before_minutes = EXCLUDED.buffer_before_minutes").
+		Set("buffer_after_minutes = EXCLUDED.buffer_after_minutes").
+		Set("slot_step_minutes = EXCLUDED.slot_step_minutes").
+		Set("min_notice_minutes = EXCLUDED.min_notice_minutes").
+		Set("max_advance_days = EXCLUDED.max_advance_days").
+		Set("updated_by = EXCLUDED.updated_by").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Insert()
+
+	return err
+}
+
+// SessionLength returns the length of a session. New appointments must last exactly this long.
+func (s *SchedulingSettings) SessionLength() time.Duration {
+	return time.Duration(s.SessionMinutes) * time.Minute
+}
+
+// SlotStep returns the time between the starts of consecutive slots. New appointments must start on a slot.
+func (s *SchedulingSettings) SlotStep() time.Duration {
+	return time.Duration(s.SlotStepMinutes) * time.Minute
+}
+
+// BufferBefore returns the free time required before a session.
+func (s *SchedulingSettings) BufferBefore() time.Duration {
+	return time.Duration(s.BufferBeforeMinutes) * time.Minute
+}
+
+// BufferAfter returns the free time required after a session.
+func (s *SchedulingSettings) BufferAfter() time.Duration {
+	return time.Duration(s.BufferAfterMinutes) * time.Minute
+}
+
+// BookingWindow returns the earliest and latest time a session booked at now may start.
+func (s *SchedulingSettings) BookingWindow(now time.Time) (time.Time, time.Time) {
+	return now.Add(time.Duration(s.MinNoticeMinutes) * time.Minute), now.AddDate(0, 0, s.MaxAdvanceDays)
+}
+
+// SlotOptions returns the slot generation options for sessions of the given duration booked at now.
+// A zero duration uses the default session length.
+func (s *SchedulingSettings) SlotOptions(now time.Time, duration time.Duration) scheduling.SlotOptions {
+	if duration <= 0 {
+		duration = s.SessionLength()
+	}
+	notBefore, notAfter := s.BookingWindow(now)
+
+	return scheduling.SlotOptions{
+		Duration:  duration,
+		Step:      s.SlotStep(),
+		NotBefore: notBefore,
+		NotAfter:  notAfter,
+	}
+}
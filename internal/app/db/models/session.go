@@ -0,0 +1,156 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// RefreshTokenTTL is how long a refresh token can be exchanged for new tokens.
+var RefreshTokenTTL = 30 * 24 * time.Hour
+
+// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired, revoked or already used.
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+// RefreshToken represents the refresh_tokens table in the database.
+// Only a hash of the token is stored. Every refresh replaces the token with a new one of the same family,
+// so a token that is presented twice has been stolen and the whole family is revoked.
+type RefreshToken struct {
+	ID        int       `pg:",pk"`
+	UserID    int       `pg:",notnull"`
+	FamilyID  string    `pg:",notnull"`
+	TokenHash string    `pg:",unique,notnull"`
+	ExpiresAt time.Time `pg:",notnull"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time `pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the refresh_tokens table when INSERT query executes. It adds time in created_at column.
+func (t *RefreshToken) BeforeInsert(ctx context.Context) (context.Context, error) {
+	t.CreatedAt = time.Now()
+
+	return ctx, nil
+}
+
+// Session is a signed in user and the refresh token that keeps them signed in.
+type Session struct {
+	User                  *User
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+// StartSession issues the first refresh token of a new session for the user.
+func StartSession(ctx context.Context, user *User) (*Session, error) {
+	family := make([]byte, 16)
+	if _, err := rand.Read(family); err != nil {
+		return nil, err
+	}
+
+	return issueRefreshToken(ctx, db.GetConnection(), user, hex.EncodeToString(family))
+}
+
+// RefreshSession exchanges a refresh token for a new one of the same session.
+// Presenting a token that was already exchanged revokes the session, since only a thief would do that.
+func RefreshSession(ctx context.Context, token string) (*Session, error) {
+	var session *Session
+	reused := false
+
+	conn := db.GetConnection()
+	err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		var current RefreshToken
+		err := tx.ModelContext(ctx, &current).Where("token_hash = ?", hashToken(token)).For("UPDATE").Select()
+		if errors.Is(err, pg.ErrNoRows) {
+			return ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		switch {
+		case current.RevokedAt != nil || now.After(current.ExpiresAt):
+			return ErrInvalidRefreshToken
+		case current.UsedAt != nil:
+			reused = true
+			return revokeSession(ctx, tx, current.FamilyID)
+		}
+
+		current.UsedAt = &now
+		if _, err := tx.ModelContext(ctx, &current).Column("used_at").WherePK().Update(); err != nil {
+			return err
+		}
+
+		user := &User{ID: current.UserID}
+		if err := tx.ModelContext(ctx, user).WherePK().Select(); err != nil {
+			return err
+		}
+
+		session, err = issueRefreshToken(ctx, tx, user, current.FamilyID)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return session, nil
+}
+
+// EndSession revokes the session the refresh token belongs to. Unknown tokens are ignored.
+func EndSession(ctx context.Context, token string) error {
+	conn := db.GetConnection()
+
+	return conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		var current RefreshToken
+		err := tx.ModelContext(ctx, &current).Where("token_hash = ?", hashToken(token)).Select()
+		if errors.Is(err, pg.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return revokeSession(ctx, tx, current.FamilyID)
+	})
+}
+
+// issueRefreshToken stores a new refresh token of the session family and returns the plain token.
+func issueRefreshToken(ctx context.Context, conn orm.DB, user *User, familyID string) (*Session, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	refresh := &RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	}
+	if _, err := conn.ModelContext(ctx, refresh).Insert(); err != nil {
+		return nil, err
+	}
+
+	return &Session{User: user, RefreshToken: token, RefreshTokenExpiresAt: refresh.ExpiresAt}, nil
+}
+
+// revokeSession revokes every refresh token of the session family.
+func revokeSession(ctx context.Context, conn orm.DB, familyID string) error {
+	_, err := conn.ModelContext(ctx, (*RefreshToken)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update()
+
+	return err
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// uniqueViolation is the PostgreSQL error code raised when a unique constraint is violated.
+const uniqueViolation = "23505"
+
+// ErrDuplicateSpecialization is returned when a specialization with the same name already exists.
+var ErrDuplicateSpecialization = errors.New("a specialization with this name already exists")
+
+// Specialization represents the specializations table in the database.
+type Specialization struct {
+	ID        int       `json:"id" binding:"-" pg:",pk"`
+	Name      string    `json:"name" binding:"required,max=100" pg:",unique,notnull"`
+	CreatedBy int       `json:"created_by" binding:"-" pg:",notnull"`
+	UpdatedBy int       `json:"updated_by" binding:"-" pg:",notnull"`
+	CreatedAt time.Time `json:"created_at" binding:"-" pg:",default:now()"`
+	UpdatedAt time.Time `json:"updated_at" binding:"-" pg:",default:now()"`
+}
+
+// PsychologistSpecialization represents the psychologist_specializations table in the database.
+// It links a psychologist to one of their specializations.
+type PsychologistSpecialization struct {
+	ID               int       `json:"id" pg:",pk"`
+	PsychologistID   int       `json:"psychologist_id" pg:",notnull"`
+	SpecializationID int       `json:"specialization_id" pg:",notnull"`
+	CreatedBy        int       `json:"created_by" pg:",notnull"`
+	UpdatedBy        int       `json:"updated_by" pg:",notnull"`
+	CreatedAt        time.Time `json:"created_at" pg:",default:now()"`
+	UpdatedAt        time.Time `json:"updated_at" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the specializations table when INSERT query executes. It adds time in created_at and updated_at columns.
+func (s *Specialization) BeforeInsert(ctx context.Context) (context.Context, error) {
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+	s.CreatedBy = actingUserID(ctx)
+	s.UpdatedBy = s.CreatedBy
+
+	return ctx, nil
+}
+
+// BeforeUpdate is a method for performing additional changes to the specializations table when UPDATE query executes. It updates time in updated_at column.
+func (s *Specialization) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	s.UpdatedAt = time.Now()
+	s.UpdatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// BeforeInsert is a method for performing additional changes to the psychologist_specializations table when INSERT query executes. It adds time in created_at and updated_at columns.
+func (ps *PsychologistSpecialization) BeforeInsert(ctx context.Context) (context.Context, error) {
+	ps.CreatedAt = time.Now()
+	ps.UpdatedAt = ps.CreatedAt
+	ps.CreatedBy = actingUserID(ctx)
+	ps.UpdatedBy = ps.CreatedBy
+
+	return ctx, nil
+}
+
+// Create inserts a new specialization into the database.
+func (s *Specialization) Create(ctx context.Context) error {
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(s).Returning("*").Insert()
+
+	return duplicateSpecialization(err)
+}
+
+// GetByID retrieves a specialization by its ID.
+func (s *Specialization) GetByID(ctx context.Context) (*Specialization, error) {
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(s).WherePK().Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GetAllSpecializations retrieves all specializations ordered by name.
+func GetAllSpecializations(ctx context.Context) ([]Specialization, error) {
+	conn := db.GetConnection()
+	var specializations []Specialization
+	err := conn.WithContext(ctx).Model(&specializations).Order("name", "id").Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return specializations, nil
+}
+
+// Update modifies an existing specialization.
+func (s *Specialization) Update(ctx context.Context) error {
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(s).WherePK().Update()
+
+	return duplicateSpecialization(err)
+}
+
+// DeleteByID removes a specialization from the database by its ID. It is detached from every psychologist.
+func (s *Specialization) DeleteByID(ctx context.Context) error {
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(s).WherePK().Delete()
+
+	return err
+}
+
+// GetSpecializationsByPsychologist retrieves the specializations of a psychologist ordered by name.
+func GetSpecializationsByPsychologist(ctx context.Context, psychologistID int) ([]Specialization, error) {
+	conn := db.GetConnection()
+	var specializations []Specialization
+	err := conn.WithContext(ctx).Model(&specializations).
+		Join("JOIN psychologist_specializations AS ps ON ps.specialization_id = specialization.id").
+		Where("ps.psychologist_id = ?", psychologistID).
+		Order("specialization.name", "specialization.id").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return specializations, nil
+}
+
+// AttachSpecialization links a specialization to a psychologist. Attaching it again has no effect.
+func AttachSpecialization(ctx context.Context, psychologistID, specializationID int) error {
+	link := &PsychologistSpecialization{PsychologistID: psychologistID, SpecializationID: specializationID}
+
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model(link).OnConflict("(psychologist_id, specialization_id) DO NOTHING").Insert()
+
+	return err
+}
+
+// DetachSpecialization removes a specialization from a psychologist.
+func DetachSpecialization(ctx context.Context, psychologistID, specializationID int) error {
+	conn := db.GetConnection()
+	_, err := conn.WithContext(ctx).Model((*PsychologistSpecialization)(nil)).
+		Where("psychologist_id = ? AND specialization_id = ?", psychologistID, specializationID).
+		Delete()
+
+	return err
+}
+
+// duplicateSpecialization maps a unique violation on the specialization name to ErrDuplicateSpecialization.
+func duplicateSpecialization(err error) error {
+	var pgErr pg.Error
+	if errors.As(err, &pgErr) && pgErr.Field('C') == uniqueViolation {
+		return ErrDuplicateSpecialization
+	}
+
+	return err
+}
@@ -0,0 +1,280 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+)
+
+// Errors returned by account operations.
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrEmailTaken         = errors.New("an account with this email already exists")
+	ErrInvalidRole        = errors.New("role must be admin, psychologist or customer")
+	ErrProfileLink        = errors.New("psychologist accounts must be linked to a psychologist and customer accounts to a customer")
+	ErrProfileTaken       = errors.New("the psychologist or customer already has an account")
+	ErrUnknownProfile     = errors.New("the linked psychologist or customer does not exist")
+)
+
+// dummyPasswordHash is compared against when nobody has the email, so failed sign-ins take as long either way.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("psychologist_app"), bcrypt.DefaultCost)
+
+// User represents the users table in the database.
+// It is an account someone signs in with. Psychologist and customer accounts act as the record they are linked to.
+type User struct {
+	ID             int        `json:"id" pg:",pk"`
+	Email          string     `json:"email" pg:",unique,notnull"`
+	PasswordHash   string     `json:"-"`
+	Role           auth.Role  `json:"role" pg:",notnull"`
+	PsychologistID *int       `json:"psychologist_id"`
+	CustomerID     *int       `json:"customer_id"`
+	LastLoginAt    *time.Time `json:"last_login_at"`
+	CreatedBy      int        `json:"created_by" pg:",notnull"`
+	UpdatedBy      int        `json:"updated_by" pg:",notnull"`
+	CreatedAt      time.Time  `json:"created_at" pg:",default:now()"`
+	UpdatedAt      time.Time  `json:"updated_at" pg:",default:now()"`
+}
+
+// BeforeInsert is a method for performing additional changes to the users table when INSERT query executes. It adds time in created_at and updated_at columns.
+func (u *User) BeforeInsert(ctx context.Context) (context.Context, error) {
+	u.CreatedAt = time.Now()
+	u.UpdatedAt = u.CreatedAt
+	u.CreatedBy = actingUserID(ctx)
+	u.UpdatedBy = u.CreatedBy
+
+	return ctx, nil
+}
+
+// BeforeUpdate is a method for performing additional changes to the users table when UPDATE query executes. It updates time in updated_at column.
+func (u *User) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	u.UpdatedAt = time.Now()
+	u.UpdatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// actingUserID returns the ID of the signed in user a query runs for, or zero for anonymous callers and background jobs.
+func actingUserID(ctx context.Context) int {
+	return auth.FromContext(ctx).UserID
+}
+
+// NormalizeEmail trims and lower-cases an email address so accounts are found however it is typed.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Principal returns the principal requests signed in as the user run as.
+func (u *User) Principal() *auth.Principal {
+	principal := &auth.Principal{UserID: u.ID, Role: u.Role}
+	if u.PsychologistID != nil {
+		principal.PsychologistID = *u.PsychologistID
+	}
+	if u.CustomerID != nil {
+		principal.CustomerID = *u.CustomerID
+	}
+
+	return principal
+}
+
+// SetPassword stores a bcrypt hash of the password.
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.PasswordHash = string(hash)
+
+	return nil
+}
+
+// validate checks that the account is linked to exactly the record its role acts as.
+func (u *User) validate() error {
+	u.Email = NormalizeEmail(u.Email)
+
+	switch u.Role {
+	case auth.RoleAdmin:
+		if u.PsychologistID != nil || u.CustomerID != nil {
+			return ErrProfileLink
+		}
+	case auth.RolePsychologist:
+		if u.PsychologistID == nil || u.CustomerID != nil {
+			return ErrProfileLink
+		}
+	case auth.RoleCustomer:
+		if u.CustomerID == nil || u.PsychologistID != nil {
+			return ErrProfileLink
+		}
+	default:
+		return ErrInvalidRole
+	}
+
+	return nil
+}
+
+// insert stores the account using the given connection or transaction.
+func (u *User) insert(ctx context.Context, conn orm.DB) error {
+	if err := u.validate(); err != nil {
+		return err
+	}
+
+	_, err := conn.ModelContext(ctx, u).Returning("*").Insert()
+
+	return accountConflict(err)
+}
+
+// accountConflict turns constraint violations raised while storing an account into account errors.
+// Accounts, psychologists and customers all have unique emails.
+func accountConflict(err error) error {
+	var pgErr pg.Error
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Field('C') {
+	case uniqueViolation:
+		if strings.HasSuffix(pgErr.Field('n'), "_email_key") {
+			return ErrEmailTaken
+		}
+		return ErrProfileTaken
+	case foreignKeyViolation:
+		return ErrUnknownProfile
+	}
+
+	return err
+}
+
+// Create inserts a new account for an existing psychologist or customer, or a new administrator.
+func (u *User) Create(ctx context.Context, password string) error {
+	if err := u.SetPassword(password); err != nil {
+		return err
+	}
+
+	return u.insert(ctx, db.GetConnection())
+}
+
+// RegisterPsychologist creates a psychologist profile together with the account they sign in with.
+func RegisterPsychologist(ctx context.Context, u *User, password string, p *Psychologist) error {
+	if err := u.SetPassword(password); err != nil {
+		return err
+	}
+
+	conn := db.GetConnection()
+	err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := p.insert(ctx, tx); err != nil {
+			return err
+		}
+
+		u.Role = auth.RolePsychologist
+		u.PsychologistID = &p.ID
+
+		return u.insert(ctx, tx)
+	})
+
+	return accountConflict(err)
+}
+
+// RegisterCustomer creates a customer together with the account they sign in with.
+func RegisterCustomer(ctx context.Context, u *User, password string, c *Customer) error {
+	if err := u.SetPassword(password); err != nil {
+		return err
+	}
+
+	conn := db.GetConnection()
+	err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if _, err := tx.ModelContext(ctx, c).Returning("*").Insert(); err != nil {
+			return err
+		}
+
+		u.Role = auth.RoleCustomer
+		u.CustomerID = &c.ID
+
+		return u.insert(ctx, tx)
+	})
+
+	return accountConflict(err)
+}
+
+// GetByID retrieves an account by its ID.
+func (u *User) GetByID(ctx context.Context) (*User, error) {
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(u).WherePK().Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// Authenticate finds the account with the email and checks its password.
+// It returns ErrInvalidCredentials whether the email is unknown or the password is wrong.
+func Authenticate(ctx context.Context, email, password string) (*User, error) {
+	conn := db.GetConnection()
+	var user User
+	err := conn.WithContext(ctx).Model(&user).Where("email = ?", NormalizeEmail(email)).Select()
+	if errors.Is(err, pg.ErrNoRows) {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PasswordHash == "" || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	user.LastLoginAt = &now
+	if _, err := conn.WithContext(ctx).Model(&user).Column("last_login_at").WherePK().Update(); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// EnsureAdmin creates an administrator account with the email and password unless an account with the email exists.
+func EnsureAdmin(ctx context.Context, email, password string) error {
+	conn := db.GetConnection()
+	exists, err := conn.WithContext(ctx).Model((*User)(nil)).Where("email = ?", NormalizeEmail(email)).Exists()
+	if err != nil || exists {
+		return err
+	}
+
+	admin := &User{Email: email, Role: auth.RoleAdmin}
+
+	return admin.Create(ctx, password)
+}
+
+// userListSpec lists the fields account lists can be sorted and filtered by.
+var userListSpec = listSpec{
+	sortable: map[string]string{
+		"id":            "id",
+		"email":         "email",
+		"last_login_at": "last_login_at",
+		"created_at":    "created_at",
+	},
+	defaultSort: []SortField{{Field: "id"}},
+	filters: map[string]filterFunc{
+		"email": filterString("email"),
+		"role":  filterOneOf("role"),
+	},
+}
+
+// ListUsers retrieves a page of accounts and the number of accounts matching the filters.
+func ListUsers(ctx context.Context, params *ListParams) ([]User, int, error) {
+	var users []User
+	total, err := selectPage(newListQuery(ctx, &users), params, userListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
@@ -0,0 +1,570 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/db"
+	"github.com/vitalicher97/psychologist_app/internal/app/scheduling"
+)
+
+// WaitlistOfferHold is how long a freed slot is held for the customer it was offered to.
+var WaitlistOfferHold = 2 * time.Hour
+
+// WaitlistStatus is a state of a waitlist entry.
+type WaitlistStatus string
+
+// Waitlist entry states. Only waiting entries receive offers.
+const (
+	WaitlistWaiting   WaitlistStatus = "waiting"
+	WaitlistOffered   WaitlistStatus = "offered"
+	WaitlistBooked    WaitlistStatus = "booked"
+	WaitlistWithdrawn WaitlistStatus = "withdrawn"
+)
+
+// OfferStatus is a state of a waitlist offer.
+type OfferStatus string
+
+// Waitlist offer states.
+const (
+	OfferPending  OfferStatus = "pending"
+	OfferAccepted OfferStatus = "accepted"
+	OfferDeclined OfferStatus = "declined"
+	OfferExpired  OfferStatus = "expired"
+)
+
+// Errors returned by waitlist operations.
+var (
+	ErrInvalidWaitlistWindow = errors.New("each preferred window needs a day_of_week between 0 and 6 and an end_time after its start_time")
+	ErrAlreadyOnWaitlist     = errors.New("the customer is already on the psychologist's waitlist")
+	ErrEntryNotActive        = errors.New("the waitlist entry is no longer active")
+	ErrOfferNotPending       = errors.New("the offer has already been answered")
+	ErrOfferExpired          = errors.New("the offer has expired")
+	ErrSlotHeld              = errors.New("the requested time is held for a waitlist offer")
+)
+
+// WaitlistWindow is a weekly time range in which a waitlisted customer can attend a session.
+// Times are wall clock times in the psychologist's time zone.
+type WaitlistWindow struct {
+	DayOfWeek time.Weekday `json:"day_of_week"`
+	StartTime TimeOnly     `json:"start_time"`
+	EndTime   TimeOnly     `json:"end_time"`
+}
+
+// WaitlistEntry represents the waitlist_entries table in the database.
+// An entry without preferred windows matches any freed slot.
+type WaitlistEntry struct {
+	ID               int              `json:"id" binding:"-" pg:",pk"`
+	PsychologistID   int              `json:"psychologist_id" binding:"required" pg:",notnull"`
+	CustomerID       int              `json:"customer_id" binding:"required" pg:",notnull"`
+	PreferredWindows []WaitlistWindow `json:"preferred_windows" binding:"-" pg:",notnull"`
+	Note             string           `json:"note" binding:"-"`
+	Status           WaitlistStatus   `json:"status" binding:"-" pg:",notnull"`
+	CreatedBy        int              `json:"created_by" binding:"-" pg:",notnull"`
+	UpdatedBy        int              `json:"updated_by" binding:"-" pg:",notnull"`
+	CreatedAt        time.Time        `json:"created_at" binding:"-" pg:",default:now()"`
+	UpdatedAt        time.Time        `json:"updated_at" binding:"-" pg:",default:now()"`
+}
+
+// WaitlistOffer represents the waitlist_offers table in the database.
+// It records a freed slot offered to a waitlist entry and how the customer responded.
+type WaitlistOffer struct {
+	ID                  int         `json:"id" pg:",pk"`
+	EntryID             int         `json:"entry_id" pg:",notnull"`
+	PsychologistID      int         `json:"psychologist_id" pg:",notnull"`
+	CustomerID          int         `json:"customer_id" pg:",notnull"`
+	SourceAppointmentID int         `json:"source_appointment_id,omitempty"`
+	AppointmentID       int         `json:"appointment_id,omitempty"`
+	StartTime           time.Time   `json:"start_time" pg:",notnull"`
+	EndTime             time.Time   `json:"end_time" pg:",notnull"`
+	Status              OfferStatus `json:"status" pg:",notnull"`
+	ExpiresAt           time.Time   `json:"expires_at" pg:",notnull"`
+	RespondedAt         *time.Time  `json:"responded_at"`
+	CreatedAt           time.Time   `json:"created_at" pg:",default:now()"`
+	UpdatedAt           time.Time   `json:"updated_at" pg:",default:now()"`
+}
+
+// WaitlistStats summarises the offers made from a psychologist's waitlist.
+type WaitlistStats struct {
+	PsychologistID int     `json:"psychologist_id"`
+	Offered        int     `json:"offered"`
+	Pending        int     `json:"pending"`
+	Accepted       int     `json:"accepted"`
+	Declined       int     `json:"declined"`
+	Expired        int     `json:"expired"`
+	ConversionRate float64 `json:"conversion_rate"`
+}
+
+// BeforeInsert is a method for performing additional changes to the waitlist_entries table when INSERT query executes. It adds time in created_at and updated_at columns.
+func (e *WaitlistEntry) BeforeInsert(ctx context.Context) (context.Context, error) {
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+	e.CreatedBy = actingUserID(ctx)
+	e.UpdatedBy = e.CreatedBy
+
+	return ctx, nil
+}
+
+// BeforeUpdate is a method for performing additional changes to the waitlist_entries table when UPDATE query executes. It updates time in updated_at column.
+func (e *WaitlistEntry) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	e.UpdatedAt = time.Now()
+	e.UpdatedBy = actingUserID(ctx)
+
+	return ctx, nil
+}
+
+// BeforeInsert is a method for performing additional changes to the waitlist_offers table when INSERT query executes. It adds time in created_at and updated_at columns.
+func (o *WaitlistOffer) BeforeInsert(ctx context.Context) (context.Context, error) {
+	o.CreatedAt = time.Now()
+	o.UpdatedAt = o.CreatedAt
+
+	return ctx, nil
+}
+
+// BeforeUpdate is a method for performing additional changes to the waitlist_offers table when UPDATE query executes. It updates time in updated_at column.
+func (o *WaitlistOffer) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	o.UpdatedAt = time.Now()
+
+	return ctx, nil
+}
+
+// Validate checks the preferred windows of the entry.
+func (e *WaitlistEntry) Validate() error {
+	for _, w := range e.PreferredWindows {
+		if w.DayOfWeek < time.Sunday || w.DayOfWeek > time.Saturday || !w.EndTime.After(w.StartTime.Time) {
+			return ErrInvalidWaitlistWindow
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether a session in the interval falls within one of the entry's preferred windows,
+// interpreted in the psychologist's time zone.
+func (e *WaitlistEntry) Matches(iv scheduling.Interval, loc *time.Location) bool {
+	if len(e.PreferredWindows) == 0 {
+		return true
+	}
+
+	preferences := &scheduling.Schedule{Location: loc}
+	for _, w := range e.PreferredWindows {
+		preferences.Weekly = append(preferences.Weekly, scheduling.WeeklyRule{
+			Weekday: w.DayOfWeek,
+			Start:   w.StartTime.Offset(),
+			End:     w.EndTime.Offset(),
+		})
+	}
+
+	return preferences.Covers(iv)
+}
+
+// Create adds the customer to the psychologist's waitlist.
+func (e *WaitlistEntry) Create(ctx context.Context) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	if e.PreferredWindows == nil {
+		e.PreferredWindows = []WaitlistWindow{}
+	}
+	e.Status = WaitlistWaiting
+
+	conn := db.GetConnection()
+	active, err := conn.WithContext(ctx).Model((*WaitlistEntry)(nil)).
+		Where("psychologist_id = ? AND customer_id = ?", e.PsychologistID, e.CustomerID).
+		Where("status IN (?)", pg.In([]WaitlistStatus{WaitlistWaiting, WaitlistOffered})).
+		Exists()
+	if err != nil {
+		return err
+	}
+	if active {
+		return ErrAlreadyOnWaitlist
+	}
+
+	_, err = conn.WithContext(ctx).Model(e).Insert()
+
+	return err
+}
+
+// GetByID retrieves a waitlist entry by its ID.
+func (e *WaitlistEntry) GetByID(ctx context.Context) (*WaitlistEntry, error) {
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(e).WherePK().Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// GetWaitlistEntries retrieves the waitlist entries of a psychologist or a customer in the order they were added.
+// A zero ID does not filter.
+func GetWaitlistEntries(ctx context.Context, psychologistID, customerID int) ([]WaitlistEntry, error) {
+	conn := db.GetConnection()
+	var entries []WaitlistEntry
+	query := conn.WithContext(ctx).Model(&entries).Order("created_at", "id")
+	if psychologistID != 0 {
+		query.Where("psychologist_id = ?", psychologistID)
+	}
+	if customerID != 0 {
+		query.Where("customer_id = ?", customerID)
+	}
+	if err := query.Select(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Withdraw takes the entry off the waitlist. A pending offer is declined and passed on to the next entry.
+func (e *WaitlistEntry) Withdraw(ctx context.Context) error {
+	var declined []WaitlistOffer
+
+	conn := db.GetConnection()
+	err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := tx.ModelContext(ctx, e).WherePK().For("UPDATE").Select(); err != nil {
+			return err
+		}
+		if e.Status != WaitlistWaiting && e.Status != WaitlistOffered {
+			return ErrEntryNotActive
+		}
+
+		e.Status = WaitlistWithdrawn
+		if _, err := tx.ModelContext(ctx, e).Column("status", "updated_at", "updated_by").WherePK().Update(); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		_, err := tx.ModelContext(ctx, &declined).
+			Set("status = ?", OfferDeclined).
+			Set("responded_at = ?", now).
+			Set("updated_at = ?", now).
+			Where("entry_id = ? AND status = ?", e.ID, OfferPending).
+			Returning("*").
+			Update()
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range declined {
+		passOn(ctx, &declined[i])
+	}
+
+	return nil
+}
+
+// GetByID retrieves a waitlist offer by its ID.
+func (o *WaitlistOffer) GetByID(ctx context.Context) (*WaitlistOffer, error) {
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model(o).WherePK().Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// GetWaitlistOffers retrieves the offers made to a psychologist's or a customer's waitlist entries, newest first.
+// A zero ID does not filter.
+func GetWaitlistOffers(ctx context.Context, psychologistID, customerID int) ([]WaitlistOffer, error) {
+	conn := db.GetConnection()
+	var offers []WaitlistOffer
+	query := conn.WithContext(ctx).Model(&offers).Order("created_at DESC", "id DESC")
+	if psychologistID != 0 {
+		query.Where("psychologist_id = ?", psychologistID)
+	}
+	if customerID != 0 {
+		query.Where("customer_id = ?", customerID)
+	}
+	if err := query.Select(); err != nil {
+		return nil, err
+	}
+
+	return offers, nil
+}
+
+// GetWaitlistStats counts the offers made from a psychologist's waitlist by outcome.
+// The conversion rate is the share of answered offers that were accepted.
+func GetWaitlistStats(ctx context.Context, psychologistID int) (*WaitlistStats, error) {
+	var counts []struct {
+		Status OfferStatus
+		Count  int
+	}
+
+	conn := db.GetConnection()
+	err := conn.WithContext(ctx).Model((*WaitlistOffer)(nil)).
+		Column("status").
+		ColumnExpr("count(*) AS count").
+		Where("psychologist_id = ?", psychologistID).
+		Group("status").
+		Select(&counts)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &WaitlistStats{PsychologistID: psychologistID}
+	for _, c := range counts {
+		stats.Offered += c.Count
+		switch c.Status {
+		case OfferPending:
+			stats.Pending = c.Count
+		case OfferAccepted:
+			stats.Accepted = c.Count
+		case OfferDeclined:
+			stats.Declined = c.Count
+		case OfferExpired:
+			stats.Expired = c.Count
+		}
+	}
+	if answered := stats.Offered - stats.Pending; answered > 0 {
+		stats.ConversionRate = float64(stats.Accepted) / float64(answered)
+	}
+
+	return stats, nil
+}
+
+// Accept books the offered slot for the customer in the chosen modality and closes the entry.
+func (o *WaitlistOffer) Accept(ctx context.Context, modality Modality, officeLocationID int) (*Appointment, error) {
+	if _, err := o.GetByID(ctx); err != nil {
+		return nil, err
+	}
+
+	appointment := &Appointment{
+		PsychologistID:   o.PsychologistID,
+		CustomerID:       o.CustomerID,
+		StartTime:        o.StartTime,
+		EndTime:          o.EndTime,
+		Modality:         modality,
+		OfficeLocationID: officeLocationID,
+		Status:           StatusRequested,
+	}
+
+	err := appointment.saveInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := o.lockPending(ctx, tx); err != nil {
+			return err
+		}
+
+		if err := appointment.insert(ctx, tx); err != nil {
+			return err
+		}
+
+		o.AppointmentID = appointment.ID
+		if err := o.respond(ctx, tx, OfferAccepted); err != nil {
+			return err
+		}
+
+		return setEntryStatus(ctx, tx, o.EntryID, WaitlistBooked)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return appointment, nil
+}
+
+// Decline turns the offer down, puts the entry back on the waitlist and offers the slot to the next entry.
+func (o *WaitlistOffer) Decline(ctx context.Context) error {
+	conn := db.GetConnection()
+	err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if err := o.lockPending(ctx, tx); err != nil {
+			return err
+		}
+
+		if err := o.respond(ctx, tx, OfferDeclined); err != nil {
+			return err
+		}
+
+		return setEntryStatus(ctx, tx, o.EntryID, WaitlistWaiting)
+	})
+	if err != nil {
+		return err
+	}
+
+	passOn(ctx, o)
+
+	return nil
+}
+
+// ExpireWaitlistOffers closes the offers whose hold has run out and passes their slots on. It is meant to run periodically.
+func ExpireWaitlistOffers(ctx context.Context) error {
+	conn := db.GetConnection()
+	var offers []WaitlistOffer
+	err := conn.WithContext(ctx).Model(&offers).
+		Where("status = ? AND expires_at <= ?", OfferPending, time.Now()).
+		Order("expires_at", "id").
+		Select()
+	if err != nil {
+		return err
+	}
+
+	for i := range offers {
+		offer := &offers[i]
+		err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+			if err := tx.ModelContext(ctx, offer).WherePK().For("UPDATE").Select(); err != nil {
+				return err
+			}
+			if offer.Status != OfferPending {
+				return ErrOfferNotPending
+			}
+
+			if err := offer.respond(ctx, tx, OfferExpired); err != nil {
+				return err
+			}
+
+			return setEntryStatus(ctx, tx, offer.EntryID, WaitlistWaiting)
+		})
+		if errors.Is(err, ErrOfferNotPending) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to expire waitlist offer %d: %v", offer.ID, err)
+			continue
+		}
+
+		passOn(ctx, offer)
+	}
+
+	return nil
+}
+
+// lockPending reloads the offer for update and ensures it can still be answered.
+func (o *WaitlistOffer) lockPending(ctx context.Context, tx *pg.Tx) error {
+	if err := tx.ModelContext(ctx, o).WherePK().For("UPDATE").Select(); err != nil {
+		return err
+	}
+	if o.Status != OfferPending {
+		return ErrOfferNotPending
+	}
+	if !time.Now().Before(o.ExpiresAt) {
+		return ErrOfferExpired
+	}
+
+	return nil
+}
+
+// respond records the outcome of the offer.
+func (o *WaitlistOffer) respond(ctx context.Context, tx *pg.Tx, status OfferStatus) error {
+	now := time.Now()
+	o.Status = status
+	o.RespondedAt = &now
+
+	_, err := tx.ModelContext(ctx, o).Column("status", "appointment_id", "responded_at", "updated_at").WherePK().Update()
+
+	return err
+}
+
+// setEntryStatus moves a waitlist entry to the given state.
+func setEntryStatus(ctx context.Context, tx *pg.Tx, entryID int, status WaitlistStatus) error {
+	entry := &WaitlistEntry{ID: entryID, Status: status}
+	_, err := tx.ModelContext(ctx, entry).Column("status", "updated_at").WherePK().Update()
+
+	return err
+}
+
+// offerFreedSlot offers the time of a cancelled or deleted appointment to the waitlist.
+// Failures are logged rather than returned, so they never undo the cancellation.
+func offerFreedSlot(ctx context.Context, a *Appointment) {
+	slot := scheduling.Interval{Start: a.StartTime, End: a.EndTime}
+	if _, err := OfferSlot(ctx, a.PsychologistID, slot, a.ID); err != nil {
+		log.Printf("Failed to offer the slot of appointment %d to the waitlist: %v", a.ID, err)
+	}
+}
+
+// passOn offers the slot of an answered offer to the next entry on the waitlist.
+func passOn(ctx context.Context, o *WaitlistOffer) {
+	slot := scheduling.Interval{Start: o.StartTime, End: o.EndTime}
+	if _, err := OfferSlot(ctx, o.PsychologistID, slot, o.SourceAppointmentID); err != nil {
+		log.Printf("Failed to pass on the slot of waitlist offer %d: %v", o.ID, err)
+	}
+}
+
+// OfferSlot offers a free slot of the psychologist to the longest-waiting entry whose preferred windows
+// match it and that has not been offered the same slot before. The slot is held for the customer until
+// the offer expires. It returns nil when the slot is in the past, no longer bookable or nobody matches.
+func OfferSlot(ctx context.Context, psychologistID int, slot scheduling.Interval, sourceAppointmentID int) (*WaitlistOffer, error) {
+	if !slot.Start.After(time.Now()) {
+		return nil, nil
+	}
+
+	var offer *WaitlistOffer
+
+	conn := db.GetConnection()
+	err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		// Serialize with bookings of the same psychologist, so the slot cannot be taken meanwhile.
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?, ?)", psychologistBookingLock, psychologistID); err != nil {
+			return err
+		}
+
+		schedule, err := LoadSchedule(ctx, psychologistID, slot.Start, slot.End)
+		if err != nil {
+			return err
+		}
+		if !schedule.Bookable(slot) {
+			return nil
+		}
+
+		var entries []WaitlistEntry
+		err = tx.ModelContext(ctx, &entries).
+			Where("psychologist_id = ? AND status = ?", psychologistID, WaitlistWaiting).
+			Where("NOT EXISTS (SELECT 1 FROM waitlist_offers AS o WHERE o.entry_id = waitlist_entry.id AND o.start_time = ? AND o.end_time = ?)", slot.Start, slot.End).
+			Where(`NOT EXISTS (SELECT 1 FROM appointments AS a WHERE a.customer_id = waitlist_entry.customer_id
+				AND a.status NOT IN (?) AND a.start_time < ? AND a.end_time > ?)`, pg.In(cancelledStatuses), slot.End, slot.Start).
+			Order("created_at", "id").
+			For("UPDATE").
+			Select()
+		if err != nil {
+			return err
+		}
+
+		for i := range entries {
+			if !entries[i].Matches(slot, schedule.Location) {
+				continue
+			}
+
+			offer = &WaitlistOffer{
+				EntryID:             entries[i].ID,
+				PsychologistID:      psychologistID,
+				CustomerID:          entries[i].CustomerID,
+				SourceAppointmentID: sourceAppointmentID,
+				StartTime:           slot.Start,
+				EndTime:             slot.End,
+				Status:              OfferPending,
+				ExpiresAt:           time.Now().Add(WaitlistOfferHold),
+			}
+			if _, err := tx.ModelContext(ctx, offer).Insert(); err != nil {
+				return err
+			}
+
+			return setEntryStatus(ctx, tx, entries[i].ID, WaitlistOffered)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return offer, nil
+}
+
+// GetHeldSlotsByPsychologistInRange retrieves the pending offers of a psychologist that overlap the [from, to) window.
+func GetHeldSlotsByPsychologistInRange(ctx context.Context, psychologistID int, from, to time.Time) ([]WaitlistOffer, error) {
+	conn := db.GetConnection()
+	var offers []WaitlistOffer
+	err := conn.WithContext(ctx).Model(&offers).
+		Where("psychologist_id = ? AND status = ? AND expires_at > ?", psychologistID, OfferPending, time.Now()).
+		Where("start_time < ? AND end_time > ?", to, from).
+		Order("start_time").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	return offers, nil
+}
@@ -0,0 +1,161 @@
+// Package ical reads and writes calendars in the iCalendar format described by RFC 5545.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Event statuses.
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+// maxLineLength is the number of octets after which content lines are folded.
+const maxLineLength = 75
+
+// Layouts of DATE and UTC DATE-TIME values.
+const (
+	dateFormat = "20060102"
+	utcFormat  = "20060102T150405Z"
+)
+
+// Event is a single VEVENT.
+type Event struct {
+	UID          string
+	Sequence     int
+	Start        time.Time
+	End          time.Time
+	Summary      string
+	Description  string
+	Status       string
+	AllDay       bool
+	Transparent  bool
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID time.Time
+	Created      time.Time
+	LastModified time.Time
+}
+
+// Calendar is a VCALENDAR holding a list of events.
+type Calendar struct {
+	ProductID string
+	Name      string
+	Events    []Event
+}
+
+// Encode writes the calendar to w.
+func (c *Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	e := &encoder{w: bw}
+
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", c.ProductID)
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		e.line("X-WR-CALNAME", escape(c.Name))
+	}
+
+	for _, event := range c.Events {
+		e.line("BEGIN", "VEVENT")
+		e.line("UID", escape(event.UID))
+		e.line("DTSTAMP", formatTime(event.LastModified))
+		if event.AllDay {
+			e.line("DTSTART;VALUE=DATE", event.Start.Format(dateFormat))
+			e.line("DTEND;VALUE=DATE", event.End.Format(dateFormat))
+		} else {
+			e.line("DTSTART", formatTime(event.Start))
+			e.line("DTEND", formatTime(event.End))
+		}
+		e.line("SEQUENCE", fmt.Sprint(event.Sequence))
+		if event.RRule != "" {
+			e.line("RRULE", event.RRule)
+		}
+		e.line("SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			e.line("DESCRIPTION", escape(event.Description))
+		}
+		if event.Status != "" {
+			e.line("STATUS", event.Status)
+		}
+		if event.Transparent {
+			e.line("TRANSP", "TRANSPARENT")
+		}
+		if !event.Created.IsZero() {
+			e.line("CREATED", formatTime(event.Created))
+		}
+		if !event.LastModified.IsZero() {
+			e.line("LAST-MODIFIED", formatTime(event.LastModified))
+		}
+		e.line("END", "VEVENT")
+	}
+
+	e.line("END", "VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+
+	return bw.Flush()
+}
+
+// encoder writes folded content lines and remembers the first error.
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes a "NAME:value" content line, folding it when it is too long.
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+
+	_, e.err = e.w.WriteString(fold(name + ":" + value))
+}
+
+// fold splits a content line into chunks of at most maxLineLength octets without breaking UTF-8 sequences.
+// Continuation lines start with a single space.
+func fold(line string) string {
+	var b strings.Builder
+
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > maxLineLength {
+			b.WriteString("\r\n ")
+			// The leading space counts towards the length of the continuation line.
+			length = 1
+		}
+		b.WriteRune(r)
+		length += size
+	}
+	b.WriteString("\r\n")
+
+	return b.String()
+}
+
+// escape escapes a TEXT value.
+func escape(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+
+	return replacer.Replace(text)
+}
+
+// formatTime formats t as a UTC date-time value.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(utcFormat)
+}
@@ -0,0 +1,139 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestCalendarEncode(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.FixedZone("EET", 2*60*60))
+	modified := time.Date(2026, time.February, 1, 12, 30, 0, 0, time.UTC)
+
+	calendar := &Calendar{
+		ProductID: "-//Psychologist App//Appointments//EN",
+		Name:      "Sessions, weekly",
+		Events: []Event{{
+			UID:          "appointment-1@psychologist-app",
+			Sequence:     2,
+			Start:        start,
+			End:          start.Add(time.Hour),
+			Summary:      "Session; online",
+			Description:  "Line one\nLine two",
+			Status:       StatusConfirmed,
+			Created:      modified,
+			LastModified: modified,
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := calendar.Encode(&buf); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Psychologist App//Appointments//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		`X-WR-CALNAME:Sessions\, weekly`,
+		"BEGIN:VEVENT",
+		"UID:appointment-1@psychologist-app",
+		"DTSTAMP:20260201T123000Z",
+		"DTSTART:20260302T080000Z",
+		"DTEND:20260302T090000Z",
+		"SEQUENCE:2",
+		`SUMMARY:Session\; online`,
+		`DESCRIPTION:Line one\nLine two`,
+		"STATUS:CONFIRMED",
+		"CREATED:20260201T123000Z",
+		"LAST-MODIFIED:20260201T123000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if got := buf.String(); got != want {
+		t.Errorf("Encode wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCalendarEncodeAllDayEvent(t *testing.T) {
+	day := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+	calendar := &Calendar{Events: []Event{{UID: "1", Start: day, End: day.AddDate(0, 0, 1), AllDay: true, Transparent: true}}}
+
+	var buf bytes.Buffer
+	if err := calendar.Encode(&buf); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	for _, line := range []string{"DTSTART;VALUE=DATE:20260302\r\n", "DTEND;VALUE=DATE:20260303\r\n", "TRANSP:TRANSPARENT\r\n"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("Encode output lacks %q", line)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "plain", want: "plain"},
+		{in: `back\slash`, want: `back\\slash`},
+		{in: "a;b,c", want: `a\;b\,c`},
+		{in: "one\r\ntwo\nthree", want: `one\ntwo\nthree`},
+	}
+
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "short", line: "SUMMARY:Session"},
+		{name: "exactly the limit", line: "SUMMARY:" + strings.Repeat("a", maxLineLength-len("SUMMARY:"))},
+		{name: "long", line: "DESCRIPTION:" + strings.Repeat("abcdefghij", 20)},
+		{name: "multi-byte", line: "DESCRIPTION:" + strings.Repeat("привіт ", 30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folded := fold(tt.line)
+			if !strings.HasSuffix(folded, "\r\n") {
+				t.Fatalf("fold(%q) does not end with CRLF", tt.line)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+			for i, line := range lines {
+				if len(line) > maxLineLength {
+					t.Errorf("line %d is %d octets long", i, len(line))
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a UTF-8 sequence", i)
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("continuation line %d does not start with a space", i)
+				}
+			}
+
+			unfolded := lines[0]
+			for _, line := range lines[1:] {
+				unfolded += line[1:]
+			}
+			if unfolded != tt.line {
+				t.Errorf("unfolding gives %q, want %q", unfolded, tt.line)
+			}
+			if len(tt.line) <= maxLineLength && len(lines) != 1 {
+				t.Errorf("a %d octet line was folded", len(tt.line))
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCalendar is returned when the input is not an iCalendar stream.
+var ErrInvalidCalendar = errors.New("the file is not a valid iCalendar file")
+
+// durationPattern matches the subset of RFC 5545 durations used by calendar applications.
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// property is a parsed content line.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads the events of an iCalendar stream. Floating and all-day times are interpreted in loc.
+// Events without a start are skipped.
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, ErrInvalidCalendar
+	}
+
+	var events []Event
+	var current *Event
+	var duration time.Duration
+	depth := 0
+	for _, line := range lines {
+		prop, ok := parseLine(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			current = &Event{}
+			duration = 0
+			depth = 0
+			continue
+		case current == nil:
+			continue
+		case prop.name == "BEGIN":
+			// Nested components such as VALARM are not needed.
+			depth++
+			continue
+		case prop.name == "END" && depth > 0:
+			depth--
+			continue
+		case depth > 0:
+			continue
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if current.End.IsZero() {
+				current.End = current.Start.Add(duration)
+			}
+			if !current.Start.IsZero() {
+				events = append(events, *current)
+			}
+			current = nil
+			continue
+		}
+
+		switch prop.name {
+		case "UID":
+			current.UID = prop.value
+		case "SUMMARY":
+			current.Summary = unescape(prop.value)
+		case "DESCRIPTION":
+			current.Description = unescape(prop.value)
+		case "STATUS":
+			current.Status = strings.ToUpper(prop.value)
+		case "TRANSP":
+			current.Transparent = strings.EqualFold(prop.value, "TRANSPARENT")
+		case "RRULE":
+			current.RRule = prop.value
+		case "EXDATE":
+			for _, value := range strings.Split(prop.value, ",") {
+				exdate, _, err := parseTime(property{name: prop.name, params: prop.params, value: value}, loc)
+				if err != nil {
+					return nil, err
+				}
+				current.ExDates = append(current.ExDates, exdate)
+			}
+		case "RECURRENCE-ID":
+			current.RecurrenceID, _, err = parseTime(prop, loc)
+			if err != nil {
+				return nil, err
+			}
+		case "SEQUENCE":
+			current.Sequence, _ = strconv.Atoi(prop.value)
+		case "DTSTART":
+			current.Start, current.AllDay, err = parseTime(prop, loc)
+			if err != nil {
+				return nil, err
+			}
+			if current.AllDay && duration == 0 {
+				duration = 24 * time.Hour
+			}
+		case "DTEND":
+			current.End, _, err = parseTime(prop, loc)
+			if err != nil {
+				return nil, err
+			}
+		case "DURATION":
+			duration, err = parseDuration(prop.value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// unfold reads the content lines of the stream, joining folded continuation lines.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseLine splits a content line into its name, parameters and value.
+func parseLine(line string) (property, bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return property{}, false
+	}
+
+	parts := strings.Split(head, ";")
+	prop := property{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: value}
+	for _, param := range parts[1:] {
+		name, paramValue, ok := strings.Cut(param, "=")
+		if ok {
+			prop.params[strings.ToUpper(name)] = strings.Trim(paramValue, `"`)
+		}
+	}
+
+	return prop, true
+}
+
+// parseTime parses a DATE or DATE-TIME property, reporting whether it is a whole-day date.
+func parseTime(prop property, loc *time.Location) (time.Time, bool, error) {
+	value := prop.value
+
+	if prop.params["VALUE"] == "DATE" || len(value) == len(dateFormat) {
+		t, err := time.ParseInLocation(dateFormat, value, loc)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcFormat, value)
+		return t, false, err
+	}
+
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if tzLoc, err := time.LoadLocation(tzid); err == nil {
+			loc = tzLoc
+		}
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+
+	return t, false, err
+}
+
+// parseDuration parses a DURATION value such as "PT1H30M" or "P1D".
+func parseDuration(value string) (time.Duration, error) {
+	match := durationPattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, ErrInvalidCalendar
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var duration time.Duration
+	for i, unit := range units {
+		if match[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(match[i+2])
+		if err != nil {
+			return 0, ErrInvalidCalendar
+		}
+		duration += time.Duration(n) * unit
+	}
+
+	if match[1] == "-" {
+		duration = -duration
+	}
+
+	return duration, nil
+}
+
+// unescape reverses the escaping of a TEXT value.
+func unescape(text string) string {
+	replacer := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+	return replacer.Replace(text)
+}
@@ -0,0 +1,211 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// calendar wraps content lines in a VCALENDAR and joins them with CRLF.
+func calendar(lines ...string) string {
+	lines = append(append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...), "END:VCALENDAR")
+
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func TestParse(t *testing.T) {
+	kyiv := time.FixedZone("Kyiv", 2*60*60)
+	start := time.Date(2026, time.March, 2, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Event
+	}{
+		{
+			name:  "UTC times",
+			input: calendar("BEGIN:VEVENT", "UID:1", "DTSTART:20260302T080000Z", "DTEND:20260302T090000Z", "END:VEVENT"),
+			want:  []Event{{UID: "1", Start: start, End: start.Add(time.Hour)}},
+		},
+		{
+			name:  "floating times are local",
+			input: calendar("BEGIN:VEVENT", "UID:1", "DTSTART:20260302T100000", "DTEND:20260302T110000", "END:VEVENT"),
+			want:  []Event{{UID: "1", Start: time.Date(2026, time.March, 2, 10, 0, 0, 0, kyiv), End: time.Date(2026, time.March, 2, 11, 0, 0, 0, kyiv)}},
+		},
+		{
+			name:  "duration",
+			input: calendar("BEGIN:VEVENT", "UID:1", "DTSTART:20260302T080000Z", "DURATION:PT1H30M", "END:VEVENT"),
+			want:  []Event{{UID: "1", Start: start, End: start.Add(90 * time.Minute)}},
+		},
+		{
+			name:  "all-day event lasts a day",
+			input: calendar("BEGIN:VEVENT", "UID:1", "DTSTART;VALUE=DATE:20260302", "END:VEVENT"),
+			want: []Event{{
+				UID:    "1",
+				Start:  time.Date(2026, time.March, 2, 0, 0, 0, 0, kyiv),
+				End:    time.Date(2026, time.March, 3, 0, 0, 0, 0, kyiv),
+				AllDay: true,
+			}},
+		},
+		{
+			name: "text, status and transparency",
+			input: calendar("BEGIN:VEVENT", "UID:1", "DTSTART:20260302T080000Z", "DTEND:20260302T090000Z",
+				`SUMMARY:Team\, weekly\; room 2`, `DESCRIPTION:one\ntwo`, "STATUS:tentative", "TRANSP:TRANSPARENT",
+				"SEQUENCE:3", "RRULE:FREQ=WEEKLY;COUNT=2", "END:VEVENT"),
+			want: []Event{{
+				UID: "1", Start: start, End: start.Add(time.Hour), Summary: "Team, weekly; room 2", Description: "one\ntwo",
+				Status: StatusTentative, Transparent: true, Sequence: 3, RRule: "FREQ=WEEKLY;COUNT=2",
+			}},
+		},
+		{
+			name: "excluded dates and overrides",
+			input: calendar("BEGIN:VEVENT", "UID:1", "DTSTART:20260302T080000Z", "DTEND:20260302T090000Z", "RRULE:FREQ=DAILY;COUNT=5",
+				"EXDATE:20260303T080000Z,20260304T080000Z", "EXDATE:20260305T080000Z", "END:VEVENT",
+				"BEGIN:VEVENT", "UID:1", "RECURRENCE-ID:20260306T080000Z", "DTSTART:20260306T120000Z", "DTEND:20260306T130000Z", "END:VEVENT"),
+			want: []Event{
+				{
+					UID: "1", Start: start, End: start.Add(time.Hour), RRule: "FREQ=DAILY;COUNT=5",
+					ExDates: []time.Time{start.AddDate(0, 0, 1), start.AddDate(0, 0, 2), start.AddDate(0, 0, 3)},
+				},
+				{UID: "1", Start: start.AddDate(0, 0, 4).Add(4 * time.Hour), End: start.AddDate(0, 0, 4).Add(5 * time.Hour), RecurrenceID: start.AddDate(0, 0, 4)},
+			},
+		},
+		{
+			name:  "folded lines",
+			input: calendar("BEGIN:VEVENT", "UID:1", "DTSTART:20260302T080000Z", "DTEND:20260302T090000Z", "SUMMARY:Long", "  summary", "END:VEVENT"),
+			want:  []Event{{UID: "1", Start: start, End: start.Add(time.Hour), Summary: "Long summary"}},
+		},
+		{
+			name: "nested alarms are ignored",
+			input: calendar("BEGIN:VEVENT", "UID:1", "DTSTART:20260302T080000Z", "BEGIN:VALARM", "TRIGGER:-PT15M",
+				"DTSTART:20260101T000000Z", "END:VALARM", "DTEND:20260302T090000Z", "END:VEVENT"),
+			want: []Event{{UID: "1", Start: start, End: start.Add(time.Hour)}},
+		},
+		{
+			name:  "events without a start are skipped",
+			input: calendar("BEGIN:VEVENT", "UID:1", "SUMMARY:No start", "END:VEVENT"),
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.input), kyiv)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Parse returned %d events, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if !got[i].Start.Equal(tt.want[i].Start) || !got[i].End.Equal(tt.want[i].End) {
+					t.Errorf("event %d runs %v to %v, want %v to %v", i, got[i].Start, got[i].End, tt.want[i].Start, tt.want[i].End)
+				}
+				got[i].Start, got[i].End = tt.want[i].Start, tt.want[i].End
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("event %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseTimeZoneID(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	input := calendar("BEGIN:VEVENT", "UID:1", `DTSTART;TZID="Europe/Berlin":20260302T100000`, "DURATION:PT1H", "END:VEVENT")
+	events, err := Parse(strings.NewReader(input), time.UTC)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := time.Date(2026, time.March, 2, 10, 0, 0, 0, berlin)
+	if len(events) != 1 || !events[0].Start.Equal(want) {
+		t.Errorf("Parse = %+v, want a start at %v", events, want)
+	}
+}
+
+func TestParseRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty", input: ""},
+		{name: "not a calendar", input: "<html></html>\r\n"},
+		{name: "bad start", input: calendar("BEGIN:VEVENT", "DTSTART:tomorrow", "END:VEVENT")},
+		{name: "bad duration", input: calendar("BEGIN:VEVENT", "DTSTART:20260302T080000Z", "DURATION:1 hour", "END:VEVENT")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.input), time.UTC); err == nil {
+				t.Error("Parse succeeded, want an error")
+			}
+		})
+	}
+
+	if _, err := Parse(strings.NewReader("hello\r\n"), time.UTC); !errors.Is(err, ErrInvalidCalendar) {
+		t.Errorf("Parse error = %v, want ErrInvalidCalendar", err)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "PT15M", want: 15 * time.Minute},
+		{value: "PT1H30M", want: 90 * time.Minute},
+		{value: "P1D", want: 24 * time.Hour},
+		{value: "P1W", want: 7 * 24 * time.Hour},
+		{value: "P1DT2H3M4S", want: 26*time.Hour + 3*time.Minute + 4*time.Second},
+		{value: "-PT15M", want: -15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDuration(tt.value)
+		if err != nil {
+			t.Errorf("parseDuration(%q): %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseDuration(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	if _, err := parseDuration("15 minutes"); !errors.Is(err, ErrInvalidCalendar) {
+		t.Errorf("parseDuration error = %v, want ErrInvalidCalendar", err)
+	}
+}
+
+func TestEncodeParseRoundTrip(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 8, 0, 0, 0, time.UTC)
+	want := Event{
+		UID:         "appointment-7@psychologist-app",
+		Sequence:    1,
+		Start:       start,
+		End:         start.Add(50 * time.Minute),
+		Summary:     "Session with Dr. Kovalenko, room 3; bring notes",
+		Description: strings.Repeat("A long description that has to be folded. ", 5) + "\nSecond line",
+		Status:      StatusCancelled,
+	}
+
+	var buf bytes.Buffer
+	if err := (&Calendar{ProductID: "-//Test//EN", Events: []Event{want}}).Encode(&buf); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	events, err := Parse(&buf, time.UTC)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(events) != 1 || !reflect.DeepEqual(events[0], want) {
+		t.Errorf("Parse = %+v, want %+v", events, want)
+	}
+}
@@ -0,0 +1,198 @@
+package ical
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxOccurrences caps how many occurrences a single event expands to within a window.
+const maxOccurrences = 1000
+
+// ErrUnsupportedRule is returned when a recurrence rule uses parts that cannot be expanded.
+var ErrUnsupportedRule = errors.New("only daily and weekly recurrence rules can be expanded")
+
+// weekdays maps the two-letter weekday codes of RFC 5545 to weekdays.
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Rule is the part of an RFC 5545 RRULE that calendar applications send for repeating busy time:
+// daily or weekly events, optionally on several weekdays, bounded by a count, an end time or neither.
+type Rule struct {
+	Weekly    bool
+	Interval  int
+	Count     int
+	Until     time.Time
+	ByDay     []time.Weekday
+	WeekStart time.Weekday
+}
+
+// ParseRule parses a rule such as "FREQ=WEEKLY;WKST=MO;BYDAY=MO,WE" or "FREQ=DAILY;UNTIL=20261231T000000Z".
+// A leading "RRULE:" is accepted. A floating or date UNTIL is read in loc, the time zone of the event start.
+func ParseRule(value string, loc *time.Location) (Rule, error) {
+	r := Rule{Interval: 1, WeekStart: time.Monday}
+
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	frequency := ""
+	for _, part := range strings.Split(value, ";") {
+		name, partValue, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, ErrUnsupportedRule
+		}
+
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			frequency = strings.ToUpper(partValue)
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(partValue)
+		case "COUNT":
+			r.Count, err = strconv.Atoi(partValue)
+		case "UNTIL":
+			var allDay bool
+			r.Until, allDay, err = parseTime(property{params: map[string]string{}, value: partValue}, loc)
+			if allDay {
+				// A date includes the whole day.
+				r.Until = r.Until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+		case "WKST":
+			r.WeekStart, ok = weekdays[strings.ToUpper(partValue)]
+		case "BYDAY":
+			for _, code := range strings.Split(partValue, ",") {
+				// Ordinal days such as "1MO" only apply to monthly and yearly rules.
+				day, known := weekdays[strings.ToUpper(code)]
+				if !known {
+					return Rule{}, ErrUnsupportedRule
+				}
+				r.ByDay = append(r.ByDay, day)
+			}
+		default:
+			return Rule{}, ErrUnsupportedRule
+		}
+		if err != nil || !ok {
+			return Rule{}, ErrUnsupportedRule
+		}
+	}
+
+	switch frequency {
+	case "DAILY":
+	case "WEEKLY":
+		r.Weekly = true
+	default:
+		return Rule{}, ErrUnsupportedRule
+	}
+	if r.Interval < 1 || r.Count < 0 {
+		return Rule{}, ErrUnsupportedRule
+	}
+
+	return r, nil
+}
+
+// Between returns the occurrences of the rule that start within the [from, to) window.
+// The rule's first occurrence is start, and later ones keep its wall-clock time in its own time zone,
+// so they do not drift across daylight saving changes.
+func (r Rule) Between(start, from, to time.Time) []time.Time {
+	// Weekly rules repeat whole weeks beginning on the week start day, daily rules single days.
+	first, span := start, 1
+	if r.Weekly {
+		first = start.AddDate(0, 0, -((int(start.Weekday()) - int(r.WeekStart) + 7) % 7))
+		span = 7
+	}
+	step := span * r.Interval
+
+	// Without a count nothing before the window needs to be counted, so the periods before it are skipped.
+	skipped := 0
+	if r.Count == 0 && from.After(first) {
+		skipped = max(int(from.Sub(first).Hours()/24)/step-1, 0)
+	}
+
+	var occurrences []time.Time
+	n := 0
+	for period := skipped; ; period++ {
+		if !first.AddDate(0, 0, period*step).Before(to) {
+			return occurrences
+		}
+
+		for day := 0; day < span; day++ {
+			occurrence := first.AddDate(0, 0, period*step+day)
+			if occurrence.Before(start) || !occurrence.Equal(start) && !r.on(occurrence.Weekday(), start.Weekday()) {
+				continue
+			}
+			if !r.Until.IsZero() && occurrence.After(r.Until) {
+				return occurrences
+			}
+
+			n++
+			if r.Count > 0 && n > r.Count {
+				return occurrences
+			}
+			if !occurrence.Before(from) && occurrence.Before(to) {
+				occurrences = append(occurrences, occurrence)
+				if len(occurrences) >= maxOccurrences {
+					return occurrences
+				}
+			}
+		}
+	}
+}
+
+// on reports whether the rule repeats on the weekday. Weekly rules without BYDAY repeat on the
+// weekday of their start; daily rules without it repeat every day.
+func (r Rule) on(weekday, startWeekday time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return !r.Weekly || weekday == startWeekday
+	}
+
+	for _, day := range r.ByDay {
+		if day == weekday {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Occurrences returns the start times of the event's instances that start within the [from, to) window.
+// Recurring events are expanded and their EXDATE instances left out; an event without a rule has
+// at most its own start.
+func (e Event) Occurrences(from, to time.Time) ([]time.Time, error) {
+	if e.RRule == "" {
+		if e.Start.Before(from) || !e.Start.Before(to) {
+			return nil, nil
+		}
+		return []time.Time{e.Start}, nil
+	}
+
+	rule, err := ParseRule(e.RRule, e.Start.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	var occurrences []time.Time
+	for _, start := range rule.Between(e.Start, from, to) {
+		if !containsTime(e.ExDates, start) {
+			occurrences = append(occurrences, start)
+		}
+	}
+
+	return occurrences, nil
+}
+
+// containsTime reports whether the list holds the instant t.
+func containsTime(list []time.Time, t time.Time) bool {
+	for _, item := range list {
+		if item.Equal(t) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,228 @@
+package ical
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// days returns 9:00 UTC on each of the days of March 2026.
+func days(dates ...int) []time.Time {
+	times := make([]time.Time, 0, len(dates))
+	for _, date := range dates {
+		times = append(times, time.Date(2026, time.March, date, 9, 0, 0, 0, time.UTC))
+	}
+
+	return times
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		rule    string
+		want    Rule
+		wantErr bool
+	}{
+		{
+			rule: "FREQ=WEEKLY;WKST=MO;BYDAY=MO,WE",
+			want: Rule{Weekly: true, Interval: 1, ByDay: []time.Weekday{time.Monday, time.Wednesday}, WeekStart: time.Monday},
+		},
+		{
+			rule: "RRULE:FREQ=WEEKLY;UNTIL=20260430T070000Z;INTERVAL=2;BYDAY=MO,TH;WKST=SU",
+			want: Rule{
+				Weekly: true, Interval: 2, Until: time.Date(2026, time.April, 30, 7, 0, 0, 0, time.UTC),
+				ByDay: []time.Weekday{time.Monday, time.Thursday}, WeekStart: time.Sunday,
+			},
+		},
+		{
+			rule: "FREQ=DAILY;COUNT=5",
+			want: Rule{Interval: 1, Count: 5, WeekStart: time.Monday},
+		},
+		{
+			rule: "FREQ=DAILY;UNTIL=20260310",
+			want: Rule{Interval: 1, Until: time.Date(2026, time.March, 11, 0, 0, 0, -1, time.UTC), WeekStart: time.Monday},
+		},
+		{rule: "FREQ=MONTHLY;BYDAY=1MO", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=1MO", wantErr: true},
+		{rule: "FREQ=YEARLY;BYMONTH=3", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYMONTHDAY=1", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYSETPOS=-1;BYDAY=MO,TU", wantErr: true},
+		{rule: "FREQ=WEEKLY;WKST=XX", wantErr: true},
+		{rule: "FREQ=WEEKLY;INTERVAL=0", wantErr: true},
+		{rule: "FREQ=WEEKLY;UNTIL=soon", wantErr: true},
+		{rule: "BYDAY=MO", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			got, err := ParseRule(tt.rule, time.UTC)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupportedRule) {
+					t.Errorf("ParseRule error = %v, want ErrUnsupportedRule", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRule: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRule = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventOccurrences(t *testing.T) {
+	// 2 March 2026 is a Monday.
+	monday := days(2)[0]
+
+	tests := []struct {
+		name  string
+		event Event
+		from  time.Time
+		to    time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "single event",
+			event: Event{Start: monday},
+			want:  days(2),
+		},
+		{
+			name:  "Google weekly on several days",
+			event: Event{Start: monday, RRule: "FREQ=WEEKLY;WKST=MO;BYDAY=MO,WE"},
+			want:  days(2, 4, 9, 11, 16, 18, 23, 25, 30),
+		},
+		{
+			name:  "Google weekly with a count",
+			event: Event{Start: days(3)[0], RRule: "FREQ=WEEKLY;WKST=SU;COUNT=5;BYDAY=TU,TH"},
+			want:  days(3, 5, 10, 12, 17),
+		},
+		{
+			name:  "Google daily until a time",
+			event: Event{Start: monday, RRule: "FREQ=DAILY;UNTIL=20260305T225959Z"},
+			want:  days(2, 3, 4, 5),
+		},
+		{
+			name:  "Outlook every weekday",
+			event: Event{Start: monday, RRule: "FREQ=WEEKLY;COUNT=7;BYDAY=MO,TU,WE,TH,FR;WKST=SU"},
+			want:  days(2, 3, 4, 5, 6, 9, 10),
+		},
+		{
+			name:  "Outlook every other week",
+			event: Event{Start: monday, RRule: "FREQ=WEEKLY;UNTIL=20260331T090000Z;INTERVAL=2;BYDAY=MO,TH;WKST=SU"},
+			want:  days(2, 5, 16, 19, 30),
+		},
+		{
+			name:  "daily on weekdays",
+			event: Event{Start: days(6)[0], RRule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=3"},
+			want:  days(6, 9, 10),
+		},
+		{
+			name:  "weekly without days repeats on the start day",
+			event: Event{Start: monday, RRule: "FREQ=WEEKLY;COUNT=3"},
+			want:  days(2, 9, 16),
+		},
+		{
+			name:  "excluded dates",
+			event: Event{Start: monday, RRule: "FREQ=DAILY;COUNT=5", ExDates: days(3, 5)},
+			want:  days(2, 4, 6),
+		},
+		{
+			name:  "window cuts a rule without an end",
+			event: Event{Start: time.Date(2020, time.January, 6, 9, 0, 0, 0, time.UTC), RRule: "FREQ=WEEKLY;BYDAY=MO,WE"},
+			from:  days(10)[0],
+			to:    days(19)[0],
+			want:  days(11, 16, 18),
+		},
+		{
+			name:  "count is spent before the window",
+			event: Event{Start: time.Date(2026, time.February, 2, 9, 0, 0, 0, time.UTC), RRule: "FREQ=WEEKLY;COUNT=5"},
+			want:  days(2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := tt.from, tt.to
+			if from.IsZero() {
+				from, to = monday, monday.AddDate(0, 0, 29)
+			}
+
+			got, err := tt.event.Occurrences(from, to)
+			if err != nil {
+				t.Fatalf("Occurrences: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Occurrences = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleBetweenWeekStart(t *testing.T) {
+	// The example of RFC 5545 where the week start changes which Sundays are in the rule.
+	start := time.Date(1997, time.August, 5, 9, 0, 0, 0, time.UTC)
+	from, to := start, start.AddDate(0, 1, 0)
+
+	tests := []struct {
+		rule string
+		want []int
+	}{
+		{rule: "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO", want: []int{5, 10, 19, 24}},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU", want: []int{5, 17, 19, 31}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule, time.UTC)
+			if err != nil {
+				t.Fatalf("ParseRule: %v", err)
+			}
+
+			var got []int
+			for _, occurrence := range rule.Between(start, from, to) {
+				got = append(got, occurrence.Day())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Between gives August %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleBetweenKeepsWallClockAcrossDaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	// Clocks in Berlin move forward on 29 March 2026.
+	start := time.Date(2026, time.March, 23, 9, 0, 0, 0, berlin)
+	rule, err := ParseRule("FREQ=WEEKLY;WKST=MO;BYDAY=MO,WE;UNTIL=20260401T235959", berlin)
+	if err != nil {
+		t.Fatalf("ParseRule: %v", err)
+	}
+
+	got := rule.Between(start, start, start.AddDate(0, 1, 0))
+	if len(got) != 4 {
+		t.Fatalf("Between returned %d occurrences, want 4", len(got))
+	}
+	for _, occurrence := range got {
+		if hour := occurrence.In(berlin).Hour(); hour != 9 {
+			t.Errorf("%v starts at %d:00 local time, want 9:00", occurrence, hour)
+		}
+	}
+}
+
+func TestRuleBetweenCapsOccurrences(t *testing.T) {
+	rule, err := ParseRule("FREQ=DAILY", time.UTC)
+	if err != nil {
+		t.Fatalf("ParseRule: %v", err)
+	}
+
+	start := days(2)[0]
+	if got := rule.Between(start, start, start.AddDate(10, 0, 0)); len(got) != maxOccurrences {
+		t.Errorf("Between returned %d occurrences, want %d", len(got), maxOccurrences)
+	}
+}
@@ -0,0 +1,164 @@
+// Package imaging validates uploaded pictures and renders them as square JPEG thumbnails.
+// Pictures are always decoded and re-encoded, so metadata such as EXIF never reaches the output.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // register the PNG decoder
+	"io"
+	"net/http"
+)
+
+const (
+	// maxPixels limits the dimensions of a picture before it is decoded. The decoded picture and its
+	// cropped copy each take up to four bytes per pixel.
+	maxPixels = 25_000_000
+	// jpegQuality is the quality thumbnails are encoded with.
+	jpegQuality = 85
+)
+
+// AllowedTypes lists the MIME types of pictures that can be uploaded.
+var AllowedTypes = []string{"image/jpeg", "image/png"}
+
+// Errors returned when a picture is rejected.
+var (
+	ErrTooLarge        = errors.New("the picture is too large")
+	ErrUnsupportedType = errors.New("the picture must be a JPEG or PNG image")
+	ErrInvalidImage    = errors.New("the picture cannot be decoded")
+)
+
+// Read reads a picture of at most maxBytes bytes and checks its type from its content.
+// It returns the raw bytes and the detected MIME type.
+func Read(r io.Reader, maxBytes int64) ([]byte, string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, "", ErrTooLarge
+	}
+
+	mimeType := http.DetectContentType(data)
+	for _, allowed := range AllowedTypes {
+		if mimeType == allowed {
+			return data, mimeType, nil
+		}
+	}
+
+	return nil, "", ErrUnsupportedType
+}
+
+// Decode decodes a picture read by Read and turns it upright according to its EXIF orientation.
+func Decode(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	return orient(img, exifOrientation(data)), nil
+}
+
+// Thumbnails crops the centre square of the picture and scales it to each of the sizes, which are given
+// from largest to smallest. The picture is cropped once and only scaled to the first size; the smaller
+// thumbnails are scaled down from that one, so large pictures are not walked again for every size.
+// Transparent areas are filled with white.
+func Thumbnails(img image.Image, sizes ...int) []*image.RGBA {
+	if len(sizes) == 0 {
+		return nil
+	}
+
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2))
+
+	src := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(src, src.Bounds(), img, crop.Min, draw.Over)
+
+	thumbnails := make([]*image.RGBA, len(sizes))
+	thumbnails[0] = scale(src, sizes[0])
+	for i := 1; i < len(sizes); i++ {
+		thumbnails[i] = scale(thumbnails[0], sizes[i])
+	}
+
+	return thumbnails
+}
+
+// EncodeJPEG encodes the picture as a JPEG without any metadata.
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("encode thumbnail: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// scale resizes a square picture to size by size pixels, averaging the source pixels each target pixel covers.
+func scale(src *image.RGBA, size int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	side := src.Bounds().Dx()
+	ratio := float64(side) / float64(size)
+
+	for y := 0; y < size; y++ {
+		y0, y1 := span(y, ratio, side)
+		for x := 0; x < size; x++ {
+			x0, x1 := span(x, ratio, side)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					b += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// span returns the range of source pixels covered by target pixel i, always at least one pixel wide.
+func span(i int, ratio float64, limit int) (int, int) {
+	start := int(float64(i) * ratio)
+	end := int(float64(i+1) * ratio)
+	if end <= start {
+		end = start + 1
+	}
+	if end > limit {
+		end = limit
+	}
+	if start >= end {
+		start = end - 1
+	}
+
+	return start, end
+}
@@ -0,0 +1,29 @@
+// Package jobs runs background tasks on a fixed interval.
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs task immediately and then every interval until ctx is cancelled.
+// Errors are logged and do not stop the schedule.
+func Every(ctx context.Context, name string, interval time.Duration, task func(ctx context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := task(ctx); err != nil {
+				log.Printf("Job %s failed: %v", name, err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
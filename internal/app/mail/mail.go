@@ -0,0 +1,141 @@
+// Package mail sends email through a pluggable sender.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers email.
+type Sender interface {
+	// Send delivers the message or returns why it could not.
+	Send(ctx context.Context, msg Message) error
+}
+
+var defaultSender Sender
+
+// SetDefault sets the sender used for outgoing email.
+func SetDefault(s Sender) {
+	defaultSender = s
+}
+
+// Default returns the sender used for outgoing email.
+func Default() Sender {
+	return defaultSender
+}
+
+// headerBreaks strips line breaks from header values so they cannot add headers of their own.
+var headerBreaks = strings.NewReplacer("\r", "", "\n", "")
+
+// format renders the message with its headers, the way it is written to disk or sent over SMTP.
+func format(from string, msg Message, date time.Time) []byte {
+	var b strings.Builder
+	if from != "" {
+		fmt.Fprintf(&b, "From: %s\r\n", headerBreaks.Replace(from))
+	}
+	fmt.Fprintf(&b, "To: %s\r\n", headerBreaks.Replace(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerBreaks.Replace(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
+
+// Memory keeps sent messages in memory. It is meant for tests and local development.
+type Memory struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemory returns an empty in-memory sender.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Send records the message.
+func (m *Memory) Send(_ context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+// FileSink writes every message to its own .eml file in a directory instead of sending it.
+// It is meant for local development.
+type FileSink struct {
+	dir  string
+	from string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewFileSink returns a sender that writes messages to dir, creating the directory if needed.
+func NewFileSink(dir, from string) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileSink{dir: dir, from: from}, nil
+}
+
+// Send writes the message to a file named after the time it was sent.
+func (f *FileSink) Send(_ context.Context, msg Message) error {
+	f.mu.Lock()
+	f.seq++
+	seq := f.seq
+	f.mu.Unlock()
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%04d.eml", now.UTC().Format("20060102T150405.000000000"), seq)
+
+	return os.WriteFile(filepath.Join(f.dir, name), format(f.from, msg, now), 0o600)
+}
+
+// SMTP sends messages through an SMTP server.
+type SMTP struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTP returns a sender that relays through the server at addr ("host:port"), signing in with
+// the username and password when a username is given.
+func NewSMTP(addr, username, password, from string) *SMTP {
+	s := &SMTP{addr: addr, from: from}
+	if username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return s
+}
+
+// Send relays the message to the server.
+func (s *SMTP) Send(_ context.Context, msg Message) error {
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, format(s.from, msg, time.Now()))
+}
@@ -0,0 +1,282 @@
+// Package matching scores how well psychologists fit the preferences a customer gave in the intake questionnaire.
+package matching
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/scheduling"
+)
+
+// Criterion is a preference psychologists are scored against.
+type Criterion string
+
+// Criteria, named after the intake questions that ask for them.
+const (
+	Concerns  Criterion = "concerns"
+	Gender    Criterion = "gender"
+	Language  Criterion = "language"
+	Budget    Criterion = "budget"
+	Modality  Criterion = "modality"
+	TimeOfDay Criterion = "time_of_day"
+)
+
+// Criteria lists every criterion in the order explanations are given.
+var Criteria = []Criterion{Concerns, Language, Modality, Budget, TimeOfDay, Gender}
+
+// DefaultWeights are used for criteria whose question does not set a weight.
+var DefaultWeights = map[Criterion]float64{
+	Concerns:  3,
+	Language:  2,
+	Modality:  2,
+	Budget:    1.5,
+	TimeOfDay: 1,
+	Gender:    1,
+}
+
+// Period is a part of the day a customer prefers sessions in.
+type Period string
+
+// Parts of the day.
+const (
+	Morning   Period = "morning"
+	Afternoon Period = "afternoon"
+	Evening   Period = "evening"
+)
+
+// periodHours maps the parts of the day to the wall clock hours they span.
+var periodHours = map[Period][2]time.Duration{
+	Morning:   {6 * time.Hour, 12 * time.Hour},
+	Afternoon: {12 * time.Hour, 17 * time.Hour},
+	Evening:   {17 * time.Hour, 22 * time.Hour},
+}
+
+// IsValid reports whether the period is one of the known parts of the day.
+func (p Period) IsValid() bool {
+	_, ok := periodHours[p]
+
+	return ok
+}
+
+// Money is an amount in a currency.
+type Money struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// String formats the amount with its currency.
+func (m Money) String() string {
+	return fmt.Sprintf("%.2f %s", m.Amount, m.Currency)
+}
+
+// Preferences are the answers of the intake questionnaire that matching uses. Zero values are not scored.
+type Preferences struct {
+	// Concerns are the IDs of the specializations the customer needs help with.
+	Concerns []int
+	Gender   string
+	Language string
+	// Budget is the most the customer wants to pay per session.
+	Budget   *Money
+	Modality string
+	// Periods are the parts of the day, in the psychologist's time zone, the customer can attend.
+	Periods []Period
+}
+
+// Candidate is a psychologist as seen by matching.
+type Candidate struct {
+	ID     int
+	Gender string
+	// Specializations maps specialization IDs to their names.
+	Specializations map[int]string
+	Languages       []string
+	Modalities      []string
+	// Prices maps currencies to the lowest consultation price in them.
+	Prices map[string]float64
+	Weekly []scheduling.WeeklyRule
+}
+
+// Result is how well a candidate matches, from 0 to 100, and why.
+type Result struct {
+	Score      float64  `json:"score"`
+	Reasons    []string `json:"reasons"`
+	Mismatches []string `json:"mismatches"`
+}
+
+// Score rates the candidate against every criterion the preferences set, weighting each by weights
+// (falling back to DefaultWeights), and explains which criteria matched and which did not.
+func Score(prefs Preferences, c Candidate, weights map[Criterion]float64) Result {
+	result := Result{Reasons: []string{}, Mismatches: []string{}}
+
+	var total, earned float64
+	for _, criterion := range Criteria {
+		score, reason, mismatch, ok := scoreCriterion(criterion, prefs, c)
+		if !ok {
+			continue
+		}
+
+		weight, set := weights[criterion]
+		if !set {
+			weight = DefaultWeights[criterion]
+		}
+		total += weight
+		earned += weight * score
+
+		if reason != "" {
+			result.Reasons = append(result.Reasons, reason)
+		}
+		if mismatch != "" {
+			result.Mismatches = append(result.Mismatches, mismatch)
+		}
+	}
+
+	if total > 0 {
+		result.Score = float64(int(earned/total*1000+0.5)) / 10
+	}
+
+	return result
+}
+
+// scoreCriterion rates a single criterion between 0 and 1. It reports false when the preferences leave it unset.
+func scoreCriterion(criterion Criterion, prefs Preferences, c Candidate) (score float64, reason, mismatch string, ok bool) {
+	switch criterion {
+	case Concerns:
+		if len(prefs.Concerns) == 0 {
+			return 0, "", "", false
+		}
+		return scoreConcerns(prefs.Concerns, c)
+	case Gender:
+		if prefs.Gender == "" {
+			return 0, "", "", false
+		}
+		if c.Gender == prefs.Gender {
+			return 1, "Matches your preferred gender", "", true
+		}
+		return 0, "", "Does not match your preferred gender", true
+	case Language:
+		if prefs.Language == "" {
+			return 0, "", "", false
+		}
+		if contains(c.Languages, prefs.Language) {
+			return 1, fmt.Sprintf("Holds sessions in your language (%s)", prefs.Language), "", true
+		}
+		return 0, "", fmt.Sprintf("Does not hold sessions in your language (%s)", prefs.Language), true
+	case Modality:
+		if prefs.Modality == "" {
+			return 0, "", "", false
+		}
+		label := strings.ReplaceAll(prefs.Modality, "_", "-")
+		if contains(c.Modalities, prefs.Modality) {
+			return 1, fmt.Sprintf("Offers %s sessions", label), "", true
+		}
+		return 0, "", fmt.Sprintf("Does not offer %s sessions", label), true
+	case Budget:
+		if prefs.Budget == nil {
+			return 0, "", "", false
+		}
+		return scoreBudget(*prefs.Budget, c)
+	case TimeOfDay:
+		if len(prefs.Periods) == 0 {
+			return 0, "", "", false
+		}
+		return scorePeriods(prefs.Periods, c)
+	}
+
+	return 0, "", "", false
+}
+
+// scoreConcerns rates the share of the customer's concerns the candidate specializes in.
+func scoreConcerns(concerns []int, c Candidate) (float64, string, string, bool) {
+	var matched []string
+	for _, id := range concerns {
+		if name, ok := c.Specializations[id]; ok {
+			matched = append(matched, name)
+		}
+	}
+
+	if len(matched) == 0 {
+		return 0, "", "Does not specialize in any of your concerns", true
+	}
+
+	return float64(len(matched)) / float64(len(concerns)), "Specializes in " + strings.Join(matched, ", "), "", true
+}
+
+// scoreBudget gives full marks to candidates whose lowest price fits the budget and fewer the further above it they are.
+func scoreBudget(budget Money, c Candidate) (float64, string, string, bool) {
+	price, ok := c.Prices[budget.Currency]
+	if !ok {
+		return 0, "", fmt.Sprintf("Has no prices in %s", budget.Currency), true
+	}
+
+	from := Money{Amount: price, Currency: budget.Currency}
+	if price <= budget.Amount {
+		return 1, fmt.Sprintf("Sessions from %s fit your budget of %s", from, budget), "", true
+	}
+
+	score := 0.0
+	if budget.Amount > 0 {
+		score = max(0, 1-(price-budget.Amount)/budget.Amount)
+	}
+
+	return score, "", fmt.Sprintf("Sessions from %s exceed your budget of %s", from, budget), true
+}
+
+// scorePeriods rates the share of the preferred parts of the day the candidate has weekly availability in.
+func scorePeriods(periods []Period, c Candidate) (float64, string, string, bool) {
+	var available, missing []string
+	for _, period := range periods {
+		days := periodDays(period, c.Weekly)
+		if len(days) == 0 {
+			missing = append(missing, string(period))
+			continue
+		}
+		available = append(available, fmt.Sprintf("%s (%s)", period, strings.Join(days, ", ")))
+	}
+
+	var reason, mismatch string
+	if len(available) > 0 {
+		reason = "Available in the " + strings.Join(available, "; ")
+	}
+	if len(missing) > 0 {
+		mismatch = "Not available in the " + strings.Join(missing, ", ")
+	}
+
+	return float64(len(available)) / float64(len(periods)), reason, mismatch, true
+}
+
+// periodDays returns the abbreviated weekdays on which a weekly rule overlaps the part of the day.
+func periodDays(period Period, weekly []scheduling.WeeklyRule) []string {
+	hours := periodHours[period]
+
+	seen := make(map[time.Weekday]bool)
+	for _, rule := range weekly {
+		if rule.Start < hours[1] && hours[0] < rule.End {
+			seen[rule.Weekday] = true
+		}
+	}
+
+	days := make([]time.Weekday, 0, len(seen))
+	for day := range seen {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+
+	names := make([]string, len(days))
+	for i, day := range days {
+		names[i] = day.String()[:3]
+	}
+
+	return names
+}
+
+// contains reports whether values holds value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
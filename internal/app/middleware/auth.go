@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+)
+
+// Authenticate is a middleware that resolves the caller from the Authorization header: a bearer access token,
+// or an API key sent either as "ApiKey <key>" or as a bearer token.
+// Requests without the header run as anonymous callers; requests with an invalid or expired token are rejected.
+func Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		if key, ok := strings.CutPrefix(header, "ApiKey "); ok {
+			authenticateAPIKey(c, strings.TrimSpace(key))
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			rejectToken(c, auth.ErrInvalidAccessToken)
+			return
+		}
+		if strings.HasPrefix(strings.TrimSpace(token), models.APIKeyPrefix) {
+			authenticateAPIKey(c, strings.TrimSpace(token))
+			return
+		}
+
+		principal, err := auth.ParseAccessToken(strings.TrimSpace(token))
+		if err != nil {
+			rejectToken(c, auth.ErrInvalidAccessToken)
+			return
+		}
+
+		auth.SetPrincipal(c, principal)
+		c.Next()
+	}
+}
+
+// authenticateAPIKey runs the request as the integration the API key belongs to.
+func authenticateAPIKey(c *gin.Context, key string) {
+	apiKey, err := models.AuthenticateAPIKey(c, key, c.ClientIP())
+	switch {
+	case errors.Is(err, models.ErrInvalidAPIKey):
+		rejectToken(c, err)
+		return
+	case errors.Is(err, models.ErrAPIKeyIPNotAllowed):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	auth.SetPrincipal(c, apiKey.Principal())
+	c.Next()
+}
+
+// rejectToken aborts a request whose credentials could not be verified.
+func rejectToken(c *gin.Context, err error) {
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
+	"github.com/vitalicher97/psychologist_app/internal/app/ratelimit"
+)
+
+// rateLimitRemainingKey is the gin context key holding the remaining requests of the tightest bucket
+// a request was counted against, so its headers describe the limit the caller will hit first.
+const rateLimitRemainingKey = "middleware.rateLimitRemaining"
+
+// RateLimits are the limits of one bucket for each kind of caller. Callers with an API key are counted
+// per key, signed in callers per account and everyone else per IP address. Zero limits are not enforced.
+type RateLimits struct {
+	IP     ratelimit.Limit
+	APIKey ratelimit.Limit
+	User   ratelimit.Limit
+}
+
+// RateLimit is a middleware that counts requests in the named bucket of the caller and responds with 429
+// once it is empty. It sets the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy
+// headers, and Retry-After on rejected requests. It has to run after Authenticate.
+// When the store fails the request is let through, so an outage of a shared store does not take the API down.
+func RateLimit(store ratelimit.Store, bucket string, limits RateLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, limit := rateLimitKey(c, bucket, limits)
+		if Throttle(c, store, key, limit) {
+			c.Next()
+		}
+	}
+}
+
+// RateLimitIP is a middleware that counts every request in the named bucket of its IP address, whoever
+// makes it, the way RateLimit counts anonymous callers. It runs before Authenticate, so requests with
+// made up tokens or API keys are counted too.
+func RateLimitIP(store ratelimit.Store, bucket string, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if Throttle(c, store, bucket+":ip:"+c.ClientIP(), limit) {
+			c.Next()
+		}
+	}
+}
+
+// Throttle counts the request against the bucket of the key and sets the rate limit headers. Once the
+// bucket is empty it responds with 429 and aborts the request. It reports whether the request may go on.
+// Zero limits and failing stores let every request through.
+func Throttle(c *gin.Context, store ratelimit.Store, key string, limit ratelimit.Limit) bool {
+	if limit.IsZero() {
+		return true
+	}
+
+	result, err := store.Take(c, key, limit)
+	if err != nil {
+		log.Printf("Rate limit %s failed: %v", key, err)
+		return true
+	}
+
+	setRateLimitHeaders(c, limit, result)
+
+	if !result.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, try again later"})
+		return false
+	}
+
+	return true
+}
+
+// rateLimitKey returns the bucket key of the caller and the limit that applies to them.
+func rateLimitKey(c *gin.Context, bucket string, limits RateLimits) (string, ratelimit.Limit) {
+	principal := auth.PrincipalFrom(c)
+	switch {
+	case principal.IsAPIKey():
+		return bucket + ":key:" + strconv.Itoa(principal.APIKeyID), limits.APIKey
+	case !principal.IsAnonymous():
+		return bucket + ":user:" + strconv.Itoa(principal.UserID), limits.User
+	default:
+		return bucket + ":ip:" + c.ClientIP(), limits.IP
+	}
+}
+
+// setRateLimitHeaders describes the bucket in the response headers, unless the request was already
+// counted against a bucket with fewer requests left.
+func setRateLimitHeaders(c *gin.Context, limit ratelimit.Limit, result ratelimit.Result) {
+	if remaining, ok := c.Get(rateLimitRemainingKey); ok && remaining.(int) < result.Remaining {
+		return
+	}
+	c.Set(rateLimitRemainingKey, result.Remaining)
+
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	c.Header("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+strconv.Itoa(ceilSeconds(limit.Period)))
+}
+
+// ceilSeconds rounds the duration up to whole seconds.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,170 @@
+// Package ratelimit counts requests in token buckets kept behind a pluggable store.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidLimit is returned when a limit cannot be parsed.
+var ErrInvalidLimit = errors.New(`limits must look like "100/1m"`)
+
+// Limit lets Requests requests through per Period. Buckets start full, so up to Requests requests
+// can be made at once, and refill evenly over the period.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit reads a limit written as requests/period, such as "100/1m". A bare unit period such as
+// "10/s" or "10/h" counts as one of that unit.
+func ParseLimit(s string) (Limit, error) {
+	requests, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, ErrInvalidLimit
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return Limit{}, ErrInvalidLimit
+	}
+
+	if period != "" && !strings.ContainsAny(period[:1], "0123456789") {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, ErrInvalidLimit
+	}
+
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// String writes the limit the way ParseLimit reads it.
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// IsZero reports whether the limit is unset. Unset limits let every request through.
+func (l Limit) IsZero() bool {
+	return l.Requests <= 0 || l.Period <= 0
+}
+
+// rate is how many tokens the bucket gains per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result is the state of a bucket after a request was counted.
+type Result struct {
+	Allowed bool
+	// Limit is the size of the bucket.
+	Limit int
+	// Remaining is how many more requests would be let through right now.
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request will be let through. It is zero when Allowed.
+	RetryAfter time.Duration
+}
+
+// Bucket is the state of one token bucket. Stores keep one per key.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Take refills the bucket for the time since it was last used and takes a token from it when one is left.
+// A zero bucket is treated as full.
+func (b *Bucket) Take(limit Limit, now time.Time) Result {
+	capacity := float64(limit.Requests)
+	if b.UpdatedAt.IsZero() {
+		b.Tokens = capacity
+	} else if elapsed := now.Sub(b.UpdatedAt).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(capacity, b.Tokens+elapsed*limit.rate())
+	}
+	b.UpdatedAt = now
+
+	result := Result{Limit: limit.Requests}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.Tokens) / limit.rate())
+	}
+	result.Remaining = int(b.Tokens)
+	result.Reset = seconds((capacity - b.Tokens) / limit.rate())
+
+	return result
+}
+
+// seconds converts a number of seconds to a duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Store keeps the buckets of every key.
+type Store interface {
+	// Take counts a request against the bucket of the key.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// sweepInterval is how often the memory store forgets buckets that filled up again.
+const sweepInterval = time.Minute
+
+// memoryBucket is a bucket of the memory store and the time it is full again.
+type memoryBucket struct {
+	Bucket
+	fullAt time.Time
+}
+
+// Memory keeps buckets in the memory of the process. Every instance of the API counts on its own,
+// so deployments with several instances should share a store instead.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+// NewMemory returns an empty in-process store.
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*memoryBucket), lastSweep: time.Now()}
+}
+
+// Take counts a request against the bucket of the key.
+func (m *Memory) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		m.sweep(now)
+	}
+
+	bucket, ok := m.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{}
+		m.buckets[key] = bucket
+	}
+
+	result := bucket.Take(limit, now)
+	bucket.fullAt = now.Add(result.Reset)
+
+	return result, nil
+}
+
+// sweep forgets the buckets that are full again, since a new bucket starts full anyway.
+func (m *Memory) sweep(now time.Time) {
+	for key, bucket := range m.buckets {
+		if !now.Before(bucket.fullAt) {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "100/1m", want: Limit{Requests: 100, Period: time.Minute}},
+		{in: " 10/30s ", want: Limit{Requests: 10, Period: 30 * time.Second}},
+		{in: "10/s", want: Limit{Requests: 10, Period: time.Second}},
+		{in: "20/h", want: Limit{Requests: 20, Period: time.Hour}},
+		{in: "5/1h30m", want: Limit{Requests: 5, Period: 90 * time.Minute}},
+		{in: "100", wantErr: true},
+		{in: "0/1m", wantErr: true},
+		{in: "-1/1m", wantErr: true},
+		{in: "many/1m", wantErr: true},
+		{in: "10/", wantErr: true},
+		{in: "10/0s", wantErr: true},
+		{in: "10/fortnight", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLimit(tt.in)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidLimit) {
+					t.Errorf("ParseLimit error = %v, want ErrInvalidLimit", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLimit: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLimit = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitStringRoundTrips(t *testing.T) {
+	limit := Limit{Requests: 300, Period: time.Minute}
+
+	got, err := ParseLimit(limit.String())
+	if err != nil || got != limit {
+		t.Errorf("ParseLimit(%q) = %+v, %v, want %+v", limit.String(), got, err, limit)
+	}
+}
+
+func TestLimitIsZero(t *testing.T) {
+	tests := []struct {
+		limit Limit
+		want  bool
+	}{
+		{limit: Limit{}, want: true},
+		{limit: Limit{Requests: 10}, want: true},
+		{limit: Limit{Period: time.Minute}, want: true},
+		{limit: Limit{Requests: 10, Period: time.Minute}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.limit.IsZero(); got != tt.want {
+			t.Errorf("%+v.IsZero() = %v, want %v", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestBucketTake(t *testing.T) {
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+	now := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	var bucket Bucket
+	for i := 2; i >= 0; i-- {
+		result := bucket.Take(limit, now)
+		if !result.Allowed || result.Remaining != i || result.RetryAfter != 0 {
+			t.Fatalf("request %d: %+v, want allowed with %d remaining", 3-i, result, i)
+		}
+		if result.Limit != 3 {
+			t.Errorf("Limit = %d, want 3", result.Limit)
+		}
+	}
+
+	result := bucket.Take(limit, now)
+	if result.Allowed {
+		t.Fatal("request over the limit was allowed")
+	}
+	if result.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %v, want 1s", result.RetryAfter)
+	}
+	if result.Reset != 3*time.Second {
+		t.Errorf("Reset = %v, want 3s", result.Reset)
+	}
+
+	// One token comes back per second.
+	if result := bucket.Take(limit, now.Add(time.Second)); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("after a second: %+v, want allowed with none remaining", result)
+	}
+
+	// A long pause fills the bucket up to its size and no further.
+	if result := bucket.Take(limit, now.Add(time.Hour)); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("after an hour: %+v, want allowed with 2 remaining", result)
+	}
+}
+
+func TestBucketTakeIgnoresClockGoingBack(t *testing.T) {
+	limit := Limit{Requests: 1, Period: time.Minute}
+	now := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	var bucket Bucket
+	bucket.Take(limit, now)
+	if result := bucket.Take(limit, now.Add(-time.Hour)); result.Allowed {
+		t.Errorf("request was allowed after the clock went back: %+v", result)
+	}
+}
+
+func TestMemoryTake(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 2, Period: time.Hour}
+	store := NewMemory()
+
+	for i := 0; i < 2; i++ {
+		if result, err := store.Take(ctx, "a", limit); err != nil || !result.Allowed {
+			t.Fatalf("request %d: %+v, %v, want allowed", i+1, result, err)
+		}
+	}
+	if result, _ := store.Take(ctx, "a", limit); result.Allowed {
+		t.Error("third request of a was allowed")
+	}
+
+	// Keys are counted separately.
+	if result, _ := store.Take(ctx, "b", limit); !result.Allowed || result.Remaining != 1 {
+		t.Errorf("first request of b: %+v, want allowed with 1 remaining", result)
+	}
+}
+
+func TestMemorySweepForgetsFullBuckets(t *testing.T) {
+	store := NewMemory()
+	now := time.Now()
+	store.buckets["full"] = &memoryBucket{fullAt: now.Add(-time.Second)}
+	store.buckets["draining"] = &memoryBucket{fullAt: now.Add(time.Minute)}
+
+	store.sweep(now)
+
+	if _, ok := store.buckets["full"]; ok {
+		t.Error("sweep kept a full bucket")
+	}
+	if _, ok := store.buckets["draining"]; !ok {
+		t.Error("sweep dropped a bucket that is not full yet")
+	}
+	if !store.lastSweep.Equal(now) {
+		t.Errorf("lastSweep = %v, want %v", store.lastSweep, now)
+	}
+}
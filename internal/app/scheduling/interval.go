@@ -0,0 +1,108 @@
+package scheduling
+
+import (
+	"sort"
+	"time"
+)
+
+// Interval represents a half-open time range [Start, End).
+type Interval struct {
+	Start time.Time `json:"start_time"`
+	End   time.Time `json:"end_time"`
+}
+
+// Duration returns the length of the interval.
+func (i Interval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// Empty reports whether the interval has no length.
+func (i Interval) Empty() bool {
+	return !i.End.After(i.Start)
+}
+
+// Overlaps reports whether the two intervals share any instant.
+func (i Interval) Overlaps(o Interval) bool {
+	return i.Start.Before(o.End) && o.Start.Before(i.End)
+}
+
+// Contains reports whether o lies entirely within the interval.
+func (i Interval) Contains(o Interval) bool {
+	return !o.Start.Before(i.Start) && !o.End.After(i.End)
+}
+
+// Merge sorts the intervals and joins the ones that overlap or touch.
+func Merge(intervals []Interval) []Interval {
+	sorted := make([]Interval, 0, len(intervals))
+	for _, iv := range intervals {
+		if !iv.Empty() {
+			sorted = append(sorted, iv)
+		}
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		return sorted[a].Start.Before(sorted[b].Start)
+	})
+
+	merged := make([]Interval, 0, len(sorted))
+	for _, iv := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && !iv.Start.After(merged[last].End) {
+			if iv.End.After(merged[last].End) {
+				merged[last].End = iv.End
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+
+	return merged
+}
+
+// Subtract removes every instant covered by remove from the given intervals.
+func Subtract(intervals []Interval, remove []Interval) []Interval {
+	remaining := Merge(intervals)
+	remove = Merge(remove)
+
+	result := make([]Interval, 0, len(remaining))
+	for _, iv := range remaining {
+		current := iv
+		for _, r := range remove {
+			if !r.Overlaps(current) {
+				continue
+			}
+			if r.Start.After(current.Start) {
+				result = append(result, Interval{Start: current.Start, End: r.Start})
+			}
+			current.Start = r.End
+			if current.Empty() {
+				break
+			}
+		}
+		if !current.Empty() {
+			result = append(result, current)
+		}
+	}
+
+	return result
+}
+
+// Clip limits the intervals to the [from, to) window, dropping the ones outside of it.
+func Clip(intervals []Interval, from, to time.Time) []Interval {
+	window := Interval{Start: from, End: to}
+
+	result := make([]Interval, 0, len(intervals))
+	for _, iv := range intervals {
+		if !iv.Overlaps(window) {
+			continue
+		}
+		if iv.Start.Before(from) {
+			iv.Start = from
+		}
+		if iv.End.After(to) {
+			iv.End = to
+		}
+		result = append(result, iv)
+	}
+
+	return result
+}
@@ -0,0 +1,110 @@
+package scheduling
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// monday is midnight UTC of a Monday the tests build their times on.
+var monday = time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+
+// at returns the time hours and minutes after midnight of monday.
+func at(hour, minute int) time.Time {
+	return monday.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+}
+
+// iv returns the interval between two times of monday, given as hour and minute pairs.
+func iv(startHour, startMinute, endHour, endMinute int) Interval {
+	return Interval{Start: at(startHour, startMinute), End: at(endHour, endMinute)}
+}
+
+func TestIntervalOverlapsAndContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     Interval
+		overlaps bool
+		contains bool
+	}{
+		{name: "disjoint", a: iv(9, 0, 10, 0), b: iv(11, 0, 12, 0)},
+		{name: "touching", a: iv(9, 0, 10, 0), b: iv(10, 0, 11, 0)},
+		{name: "partial", a: iv(9, 0, 10, 0), b: iv(9, 30, 10, 30), overlaps: true},
+		{name: "inside", a: iv(9, 0, 12, 0), b: iv(10, 0, 11, 0), overlaps: true, contains: true},
+		{name: "equal", a: iv(9, 0, 10, 0), b: iv(9, 0, 10, 0), overlaps: true, contains: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Overlaps(tt.b); got != tt.overlaps {
+				t.Errorf("Overlaps = %v, want %v", got, tt.overlaps)
+			}
+			if got := tt.b.Overlaps(tt.a); got != tt.overlaps {
+				t.Errorf("reversed Overlaps = %v, want %v", got, tt.overlaps)
+			}
+			if got := tt.a.Contains(tt.b); got != tt.contains {
+				t.Errorf("Contains = %v, want %v", got, tt.contains)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []Interval
+		want []Interval
+	}{
+		{name: "empty", in: nil, want: []Interval{}},
+		{name: "sorts", in: []Interval{iv(11, 0, 12, 0), iv(9, 0, 10, 0)}, want: []Interval{iv(9, 0, 10, 0), iv(11, 0, 12, 0)}},
+		{name: "joins overlapping", in: []Interval{iv(9, 0, 10, 30), iv(10, 0, 11, 0)}, want: []Interval{iv(9, 0, 11, 0)}},
+		{name: "joins touching", in: []Interval{iv(9, 0, 10, 0), iv(10, 0, 11, 0)}, want: []Interval{iv(9, 0, 11, 0)}},
+		{name: "keeps contained", in: []Interval{iv(9, 0, 12, 0), iv(10, 0, 11, 0)}, want: []Interval{iv(9, 0, 12, 0)}},
+		{name: "drops empty", in: []Interval{iv(9, 0, 9, 0), iv(10, 0, 11, 0)}, want: []Interval{iv(10, 0, 11, 0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Merge(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     []Interval
+		remove []Interval
+		want   []Interval
+	}{
+		{name: "nothing removed", in: []Interval{iv(9, 0, 12, 0)}, want: []Interval{iv(9, 0, 12, 0)}},
+		{name: "hole", in: []Interval{iv(9, 0, 12, 0)}, remove: []Interval{iv(10, 0, 11, 0)}, want: []Interval{iv(9, 0, 10, 0), iv(11, 0, 12, 0)}},
+		{name: "start", in: []Interval{iv(9, 0, 12, 0)}, remove: []Interval{iv(8, 0, 10, 0)}, want: []Interval{iv(10, 0, 12, 0)}},
+		{name: "end", in: []Interval{iv(9, 0, 12, 0)}, remove: []Interval{iv(11, 0, 13, 0)}, want: []Interval{iv(9, 0, 11, 0)}},
+		{name: "all", in: []Interval{iv(9, 0, 12, 0)}, remove: []Interval{iv(8, 0, 13, 0)}, want: []Interval{}},
+		{
+			name:   "several",
+			in:     []Interval{iv(9, 0, 12, 0), iv(14, 0, 18, 0)},
+			remove: []Interval{iv(11, 0, 15, 0), iv(16, 0, 17, 0)},
+			want:   []Interval{iv(9, 0, 11, 0), iv(15, 0, 16, 0), iv(17, 0, 18, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Subtract(tt.in, tt.remove); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Subtract = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClip(t *testing.T) {
+	in := []Interval{iv(8, 0, 10, 0), iv(11, 0, 12, 0), iv(13, 0, 15, 0), iv(16, 0, 17, 0)}
+	want := []Interval{iv(9, 0, 10, 0), iv(11, 0, 12, 0), iv(13, 0, 14, 0)}
+
+	if got := Clip(in, at(9, 0), at(14, 0)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Clip = %v, want %v", got, want)
+	}
+}
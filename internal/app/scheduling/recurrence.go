@@ -0,0 +1,111 @@
+package scheduling
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxOccurrences caps how many occurrences a recurrence may produce.
+const MaxOccurrences = 104
+
+// ErrInvalidRecurrence is returned when a recurrence rule is outside the supported subset.
+var ErrInvalidRecurrence = errors.New("rrule must be FREQ=WEEKLY with INTERVAL 1 or 2 and either COUNT or UNTIL")
+
+// Recurrence is the supported subset of an RFC 5545 RRULE: weekly or biweekly, bounded by a count or an end time.
+type Recurrence struct {
+	Interval int
+	Count    int
+	Until    time.Time
+}
+
+// ParseRecurrence parses a rule such as "FREQ=WEEKLY;INTERVAL=2;COUNT=10" or "FREQ=WEEKLY;UNTIL=20261231T000000Z".
+// A leading "RRULE:" is accepted.
+func ParseRecurrence(rule string) (Recurrence, error) {
+	r := Recurrence{Interval: 1}
+
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	frequency := ""
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Recurrence{}, ErrInvalidRecurrence
+		}
+
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			frequency = strings.ToUpper(value)
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		default:
+			return Recurrence{}, ErrInvalidRecurrence
+		}
+		if err != nil {
+			return Recurrence{}, ErrInvalidRecurrence
+		}
+	}
+
+	if frequency != "WEEKLY" || r.Interval < 1 || r.Interval > 2 {
+		return Recurrence{}, ErrInvalidRecurrence
+	}
+	if (r.Count > 0) == !r.Until.IsZero() || r.Count < 0 || r.Count > MaxOccurrences {
+		return Recurrence{}, ErrInvalidRecurrence
+	}
+
+	return r, nil
+}
+
+// String formats the recurrence as an RRULE value.
+func (r Recurrence) String() string {
+	rule := fmt.Sprintf("FREQ=WEEKLY;INTERVAL=%d", r.Interval)
+	if r.Count > 0 {
+		return rule + fmt.Sprintf(";COUNT=%d", r.Count)
+	}
+
+	return rule + ";UNTIL=" + r.Until.UTC().Format("20060102T150405Z")
+}
+
+// Occurrences returns the start times produced by the recurrence, beginning with start.
+// Occurrences keep the wall-clock time of start in loc, so they do not drift across daylight saving changes.
+func (r Recurrence) Occurrences(start time.Time, loc *time.Location) []time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := start.In(loc)
+
+	var occurrences []time.Time
+	for i := 0; i < MaxOccurrences; i++ {
+		occurrence := local.AddDate(0, 0, 7*r.Interval*i)
+		if r.Count > 0 && i >= r.Count {
+			break
+		}
+		if !r.Until.IsZero() && occurrence.After(r.Until) {
+			break
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+
+	return occurrences
+}
+
+// parseUntil parses the UNTIL value, either a UTC date-time or a date.
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// A date includes the whole day.
+	return t.AddDate(0, 0, 1).Add(-time.Second), nil
+}
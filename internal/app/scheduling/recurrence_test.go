@@ -0,0 +1,127 @@
+package scheduling
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		rule    string
+		want    Recurrence
+		wantErr bool
+	}{
+		{rule: "FREQ=WEEKLY;COUNT=10", want: Recurrence{Interval: 1, Count: 10}},
+		{rule: "RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4", want: Recurrence{Interval: 2, Count: 4}},
+		{rule: "freq=weekly;count=3", want: Recurrence{Interval: 1, Count: 3}},
+		{rule: "FREQ=WEEKLY;UNTIL=20260401T120000Z", want: Recurrence{Interval: 1, Until: time.Date(2026, time.April, 1, 12, 0, 0, 0, time.UTC)}},
+		{rule: "FREQ=WEEKLY;UNTIL=20260401", want: Recurrence{Interval: 1, Until: time.Date(2026, time.April, 1, 23, 59, 59, 0, time.UTC)}},
+		{rule: "FREQ=DAILY;COUNT=10", wantErr: true},
+		{rule: "FREQ=WEEKLY;INTERVAL=3;COUNT=10", wantErr: true},
+		{rule: "FREQ=WEEKLY", wantErr: true},
+		{rule: "FREQ=WEEKLY;COUNT=2;UNTIL=20260401", wantErr: true},
+		{rule: "FREQ=WEEKLY;COUNT=105", wantErr: true},
+		{rule: "FREQ=WEEKLY;COUNT=ten", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=MO;COUNT=2", wantErr: true},
+		{rule: "FREQ=WEEKLY;COUNT", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			got, err := ParseRecurrence(tt.rule)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRecurrence) {
+					t.Errorf("ParseRecurrence error = %v, want ErrInvalidRecurrence", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRecurrence: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRecurrence = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurrenceStringRoundTrips(t *testing.T) {
+	for _, rule := range []string{"FREQ=WEEKLY;INTERVAL=2;COUNT=4", "FREQ=WEEKLY;INTERVAL=1;UNTIL=20260401T120000Z"} {
+		r, err := ParseRecurrence(rule)
+		if err != nil {
+			t.Fatalf("ParseRecurrence(%q): %v", rule, err)
+		}
+		if got := r.String(); got != rule {
+			t.Errorf("String = %q, want %q", got, rule)
+		}
+	}
+}
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	start := at(10, 0)
+
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		want       []time.Time
+	}{
+		{
+			name:       "count",
+			recurrence: Recurrence{Interval: 1, Count: 3},
+			want:       []time.Time{start, start.AddDate(0, 0, 7), start.AddDate(0, 0, 14)},
+		},
+		{
+			name:       "every other week",
+			recurrence: Recurrence{Interval: 2, Count: 3},
+			want:       []time.Time{start, start.AddDate(0, 0, 14), start.AddDate(0, 0, 28)},
+		},
+		{
+			name:       "until is inclusive",
+			recurrence: Recurrence{Interval: 1, Until: start.AddDate(0, 0, 14)},
+			want:       []time.Time{start, start.AddDate(0, 0, 7), start.AddDate(0, 0, 14)},
+		},
+		{
+			name:       "until before start",
+			recurrence: Recurrence{Interval: 1, Until: start.Add(-time.Hour)},
+			want:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.recurrence.Occurrences(start, time.UTC)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Occurrences = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("occurrence %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecurrenceOccurrencesAreCapped(t *testing.T) {
+	r := Recurrence{Interval: 1, Until: monday.AddDate(10, 0, 0)}
+
+	if got := len(r.Occurrences(monday, time.UTC)); got != MaxOccurrences {
+		t.Errorf("Occurrences returned %d times, want %d", got, MaxOccurrences)
+	}
+}
+
+func TestRecurrenceOccurrencesKeepWallClockAcrossDaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	// Clocks in Berlin move forward on 29 March 2026, between the first and second occurrence.
+	start := time.Date(2026, time.March, 23, 9, 0, 0, 0, berlin)
+	for _, occurrence := range (Recurrence{Interval: 1, Count: 3}).Occurrences(start.UTC(), berlin) {
+		if local := occurrence.In(berlin); local.Hour() != 9 || local.Weekday() != time.Monday {
+			t.Errorf("occurrence at %v, want Mondays at 9:00", local)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package scheduling
+
+import (
+	"time"
+)
+
+// WeeklyRule is a recurring opening window on a weekday. Start and End are offsets from local midnight.
+type WeeklyRule struct {
+	Weekday time.Weekday
+	Start   time.Duration
+	End     time.Duration
+}
+
+// ExceptionKind tells whether a date exception removes or adds opening hours.
+type ExceptionKind string
+
+// Exception kinds.
+const (
+	ExceptionBlock ExceptionKind = "block"
+	ExceptionExtra ExceptionKind = "extra"
+)
+
+// Exception overrides the weekly rules on every date between StartDate and EndDate inclusive.
+// It covers whole days when AllDay is set, otherwise the Start to End window of each date.
+type Exception struct {
+	Kind      ExceptionKind
+	StartDate time.Time
+	EndDate   time.Time
+	AllDay    bool
+	Start     time.Duration
+	End       time.Duration
+}
+
+// Schedule describes when a psychologist can be booked: recurring weekly opening hours
+// adjusted by date exceptions, minus busy time. A session needs BufferBefore and BufferAfter
+// of free time around it; busy intervals are expected to already include their own buffers.
+type Schedule struct {
+	Location     *time.Location
+	Weekly       []WeeklyRule
+	Exceptions   []Exception
+	Busy         []Interval
+	BufferBefore time.Duration
+	BufferAfter  time.Duration
+}
+
+// SlotOptions controls how bookable slots are generated.
+type SlotOptions struct {
+	Duration time.Duration
+	Step     time.Duration
+	// NotBefore and NotAfter bound the start of a slot; zero values leave the bound open.
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// location returns the time zone the weekly rules are expressed in.
+func (s *Schedule) location() *time.Location {
+	if s.Location == nil {
+		return time.UTC
+	}
+
+	return s.Location
+}
+
+// Open expands the weekly rules and exceptions into concrete intervals within the [from, to) window.
+func (s *Schedule) Open(from, to time.Time) []Interval {
+	loc := s.location()
+
+	// Start one day early so that rules crossing midnight are not lost.
+	day := startOfDay(from.In(loc)).AddDate(0, 0, -1)
+
+	var open []Interval
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, rule := range s.Weekly {
+			if rule.Weekday == day.Weekday() {
+				open = append(open, window(day, rule.Start, rule.End))
+			}
+		}
+	}
+
+	var blocked []Interval
+	for _, exception := range s.Exceptions {
+		intervals := exception.expand(loc)
+		if exception.Kind == ExceptionExtra {
+			open = append(open, intervals...)
+		} else {
+			blocked = append(blocked, intervals...)
+		}
+	}
+
+	return Clip(Subtract(open, blocked), from, to)
+}
+
+// Covers reports whether the interval lies entirely within the opening hours.
+func (s *Schedule) Covers(iv Interval) bool {
+	for _, open := range s.Open(iv.Start, iv.End) {
+		if open.Contains(iv) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Free returns the open intervals within the [from, to) window that are not taken by busy time.
+func (s *Schedule) Free(from, to time.Time) []Interval {
+	return Subtract(s.Open(from, to), s.Busy)
+}
+
+// Slots returns the bookable sessions within the [from, to) window.
+// Candidates start at the beginning of every open interval and advance by the step, so slots stay
+// aligned to the opening hours however the window is chosen. A candidate is kept when it and its
+// buffers do not overlap busy time.
+func (s *Schedule) Slots(from, to time.Time, opts SlotOptions) []Interval {
+	if opts.Duration <= 0 {
+		return nil
+	}
+	if opts.Step <= 0 {
+		opts.Step = opts.Duration
+	}
+
+	busy := Merge(s.Busy)
+
+	var slots []Interval
+	for _, open := range s.Open(startOfDay(from.In(s.location())), to) {
+		for start := open.Start; !start.Add(opts.Duration).After(open.End); start = start.Add(opts.Step) {
+			if start.Before(from) || (!opts.NotBefore.IsZero() && start.Before(opts.NotBefore)) {
+				continue
+			}
+			if !opts.NotAfter.IsZero() && start.After(opts.NotAfter) {
+				break
+			}
+
+			slot := Interval{Start: start, End: start.Add(opts.Duration)}
+			if !overlapsAny(s.Padded(slot), busy) {
+				slots = append(slots, slot)
+			}
+		}
+	}
+
+	return slots
+}
+
+// Bookable reports whether a session in the interval lies within the opening hours and neither it
+// nor its buffers overlap busy time.
+func (s *Schedule) Bookable(iv Interval) bool {
+	return s.Covers(iv) && !overlapsAny(s.Padded(iv), Merge(s.Busy))
+}
+
+// OnGrid reports whether the interval starts where Slots would start a candidate with the given step:
+// a whole number of steps after the beginning of an open interval that contains it.
+func (s *Schedule) OnGrid(iv Interval, step time.Duration) bool {
+	if step <= 0 {
+		step = iv.End.Sub(iv.Start)
+	}
+
+	for _, open := range s.Open(startOfDay(iv.Start.In(s.location())), iv.End) {
+		if open.Contains(iv) && iv.Start.Sub(open.Start)%step == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Padded returns the interval extended by the buffers required around a session.
+func (s *Schedule) Padded(iv Interval) Interval {
+	return Interval{Start: iv.Start.Add(-s.BufferBefore), End: iv.End.Add(s.BufferAfter)}
+}
+
+// expand returns the intervals the exception covers, with its dates interpreted in loc.
+func (e Exception) expand(loc *time.Location) []Interval {
+	first := time.Date(e.StartDate.Year(), e.StartDate.Month(), e.StartDate.Day(), 0, 0, 0, 0, loc)
+	last := time.Date(e.EndDate.Year(), e.EndDate.Month(), e.EndDate.Day(), 0, 0, 0, 0, loc)
+
+	var intervals []Interval
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if e.AllDay {
+			intervals = append(intervals, Interval{Start: day, End: day.AddDate(0, 0, 1)})
+		} else {
+			intervals = append(intervals, window(day, e.Start, e.End))
+		}
+	}
+
+	return intervals
+}
+
+// window returns the interval between two offsets from midnight of the given day.
+// An end at or before the start is taken to fall on the next day.
+func window(day time.Time, start, end time.Duration) Interval {
+	iv := Interval{Start: atOffset(day, start), End: atOffset(day, end)}
+	if end <= start {
+		iv.End = atOffset(day.AddDate(0, 0, 1), end)
+	}
+
+	return iv
+}
+
+// overlapsAny reports whether the interval overlaps any of the given sorted intervals.
+func overlapsAny(iv Interval, sorted []Interval) bool {
+	for _, other := range sorted {
+		if !other.Start.Before(iv.End) {
+			return false
+		}
+		if other.Overlaps(iv) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// startOfDay returns local midnight of the day t falls on.
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// atOffset returns the wall-clock time offset from midnight of the given day.
+// Building the time from its components keeps it correct across daylight saving changes.
+func atOffset(day time.Time, offset time.Duration) time.Time {
+	year, month, date := day.Date()
+	hours := int(offset / time.Hour)
+	minutes := int(offset % time.Hour / time.Minute)
+	seconds := int(offset % time.Minute / time.Second)
+
+	return time.Date(year, month, date, hours, minutes, seconds, 0, day.Location())
+}
@@ -0,0 +1,241 @@
+package scheduling
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// nineToFive is a schedule open from 9:00 to 17:00 on Mondays.
+func nineToFive() *Schedule {
+	return &Schedule{Weekly: []WeeklyRule{{Weekday: time.Monday, Start: 9 * time.Hour, End: 17 * time.Hour}}}
+}
+
+func TestScheduleOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule *Schedule
+		want     []Interval
+	}{
+		{
+			name:     "weekly rule",
+			schedule: nineToFive(),
+			want:     []Interval{iv(9, 0, 17, 0)},
+		},
+		{
+			name: "rule crossing midnight",
+			schedule: &Schedule{Weekly: []WeeklyRule{
+				{Weekday: time.Sunday, Start: 22 * time.Hour, End: 2 * time.Hour},
+			}},
+			want: []Interval{iv(0, 0, 2, 0)},
+		},
+		{
+			name: "blocked hours",
+			schedule: &Schedule{
+				Weekly:     nineToFive().Weekly,
+				Exceptions: []Exception{{Kind: ExceptionBlock, StartDate: monday, EndDate: monday, Start: 12 * time.Hour, End: 13 * time.Hour}},
+			},
+			want: []Interval{iv(9, 0, 12, 0), iv(13, 0, 17, 0)},
+		},
+		{
+			name: "blocked day",
+			schedule: &Schedule{
+				Weekly:     nineToFive().Weekly,
+				Exceptions: []Exception{{Kind: ExceptionBlock, StartDate: monday, EndDate: monday, AllDay: true}},
+			},
+			want: []Interval{},
+		},
+		{
+			name: "extra hours",
+			schedule: &Schedule{
+				Weekly:     nineToFive().Weekly,
+				Exceptions: []Exception{{Kind: ExceptionExtra, StartDate: monday, EndDate: monday, Start: 17 * time.Hour, End: 19 * time.Hour}},
+			},
+			want: []Interval{iv(9, 0, 19, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.Open(monday, monday.AddDate(0, 0, 1)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Open = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleOpenKeepsWallClockAcrossDaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	// Clocks in Berlin move forward on 29 March 2026.
+	schedule := &Schedule{
+		Location: berlin,
+		Weekly: []WeeklyRule{
+			{Weekday: time.Friday, Start: 9 * time.Hour, End: 10 * time.Hour},
+			{Weekday: time.Monday, Start: 9 * time.Hour, End: 10 * time.Hour},
+		},
+	}
+
+	open := schedule.Open(time.Date(2026, time.March, 27, 0, 0, 0, 0, berlin), time.Date(2026, time.March, 31, 0, 0, 0, 0, berlin))
+	if len(open) != 2 {
+		t.Fatalf("Open returned %d intervals, want 2", len(open))
+	}
+	for _, o := range open {
+		if hour := o.Start.In(berlin).Hour(); hour != 9 {
+			t.Errorf("interval starts at %d:00 local time, want 9:00", hour)
+		}
+	}
+	if offset := open[1].Start.Sub(open[0].Start); offset != 3*24*time.Hour-time.Hour {
+		t.Errorf("Monday starts %v after Friday, want 71h", offset)
+	}
+}
+
+func TestScheduleSlots(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule *Schedule
+		opts     SlotOptions
+		want     []Interval
+	}{
+		{
+			name:     "step defaults to duration",
+			schedule: &Schedule{Weekly: []WeeklyRule{{Weekday: time.Monday, Start: 9 * time.Hour, End: 12 * time.Hour}}},
+			opts:     SlotOptions{Duration: time.Hour},
+			want:     []Interval{iv(9, 0, 10, 0), iv(10, 0, 11, 0), iv(11, 0, 12, 0)},
+		},
+		{
+			name:     "shorter step",
+			schedule: &Schedule{Weekly: []WeeklyRule{{Weekday: time.Monday, Start: 9 * time.Hour, End: 11 * time.Hour}}},
+			opts:     SlotOptions{Duration: time.Hour, Step: 30 * time.Minute},
+			want:     []Interval{iv(9, 0, 10, 0), iv(9, 30, 10, 30), iv(10, 0, 11, 0)},
+		},
+		{
+			name:     "aligned to opening hours",
+			schedule: &Schedule{Weekly: []WeeklyRule{{Weekday: time.Monday, Start: 9*time.Hour + 15*time.Minute, End: 11*time.Hour + 15*time.Minute}}},
+			opts:     SlotOptions{Duration: time.Hour},
+			want:     []Interval{iv(9, 15, 10, 15), iv(10, 15, 11, 15)},
+		},
+		{
+			name: "busy time",
+			schedule: &Schedule{
+				Weekly: []WeeklyRule{{Weekday: time.Monday, Start: 9 * time.Hour, End: 12 * time.Hour}},
+				Busy:   []Interval{iv(10, 0, 11, 0)},
+			},
+			opts: SlotOptions{Duration: time.Hour},
+			want: []Interval{iv(9, 0, 10, 0), iv(11, 0, 12, 0)},
+		},
+		{
+			name: "buffers",
+			schedule: &Schedule{
+				Weekly:       []WeeklyRule{{Weekday: time.Monday, Start: 9 * time.Hour, End: 13 * time.Hour}},
+				Busy:         []Interval{iv(11, 0, 12, 0)},
+				BufferBefore: 15 * time.Minute,
+				BufferAfter:  15 * time.Minute,
+			},
+			opts: SlotOptions{Duration: time.Hour},
+			want: []Interval{iv(9, 0, 10, 0)},
+		},
+		{
+			name:     "booking window",
+			schedule: nineToFive(),
+			opts:     SlotOptions{Duration: time.Hour, NotBefore: at(10, 30), NotAfter: at(13, 0)},
+			want:     []Interval{iv(11, 0, 12, 0), iv(12, 0, 13, 0), iv(13, 0, 14, 0)},
+		},
+		{
+			name:     "no duration",
+			schedule: nineToFive(),
+			opts:     SlotOptions{},
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.Slots(monday, monday.AddDate(0, 0, 1), tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Slots = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleSlotsKeepAlignmentWhenWindowStartsMidway(t *testing.T) {
+	got := nineToFive().Slots(at(9, 10), at(12, 0), SlotOptions{Duration: time.Hour})
+	want := []Interval{iv(10, 0, 11, 0), iv(11, 0, 12, 0)}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Slots = %v, want %v", got, want)
+	}
+}
+
+func TestScheduleBookable(t *testing.T) {
+	schedule := nineToFive()
+	schedule.Busy = []Interval{iv(12, 0, 13, 0)}
+	schedule.BufferAfter = 30 * time.Minute
+
+	tests := []struct {
+		name    string
+		session Interval
+		want    bool
+	}{
+		{name: "free", session: iv(9, 0, 10, 0), want: true},
+		{name: "before opening", session: iv(8, 30, 9, 30)},
+		{name: "after closing", session: iv(16, 30, 17, 30)},
+		{name: "busy", session: iv(12, 30, 13, 30)},
+		{name: "buffer reaches busy time", session: iv(11, 0, 11, 45)},
+		{name: "buffer clears busy time", session: iv(10, 30, 11, 30), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schedule.Bookable(tt.session); got != tt.want {
+				t.Errorf("Bookable = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleOnGrid(t *testing.T) {
+	schedule := &Schedule{Weekly: []WeeklyRule{
+		{Weekday: time.Monday, Start: 9 * time.Hour, End: 12 * time.Hour},
+		{Weekday: time.Monday, Start: 13*time.Hour + 15*time.Minute, End: 17 * time.Hour},
+	}}
+
+	tests := []struct {
+		name    string
+		session Interval
+		step    time.Duration
+		want    bool
+	}{
+		{name: "opening", session: iv(9, 0, 10, 0), step: time.Hour, want: true},
+		{name: "whole steps later", session: iv(11, 0, 12, 0), step: time.Hour, want: true},
+		{name: "between steps", session: iv(9, 30, 10, 30), step: time.Hour},
+		{name: "shorter step", session: iv(9, 30, 10, 30), step: 30 * time.Minute, want: true},
+		{name: "odd minute", session: iv(9, 7, 10, 7), step: 30 * time.Minute},
+		{name: "aligned to its own opening hours", session: iv(14, 15, 15, 15), step: time.Hour, want: true},
+		{name: "aligned to other opening hours", session: iv(14, 0, 15, 0), step: time.Hour},
+		{name: "outside opening hours", session: iv(12, 0, 13, 0), step: time.Hour},
+		{name: "step defaults to session length", session: iv(10, 0, 11, 0), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schedule.OnGrid(tt.session, tt.step); got != tt.want {
+				t.Errorf("OnGrid = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleOnGridMatchesSlots(t *testing.T) {
+	schedule := nineToFive()
+	opts := SlotOptions{Duration: 50 * time.Minute, Step: 20 * time.Minute}
+
+	for _, slot := range schedule.Slots(monday, monday.AddDate(0, 0, 1), opts) {
+		if !schedule.OnGrid(slot, opts.Step) {
+			t.Errorf("slot %v is not on the grid", slot)
+		}
+	}
+}
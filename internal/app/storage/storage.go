@@ -0,0 +1,125 @@
+// Package storage keeps uploaded files behind a pluggable interface.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Errors returned by storage implementations.
+var (
+	ErrNotFound    = errors.New("file not found")
+	ErrInvalidName = errors.New("invalid file name")
+)
+
+// Storage stores files under flat names. Names never contain path separators.
+type Storage interface {
+	// Put stores the content under the name, replacing any file with the same name.
+	Put(ctx context.Context, name string, r io.Reader) error
+	// Open returns the content stored under the name.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// Delete removes the file stored under the name. Deleting a missing file is not an error.
+	Delete(ctx context.Context, name string) error
+}
+
+var defaultStorage, documentStorage Storage
+
+// SetDefault sets the storage used for uploaded files.
+func SetDefault(s Storage) {
+	defaultStorage = s
+}
+
+// Default returns the storage used for uploaded files.
+func Default() Storage {
+	return defaultStorage
+}
+
+// SetDocuments sets the storage used for private documents. It must not be served publicly.
+func SetDocuments(s Storage) {
+	documentStorage = s
+}
+
+// Documents returns the storage used for private documents.
+func Documents() Storage {
+	return documentStorage
+}
+
+// ValidName reports whether the name is a plain file name that cannot escape the storage root.
+func ValidName(name string) bool {
+	return name != "" &&
+		!strings.HasPrefix(name, ".") &&
+		!strings.ContainsAny(name, `/\`+"\x00") &&
+		filepath.Base(name) == name
+}
+
+// LocalDisk stores files in a directory on the local disk.
+type LocalDisk struct {
+	root string
+}
+
+// NewLocalDisk returns a storage that keeps files in root, creating the directory if needed.
+func NewLocalDisk(root string) (*LocalDisk, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalDisk{root: root}, nil
+}
+
+// Put writes the content to a temporary file and renames it into place, so readers never see a partial file.
+func (d *LocalDisk) Put(_ context.Context, name string, r io.Reader) error {
+	if !ValidName(name) {
+		return ErrInvalidName
+	}
+
+	tmp, err := os.CreateTemp(d.root, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(d.root, name))
+}
+
+// Open opens the file stored under the name.
+func (d *LocalDisk) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	if !ValidName(name) {
+		return nil, ErrInvalidName
+	}
+
+	f, err := os.Open(filepath.Join(d.root, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return f, err
+}
+
+// Delete removes the file stored under the name.
+func (d *LocalDisk) Delete(_ context.Context, name string) error {
+	if !ValidName(name) {
+		return ErrInvalidName
+	}
+
+	err := os.Remove(filepath.Join(d.root, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
 	"github.com/vitalicher97/psychologist_app/internal/app/api"
+	"github.com/vitalicher97/psychologist_app/internal/app/auth"
 	"github.com/vitalicher97/psychologist_app/internal/app/db"
+	"github.com/vitalicher97/psychologist_app/internal/app/db/models"
+	"github.com/vitalicher97/psychologist_app/internal/app/jobs"
+	"github.com/vitalicher97/psychologist_app/internal/app/mail"
+	"github.com/vitalicher97/psychologist_app/internal/app/middleware"
+	"github.com/vitalicher97/psychologist_app/internal/app/ratelimit"
+	"github.com/vitalicher97/psychologist_app/internal/app/storage"
 )
 
 func main() {
@@ -27,17 +37,100 @@ func main() {
 	})
 	defer db.GetConnection().Close()
 
+	// Store profile pictures on the local disk
+	pictures, err := storage.NewLocalDisk(envOrDefault("PROFILE_PICTURE_DIR", "./static/images/profile"))
+	if err != nil {
+		log.Fatalf("Failed to prepare picture storage: %v", err)
+	}
+	storage.SetDefault(pictures)
+
+	// Keep credential documents outside the publicly served directories
+	documents, err := storage.NewLocalDisk(envOrDefault("CREDENTIAL_DOCUMENT_DIR", "./data/credentials"))
+	if err != nil {
+		log.Fatalf("Failed to prepare document storage: %v", err)
+	}
+	storage.SetDocuments(documents)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Re-read external calendars that were imported from a path or URL
+	jobs.Every(ctx, "calendar imports", durationFromEnv("CALENDAR_IMPORT_INTERVAL", 15*time.Minute), models.RefreshCalendarImports)
+
+	// Pass waitlist offers that were not answered in time on to the next customer
+	models.WaitlistOfferHold = durationFromEnv("WAITLIST_OFFER_HOLD", models.WaitlistOfferHold)
+	jobs.Every(ctx, "waitlist offers", durationFromEnv("WAITLIST_EXPIRY_INTERVAL", time.Minute), models.ExpireWaitlistOffers)
+
+	models.ReviewEditWindow = durationFromEnv("REVIEW_EDIT_WINDOW", models.ReviewEditWindow)
+
+	// Sign access tokens with the secret shared by every instance of the API
+	secret := os.Getenv("JWT_SECRET")
+	if len(secret) < 32 {
+		log.Fatal("JWT_SECRET must be set to at least 32 characters")
+	}
+	auth.SetSigningKey([]byte(secret))
+	auth.AccessTokenTTL = durationFromEnv("ACCESS_TOKEN_TTL", auth.AccessTokenTTL)
+	models.RefreshTokenTTL = durationFromEnv("REFRESH_TOKEN_TTL", models.RefreshTokenTTL)
+
+	// Create the first administrator account
+	if email, password := os.Getenv("ADMIN_EMAIL"), os.Getenv("ADMIN_PASSWORD"); email != "" && password != "" {
+		if err := models.EnsureAdmin(ctx, email, password); err != nil {
+			log.Fatalf("Failed to create the administrator account: %v", err)
+		}
+	}
+
+	// Deliver email through SMTP, or keep it on disk or in memory during development
+	from := envOrDefault("MAIL_FROM", "no-reply@localhost")
+	switch sender := envOrDefault("MAIL_SENDER", "file"); sender {
+	case "smtp":
+		mail.SetDefault(mail.NewSMTP(os.Getenv("SMTP_ADDR"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from))
+	case "file":
+		sink, err := mail.NewFileSink(envOrDefault("MAIL_DIR", "./data/mail"), from)
+		if err != nil {
+			log.Fatalf("Failed to prepare the mail directory: %v", err)
+		}
+		mail.SetDefault(sink)
+	case "memory":
+		mail.SetDefault(mail.NewMemory())
+	default:
+		log.Fatalf("Invalid MAIL_SENDER: %q", sender)
+	}
+
+	api.LoginLinkURL = envOrDefault("LOGIN_LINK_URL", api.LoginLinkURL)
+	models.LoginChallengeTTL = durationFromEnv("LOGIN_CHALLENGE_TTL", models.LoginChallengeTTL)
+
+	// Count requests in this process, or in the database when several instances share the load
+	switch store := envOrDefault("RATE_LIMIT_STORE", "memory"); store {
+	case "memory":
+	case "postgres":
+		api.RateLimitStore = models.RateLimitStore{}
+		jobs.Every(ctx, "rate limit buckets", time.Hour, models.DeleteIdleRateLimitBuckets)
+	default:
+		log.Fatalf("Invalid RATE_LIMIT_STORE: %q", store)
+	}
+
+	api.DefaultRateLimits.IP = limitFromEnv("RATE_LIMIT_IP", api.DefaultRateLimits.IP)
+	api.DefaultRateLimits.APIKey = limitFromEnv("RATE_LIMIT_API_KEY", api.DefaultRateLimits.APIKey)
+	api.DefaultRateLimits.User = limitFromEnv("RATE_LIMIT_USER", api.DefaultRateLimits.User)
+	if login := limitFromEnv("RATE_LIMIT_LOGIN", ratelimit.Limit{}); !login.IsZero() {
+		api.LoginRateLimits = middleware.RateLimits{IP: login, APIKey: login, User: login}
+	}
+	api.LoginAccountRateLimit = limitFromEnv("RATE_LIMIT_LOGIN_ACCOUNT", api.LoginAccountRateLimit)
+	api.BookingRateLimits.IP = limitFromEnv("RATE_LIMIT_BOOKING", api.BookingRateLimits.IP)
+	api.BookingRateLimits.User = limitFromEnv("RATE_LIMIT_BOOKING", api.BookingRateLimits.User)
+	api.BookingRateLimits.APIKey = limitFromEnv("RATE_LIMIT_BOOKING_API_KEY", api.BookingRateLimits.APIKey)
+
 	r := gin.Default()
 
+	// Client addresses feed rate limits, login throttling and API key allowlists, so X-Forwarded-For
+	// is only believed when the request came through one of our own proxies
+	if err := r.SetTrustedProxies(listFromEnv("TRUSTED_PROXIES")); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	r.Static("/static", "./static")
 
-	r.GET("/image/:imageName", func(c *gin.Context) {
-		imageName := c.Param("imageName")
-		imagePath := "./static/images/profile/" + imageName
-
-		// Serve the image file
-		c.File(imagePath)
-	})
+	r.GET("/image/:imageName", api.GetImage)
 
 	api.SetupRoutes(r)
 
@@ -46,3 +139,54 @@ func main() {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }
+
+// durationFromEnv reads a duration such as "15m" from the environment, falling back to def.
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Fatalf("Invalid %s: %q", name, value)
+	}
+
+	return duration
+}
+
+// limitFromEnv reads a rate limit such as "100/1m" from the environment, falling back to def.
+func limitFromEnv(name string, def ratelimit.Limit) ratelimit.Limit {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+
+	limit, err := ratelimit.ParseLimit(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %q", name, value)
+	}
+
+	return limit
+}
+
+// listFromEnv reads a comma separated list from the environment. It is nil when the variable is empty.
+func listFromEnv(name string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// envOrDefault reads a variable from the environment, falling back to def when it is empty.
+func envOrDefault(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+
+	return def
+}